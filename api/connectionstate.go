@@ -19,6 +19,7 @@ const (
 	ConnectionStateCompleted                                     // The connection handshake is completed from both ends
 	ConnectionStateRemoteDeniedTrust                             // The remote service denied trust
 	ConnectionStateError                                         // The connection handshake resulted in an error
+	ConnectionStateTrustedByInstallerCA                          // The remote service was trusted automatically, as its certificate chains to an installer CA
 )
 
 // the connection state of a service and error if applicable
@@ -69,3 +70,6 @@ var ErrServiceNotPaired = errors.New("the provided SKI is not paired")
 
 // ErrConnectionNotFound that there was no active connection for a given SKI found
 var ErrConnectionNotFound = errors.New("no connection for provided SKI found")
+
+// ErrLatencyNotSupported that the connection for a given SKI does not measure round trip times
+var ErrLatencyNotSupported = errors.New("connection does not provide round trip times")
@@ -1,7 +1,7 @@
 package api
 
 //go:generate mockery
-//go:generate mockgen -destination=../mocks/mockgen_api.go -package=mocks github.com/enbility/ship-go/api MdnsInterface,HubReaderInterface
+//go:generate mockgen -destination=../mocks/mockgen_api.go -package=mocks github.com/enbility/ship-go/api MdnsInterface,HubReaderInterface,HubPublicKeyPinReaderInterface
 
 /* Hub */
 
@@ -19,6 +19,11 @@ type HubInterface interface {
 	// Provide the current pairing state for a SKI
 	PairingDetailForSki(ski string) *ConnectionStateDetail
 
+	// Provide the statistic of the buffer for SPINE messages received before the handshake completed
+	//
+	// returns ErrConnectionNotFound if there is no connection for the SKI
+	SpineBufferStatisticForSKI(ski string) (SpineBufferStatistic, error)
+
 	// Enables or disables to automatically accept incoming pairing and connection requests
 	//
 	// Default: false
@@ -37,6 +42,17 @@ type HubInterface interface {
 	CancelPairingWithSKI(ski string)
 }
 
+// optional interface for providing the round trip times of connections
+//
+// implemented by Hub
+type HubLatencyInterface interface {
+	// Provide the round trip time statistic of the connection to a SKI
+	//
+	// returns ErrConnectionNotFound if there is no connection for the SKI,
+	// ErrLatencyNotSupported if the connection does not measure round trip times
+	LatencyForSKI(ski string) (WebsocketLatency, error)
+}
+
 // Interface to pass information from the hub to the eebus service
 //
 // Implemented by eebus service implementation, used by Hub
@@ -66,3 +82,14 @@ type HubReaderInterface interface {
 	// return if the user is still able to trust the connection
 	AllowWaitingForTrust(ski string) bool
 }
+
+// Optional interface to persist the public keys pinned for paired remote services
+//
+// Implemented by eebus service implementation, used by Hub if the HubReaderInterface implementation provides it
+type HubPublicKeyPinReaderInterface interface {
+	// Provides the pin of the public key of a paired remote service, see cert.PublicKeyPin
+	// The public key is pinned on the first connection after the pairing.
+	// This needs to be persisted and restored using `ServiceDetails.SetPublicKeyPin`
+	// for future remote service connections, so a different key for the SKI is refused
+	ServicePublicKeyPinUpdate(ski string, pin string)
+}
@@ -0,0 +1,32 @@
+package api
+
+import "github.com/enbility/ship-go/model"
+
+/* Interceptor */
+
+// The direction of an intercepted SPINE payload
+type PayloadDirection string
+
+const (
+	PayloadDirectionIncoming PayloadDirection = "incoming" // received from the remote service
+	PayloadDirectionOutgoing PayloadDirection = "outgoing" // sent to the remote service
+)
+
+// Information about an intercepted SPINE payload
+type PayloadContext struct {
+	SKI         string           // the SKI of the remote service
+	Direction   PayloadDirection // the direction of the payload
+	MessageType byte             // the SHIP message type, e.g. model.MsgTypeData
+	Header      model.HeaderType // the SHIP data header
+}
+
+// Interface for observing, modifying or dropping SPINE payloads of a SHIP connection
+//
+// Implemented by the application, used by ShipConnection
+type PayloadInterceptorInterface interface {
+	// called for every SPINE payload
+	//
+	// returns the payload which is passed on, which may be modified,
+	// and false if the payload should be dropped
+	InterceptPayload(context PayloadContext, payload []byte) ([]byte, bool)
+}
@@ -1,6 +1,9 @@
 package api
 
-import "net"
+import (
+	"net"
+	"time"
+)
 
 /* Mdns */
 
@@ -25,6 +28,13 @@ type MdnsReportInterface interface {
 	ReportMdnsEntries(entries map[string]*MdnsEntry, newEntries bool)
 }
 
+// optionally implemented by the MdnsReportInterface implementation, used by mdns
+type MdnsNetworkChangeReportInterface interface {
+	// Invoked when network interfaces came up, went down or changed their addresses,
+	// so connections to paired services which are not connected should be retried
+	NetworkChanged()
+}
+
 // implemented by mdns, used by Hub
 type MdnsInterface interface {
 	Start(cb MdnsReportInterface) error
@@ -41,12 +51,53 @@ type MdnsInterface interface {
 }
 
 // implemented by mdns, used by Providers
+//
+// If remove is true and addresses are provided, only these addresses are removed,
+// otherwise the whole service is removed
 type MdnsResolveCB func(elements map[string]string, name, host string, addresses []net.IP, port int, remove bool)
 
+// implemented by mdns, used by Providers implementing MdnsProviderTTLInterface
+//
+// Like MdnsResolveCB, with a ttl defining how long the reported addresses are valid
+// if they are not reported again, 0 if the provider reports the removal of addresses itself
+type MdnsResolveTTLCB func(elements map[string]string, name, host string, addresses []net.IP, port int, ttl time.Duration, remove bool)
+
+// implemented by mdns, used by Providers
+//
+// Invoked if another service on the network uses the announced service name,
+// so the service has to be announced with another name
+type MdnsNameConflictCB func(serviceName string)
+
+// implemented by the application, used by mdns
+type MdnsAnnouncementReportInterface interface {
+	// Invoked with the service name announced on the network, if it differs from the previously
+	// announced name, e.g. "Name (2)" after another service was found using "Name"
+	ServiceNameChanged(serviceName string)
+
+	// Invoked with an error wrapping mdns.ErrClonedIdentity if another service on the network
+	// announces the SKI of the local certificate
+	AnnouncementError(err error)
+}
+
 // implemented by mdns providers, used by mdns
 type MdnsProviderInterface interface {
 	Start(autoReconnect bool, cb MdnsResolveCB) bool
 	Shutdown()
 	Announce(serviceName string, port int, txt []string) error
 	Unannounce()
+
+	// Invoked when network interfaces came up, went down or changed their addresses.
+	// The provider should announce the service and browse on the provided interfaces,
+	// which are empty if all interfaces are used
+	NetworkChanged(ifaces []net.Interface)
+
+	// Set the callback invoked if a name conflict of the announced service is detected,
+	// invoked before Announce
+	SetNameConflictCB(cb MdnsNameConflictCB)
+}
+
+// optionally implemented by mdns providers reporting how long resolved addresses are valid,
+// used by mdns instead of MdnsProviderInterface.Start
+type MdnsProviderTTLInterface interface {
+	StartWithTTL(autoReconnect bool, cb MdnsResolveTTLCB) bool
 }
@@ -26,6 +26,10 @@ type ServiceDetails struct {
 	// The EEBUS device type of the device model
 	deviceType string
 
+	// The pin of the public key first seen for the SKI, see cert.PublicKeyPin
+	// This needs to be persisted
+	publicKeyPin string
+
 	// Flags if the service auto accepts other services
 	autoAccept bool
 
@@ -100,6 +104,20 @@ func (s *ServiceDetails) SetDeviceType(deviceType string) {
 	s.deviceType = deviceType
 }
 
+func (s *ServiceDetails) PublicKeyPin() string {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	return s.publicKeyPin
+}
+
+func (s *ServiceDetails) SetPublicKeyPin(pin string) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.publicKeyPin = pin
+}
+
 func (s *ServiceDetails) AutoAccept() bool {
 	s.mux.Lock()
 	defer s.mux.Unlock()
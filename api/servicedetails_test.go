@@ -39,6 +39,9 @@ func (s *ServiceDetailsSuite) Test_ServiceDetails() {
 	details.SetDeviceType("devicetype")
 	assert.Equal(s.T(), "devicetype", details.DeviceType())
 
+	details.SetPublicKeyPin("pin")
+	assert.Equal(s.T(), "pin", details.PublicKeyPin())
+
 	details.SetAutoAccept(true)
 	assert.Equal(s.T(), true, details.AutoAccept())
 
@@ -13,6 +13,18 @@ type ShipConnectionInterface interface {
 	ApprovePendingHandshake()
 	AbortPendingHandshake()
 	ShipHandshakeState() (model.ShipMessageExchangeState, error)
+
+	// return the statistic of the buffer for SPINE messages received before the handshake completed
+	SpineBufferStatistic() SpineBufferStatistic
+}
+
+// Statistic of the buffer for SPINE messages received before the SHIP handshake completed
+type SpineBufferStatistic struct {
+	Messages          int    // the number of currently buffered messages
+	Bytes             int    // the size of the currently buffered messages
+	DroppedMessages   uint64 // the number of messages dropped because a buffer limit was exceeded
+	DroppedBytes      uint64 // the size of the messages dropped because a buffer limit was exceeded
+	DiscardedMessages uint64 // the number of buffered messages discarded because the handshake did not complete
 }
 
 // interface for getting service wide information
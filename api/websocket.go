@@ -1,5 +1,7 @@
 package api
 
+import "time"
+
 /* WebsocketConnection */
 
 // interface for handling the actual remote device data connection
@@ -19,6 +21,14 @@ type WebsocketDataWriterInterface interface {
 	IsDataConnectionClosed() (bool, error)
 }
 
+// optional interface for providing the round trip times of the data connection
+//
+// implemented by websocketConnection, used by Hub
+type WebsocketLatencyInterface interface {
+	// return the round trip time statistic of the data connection
+	Latency() WebsocketLatency
+}
+
 // interface for handling incoming data
 //
 // implemented by shipConnection, used by websocketConnection
@@ -31,4 +41,14 @@ type WebsocketDataReaderInterface interface {
 	ReportConnectionError(error)
 }
 
+// Round trip time statistic of a websocket connection, measured via ping/pong messages
+type WebsocketLatency struct {
+	Last        time.Duration // the most recent measurement
+	Min         time.Duration // the lowest measurement within the rolling window
+	Max         time.Duration // the highest measurement within the rolling window
+	Average     time.Duration // the average of the measurements within the rolling window
+	Samples     int           // the number of measurements within the rolling window
+	MissedPongs uint          // the number of consecutive pings without a pong
+}
+
 const ShipWebsocketSubProtocol = "ship" // SHIP 10.2: sub protocol is required for websocket connections
@@ -2,17 +2,20 @@ package cert
 
 //nolint:gosec
 import (
+	"bytes"
+	"crypto"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/sha1"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"math/big"
-	"time"
 ) // #nosec G505
 
 // SHIP 9.1: the ciphers are reported insecure but are defined to be used by SHIP
@@ -33,43 +36,73 @@ func CreateCertificate(organizationalUnit, organization, country, commonName str
 		return tls.Certificate{}, err
 	}
 
-	// Create the EEBUS service SKI using the public key
-	publicKey, err := privateKey.PublicKey.ECDH()
+	return CreateCertificateWithSigner(privateKey, organizationalUnit, organization, country, commonName)
+}
+
+// Create a ship compatible self signed certificate for a key which is only accessible
+// via a crypto.Signer, e.g. a key kept in a secure element
+//
+// The public key of the signer has to be an ECDSA P-256 key, the SKI is derived from it.
+// The private key is only used for signing, the returned certificate can be used
+// by the hub like certificates created by CreateCertificate.
+func CreateCertificateWithSigner(signer crypto.Signer, organizationalUnit, organization, country, commonName string) (tls.Certificate, error) {
+	return CreateCertificateWithOptions(CertificateOptions{
+		Subject: pkix.Name{
+			OrganizationalUnit: []string{organizationalUnit},
+			Organization:       []string{organization},
+			Country:            []string{country},
+			CommonName:         commonName,
+		},
+		IsCA:   true,
+		Signer: signer,
+	})
+}
+
+// returns the SKI for an ECDSA P-256 public key
+func skiForPublicKey(key crypto.PublicKey) ([]byte, error) {
+	ecdsaKey, ok := key.(*ecdsa.PublicKey)
+	if !ok || ecdsaKey.Curve != elliptic.P256() {
+		return nil, ErrUnsupportedKey
+	}
+
+	publicKey, err := ecdsaKey.ECDH()
 	if err != nil {
-		return tls.Certificate{}, err
+		return nil, err
 	}
+
 	// SHIP 12.2: Required to be created according to RFC 3280 4.2.1.2
 	// #nosec G401
 	ski := sha1.Sum(publicKey.Bytes())
 
-	subject := pkix.Name{
-		OrganizationalUnit: []string{organizationalUnit},
-		Organization:       []string{organization},
-		Country:            []string{country},
-		CommonName:         commonName,
-	}
+	return ski[:], nil
+}
 
-	// Create a random serial big int value
+// create a random serial number with up to 130 bits
+func randomSerialNumber() (*big.Int, error) {
 	maxValue := new(big.Int)
 	maxValue.Exp(big.NewInt(2), big.NewInt(130), nil).Sub(maxValue, big.NewInt(1))
-	serialNumber, err := rand.Int(rand.Reader, maxValue)
-	if err != nil {
-		return tls.Certificate{}, err
-	}
 
-	template := x509.Certificate{
-		SignatureAlgorithm:    x509.ECDSAWithSHA256,
-		SerialNumber:          serialNumber,
-		Subject:               subject,
-		NotBefore:             time.Now(),                                // Valid starting now
-		NotAfter:              time.Now().Add(time.Hour * 24 * 365 * 10), // Valid for 10 years
-		KeyUsage:              x509.KeyUsageDigitalSignature,
-		BasicConstraintsValid: true,
-		IsCA:                  true,
-		SubjectKeyId:          ski[:],
+	return rand.Int(rand.Reader, maxValue)
+}
+
+// create a self signed certificate for the private key from the template
+//
+// the SHIP mandatory properties are set, a random serial number is used if none is provided
+func issueCertificate(template *x509.Certificate, privateKey crypto.Signer) (tls.Certificate, error) {
+	if template.SerialNumber == nil {
+		serialNumber, err := randomSerialNumber()
+		if err != nil {
+			return tls.Certificate{}, err
+		}
+		template.SerialNumber = serialNumber
 	}
 
-	certBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, &privateKey.PublicKey, privateKey)
+	// SHIP 9.1: ECDSA signatures with SHA-256 are required
+	template.SignatureAlgorithm = x509.ECDSAWithSHA256
+	template.KeyUsage |= x509.KeyUsageDigitalSignature
+	template.BasicConstraintsValid = true
+
+	certBytes, err := x509.CreateCertificate(rand.Reader, template, template, privateKey.Public(), privateKey)
 	if err != nil {
 		return tls.Certificate{}, err
 	}
@@ -92,3 +125,49 @@ func SkiFromCertificate(cert *x509.Certificate) (string, error) {
 
 	return fmt.Sprintf("%0x", subjectKeyId), nil
 }
+
+// ErrSkiMismatch is returned if the SKI of a certificate is not derived from its public key
+var ErrSkiMismatch = errors.New("SKI does not match the public key of the certificate")
+
+// ErrPublicKeyPinMismatch is returned if a certificate provides a different public key than the pinned one
+var ErrPublicKeyPinMismatch = errors.New("public key does not match the pinned public key of the SKI")
+
+// Returns the SKI of the certificate, if it is the SHA-1 hash of the certificates public key
+//
+// SHIP 12.2 requires the SKI to be created according to RFC 3280 4.2.1.2, as done by CreateCertificate.
+// Otherwise any certificate could present the SKI of another service.
+func VerifiedSkiFromCertificate(cert *x509.Certificate) (string, error) {
+	ski, err := SkiFromCertificate(cert)
+	if err != nil {
+		return "", err
+	}
+
+	hash, err := skiForPublicKey(cert.PublicKey)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrSkiMismatch, err)
+	}
+
+	if !bytes.Equal(hash, cert.SubjectKeyId) {
+		return "", ErrSkiMismatch
+	}
+
+	return ski, nil
+}
+
+// Returns the pin of the certificates public key, the hex encoded SHA-256 hash of the subject public key info
+func PublicKeyPin(cert *x509.Certificate) string {
+	hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+
+	return hex.EncodeToString(hash[:])
+}
+
+// Check the public key of the certificate against a pin created by PublicKeyPin
+//
+// An empty pin matches every certificate
+func VerifyPublicKeyPin(cert *x509.Certificate, pin string) error {
+	if pin == "" || PublicKeyPin(cert) == pin {
+		return nil
+	}
+
+	return ErrPublicKeyPinMismatch
+}
@@ -9,6 +9,8 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"errors"
+	"fmt"
 	"math/big"
 	"testing"
 	"time"
@@ -53,6 +55,54 @@ func (c *CertSuite) Test_SkiFromCertificate() {
 	assert.Equal(c.T(), "", ski)
 }
 
+func (c *CertSuite) Test_VerifiedSkiFromCertificate() {
+	cert, err := CreateCertificate("", "Org", "DE", "CN")
+	assert.Nil(c.T(), err)
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	assert.Nil(c.T(), err)
+
+	ski, err := VerifiedSkiFromCertificate(leaf)
+	assert.Nil(c.T(), err)
+	assert.Equal(c.T(), fmt.Sprintf("%0x", leaf.SubjectKeyId), ski)
+
+	// a SKI which is not derived from the public key
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(c.T(), err)
+	leaf = createValidationCertificate(c, privateKey, func(t *x509.Certificate) { t.SubjectKeyId = make([]byte, 20) })
+
+	_, err = SkiFromCertificate(leaf)
+	assert.Nil(c.T(), err)
+	ski, err = VerifiedSkiFromCertificate(leaf)
+	assert.True(c.T(), errors.Is(err, ErrSkiMismatch))
+	assert.Equal(c.T(), "", ski)
+
+	// no SKI
+	leaf = createValidationCertificate(c, privateKey, func(t *x509.Certificate) { t.SubjectKeyId = nil })
+	_, err = VerifiedSkiFromCertificate(leaf)
+	assert.NotNil(c.T(), err)
+}
+
+func (c *CertSuite) Test_PublicKeyPin() {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(c.T(), err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(c.T(), err)
+
+	leaf := createValidationCertificate(c, privateKey, nil)
+	pin := PublicKeyPin(leaf)
+	assert.Len(c.T(), pin, 64)
+
+	// a new certificate for the same key has the same pin
+	renewed := createValidationCertificate(c, privateKey, func(t *x509.Certificate) { t.SerialNumber = big.NewInt(2) })
+	assert.Equal(c.T(), pin, PublicKeyPin(renewed))
+	assert.Nil(c.T(), VerifyPublicKeyPin(renewed, pin))
+
+	other := createValidationCertificate(c, otherKey, nil)
+	assert.True(c.T(), errors.Is(VerifyPublicKeyPin(other, pin), ErrPublicKeyPinMismatch))
+	assert.Nil(c.T(), VerifyPublicKeyPin(other, ""))
+}
+
 func createInvalidCertificate(organizationalUnit, organization, country, commonName string) (tls.Certificate, error) {
 	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
 	if err != nil {
@@ -0,0 +1,126 @@
+package certtest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// the object identifier of the subject key identifier extension, RFC 5280 4.2.1.2
+var oidSubjectKeyIdentifier = asn1.ObjectIdentifier{2, 5, 29, 14}
+
+// A CA with an in memory P-256 key, which simulates the CA of an installer
+// issuing the certificates of the devices of an installation
+type CA struct {
+	// The self signed certificate of the CA
+	Certificate *x509.Certificate
+
+	key *ecdsa.PrivateKey
+
+	serial int64
+
+	mux sync.Mutex
+}
+
+// Create a CA with a new P-256 key and a certificate valid for a day
+func NewCA(commonName string) (*CA, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CA{Certificate: certificate, key: key, serial: 1}, nil
+}
+
+// Returns a pool containing the certificate of the CA
+func (c *CA) Pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(c.Certificate)
+
+	return pool
+}
+
+// Issue a certificate for a PEM encoded certificate signing request
+//
+// The certificate keeps the SKI requested in the extensions of the request
+// and is valid for an hour. The update function may change the template
+// before the certificate is issued. Returns the PEM encoded certificate.
+func (c *CA) SignRequest(csrPEM []byte, update func(*x509.Certificate)) ([]byte, error) {
+	block, _ := pem.Decode(csrPEM)
+	if block == nil {
+		return nil, errors.New("no certificate request found")
+	}
+
+	request, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := request.CheckSignature(); err != nil {
+		return nil, err
+	}
+
+	c.mux.Lock()
+	c.serial++
+	serial := c.serial
+	c.mux.Unlock()
+
+	template := &x509.Certificate{
+		SignatureAlgorithm: x509.ECDSAWithSHA256,
+		SerialNumber:       big.NewInt(serial),
+		Subject:            request.Subject,
+		NotBefore:          time.Now().Add(-time.Minute),
+		NotAfter:           time.Now().Add(time.Hour),
+		KeyUsage:           x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:        []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:           request.DNSNames,
+		IPAddresses:        request.IPAddresses,
+	}
+
+	for _, extension := range request.Extensions {
+		if extension.Id.Equal(oidSubjectKeyIdentifier) {
+			if _, err := asn1.Unmarshal(extension.Value, &template.SubjectKeyId); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if update != nil {
+		update(template)
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, c.Certificate, request.PublicKey, c.key)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), nil
+}
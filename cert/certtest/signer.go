@@ -0,0 +1,58 @@
+// Package certtest provides helpers for testing the use of SHIP certificates.
+package certtest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"io"
+	"sync"
+)
+
+// A crypto.Signer with an in memory P-256 key, which simulates a key kept in a secure element
+//
+// The private key can not be exported, it is only used to create signatures.
+// Encoding functions like x509.MarshalPKCS8PrivateKey refuse the signer,
+// as it is no *ecdsa.PrivateKey.
+type Signer struct {
+	key *ecdsa.PrivateKey
+
+	signatures int
+
+	mux sync.Mutex
+}
+
+var _ crypto.Signer = (*Signer)(nil)
+
+// Create a signer with a new P-256 key
+func NewSigner() (*Signer, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Signer{key: key}, nil
+}
+
+// Returns the public key of the signer
+func (s *Signer) Public() crypto.PublicKey {
+	return &s.key.PublicKey
+}
+
+// Sign the digest with the private key
+func (s *Signer) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	s.mux.Lock()
+	s.signatures++
+	s.mux.Unlock()
+
+	return s.key.Sign(rand, digest, opts)
+}
+
+// Returns the number of signatures created by the signer
+func (s *Signer) Signatures() int {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	return s.signatures
+}
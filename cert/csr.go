@@ -0,0 +1,111 @@
+package cert
+
+import (
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+const pemTypeCertificateRequest = "CERTIFICATE REQUEST"
+
+// the object identifier of the subject key identifier extension, RFC 5280 4.2.1.2
+var oidSubjectKeyIdentifier = asn1.ObjectIdentifier{2, 5, 29, 14}
+
+// ErrSkiChanged is returned if a signed certificate does not provide the SKI of the existing certificate
+var ErrSkiChanged = errors.New("the SKI of the signed certificate does not match the existing certificate")
+
+// Create a PEM encoded certificate signing request for the key of a SHIP certificate
+//
+// The request contains the subject, the subject alternative names and the SKI
+// of the certificate, so a CA, e.g. of an installer, can issue a certificate
+// providing the same SKI. The private key may also be a crypto.Signer,
+// see CreateCertificateWithSigner.
+// Use ImportSignedCertificate to combine the issued certificate with the key.
+func CreateCertificateRequest(certificate tls.Certificate) ([]byte, error) {
+	if len(certificate.Certificate) == 0 {
+		return nil, ErrNoCertificate
+	}
+
+	leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := SkiFromCertificate(leaf); err != nil {
+		return nil, err
+	}
+
+	privateKey, err := signerForCertificate(leaf, certificate.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	ski, err := asn1.Marshal(leaf.SubjectKeyId)
+	if err != nil {
+		return nil, err
+	}
+
+	template := &x509.CertificateRequest{
+		// SHIP 9.1: ECDSA signatures with SHA-256 are required
+		SignatureAlgorithm: x509.ECDSAWithSHA256,
+		Subject:            leaf.Subject,
+		DNSNames:           leaf.DNSNames,
+		IPAddresses:        leaf.IPAddresses,
+		ExtraExtensions: []pkix.Extension{
+			{Id: oidSubjectKeyIdentifier, Value: ski},
+		},
+	}
+
+	der, err := x509.CreateCertificateRequest(rand.Reader, template, privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: pemTypeCertificateRequest, Bytes: der}), nil
+}
+
+// Combine a PEM encoded certificate chain issued for the key of an existing certificate with its private key
+//
+// The first certificate of the chain has to be issued for the public key of the existing
+// certificate and has to provide the same SKI, so pairings with remote services stay valid.
+// The chain should contain the intermediate CA certificates, if there are any.
+func ImportSignedCertificate(existing tls.Certificate, signedPEM []byte) (tls.Certificate, error) {
+	if len(existing.Certificate) == 0 {
+		return tls.Certificate{}, ErrNoCertificate
+	}
+
+	existingLeaf, err := x509.ParseCertificate(existing.Certificate[0])
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	chain := decodeCertificateChainPEM(signedPEM)
+	if len(chain) == 0 {
+		return tls.Certificate{}, ErrNoCertificate
+	}
+
+	certificate, err := newShipCertificate(chain, existing.PrivateKey)
+	if errors.Is(err, ErrSkiMismatch) {
+		// the SKI of the existing certificate is the hash of the same key
+		return tls.Certificate{}, fmt.Errorf("%w: %w", ErrSkiChanged, err)
+	}
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	existingSKI, err := SkiFromCertificate(existingLeaf)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	if ski, _ := SkiFromCertificate(certificate.Leaf); ski != existingSKI {
+		return tls.Certificate{}, ErrSkiChanged
+	}
+
+	return certificate, nil
+}
@@ -0,0 +1,122 @@
+package cert
+
+import (
+	"crypto/ecdsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+
+	"github.com/enbility/ship-go/cert/certtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func (c *CertSuite) Test_CreateCertificateRequest() {
+	existing, err := CreateCertificateWithOptions(CertificateOptions{
+		Subject:  pkix.Name{CommonName: "CN"},
+		DNSNames: []string{"device.local"},
+		IsCA:     true,
+	})
+	assert.Nil(c.T(), err)
+
+	existingLeaf, err := x509.ParseCertificate(existing.Certificate[0])
+	assert.Nil(c.T(), err)
+
+	csrPEM, err := CreateCertificateRequest(existing)
+	assert.Nil(c.T(), err)
+
+	block, _ := pem.Decode(csrPEM)
+	if assert.NotNil(c.T(), block) {
+		assert.Equal(c.T(), "CERTIFICATE REQUEST", block.Type)
+
+		request, err := x509.ParseCertificateRequest(block.Bytes)
+		assert.Nil(c.T(), err)
+		assert.Nil(c.T(), request.CheckSignature())
+		assert.Equal(c.T(), "CN", request.Subject.CommonName)
+		assert.Equal(c.T(), []string{"device.local"}, request.DNSNames)
+		assert.Equal(c.T(), x509.ECDSAWithSHA256, request.SignatureAlgorithm)
+		assert.True(c.T(), existingLeaf.PublicKey.(*ecdsa.PublicKey).Equal(request.PublicKey))
+	}
+
+	ca, err := certtest.NewCA("Installer CA")
+	assert.Nil(c.T(), err)
+
+	signedPEM, err := ca.SignRequest(csrPEM, nil)
+	assert.Nil(c.T(), err)
+
+	imported, err := ImportSignedCertificate(existing, signedPEM)
+	assert.Nil(c.T(), err)
+	assert.Equal(c.T(), existing.PrivateKey, imported.PrivateKey)
+	assert.Equal(c.T(), existingLeaf.SubjectKeyId, imported.Leaf.SubjectKeyId)
+	assert.Equal(c.T(), "Installer CA", imported.Leaf.Issuer.CommonName)
+
+	_, err = imported.Leaf.Verify(x509.VerifyOptions{Roots: ca.Pool()})
+	assert.Nil(c.T(), err)
+
+	// the signed certificate conforms to SHIP, except for the self signature
+	for _, finding := range ValidateShipCertificate(imported.Leaf) {
+		assert.Equal(c.T(), CheckSelfSignature, finding.Check)
+		assert.Equal(c.T(), SeverityWarning, finding.Severity)
+	}
+
+	// a signer as private key
+	signer, err := certtest.NewSigner()
+	assert.Nil(c.T(), err)
+	existing, err = CreateCertificateWithSigner(signer, "unit", "org", "DE", "CN")
+	assert.Nil(c.T(), err)
+
+	csrPEM, err = CreateCertificateRequest(existing)
+	assert.Nil(c.T(), err)
+	signedPEM, err = ca.SignRequest(csrPEM, nil)
+	assert.Nil(c.T(), err)
+	imported, err = ImportSignedCertificate(existing, signedPEM)
+	assert.Nil(c.T(), err)
+	assert.Equal(c.T(), signer, imported.PrivateKey)
+}
+
+func (c *CertSuite) Test_CreateCertificateRequest_Invalid() {
+	_, err := CreateCertificateRequest(tls.Certificate{})
+	assert.ErrorIs(c.T(), err, ErrNoCertificate)
+
+	existing, err := CreateCertificate("unit", "org", "DE", "CN")
+	assert.Nil(c.T(), err)
+	other, err := CreateCertificate("unit", "org", "DE", "CN")
+	assert.Nil(c.T(), err)
+
+	existing.PrivateKey = other.PrivateKey
+	_, err = CreateCertificateRequest(existing)
+	assert.ErrorIs(c.T(), err, ErrKeyMismatch)
+}
+
+func (c *CertSuite) Test_ImportSignedCertificate_Invalid() {
+	ca, err := certtest.NewCA("Installer CA")
+	assert.Nil(c.T(), err)
+
+	existing, err := CreateCertificate("unit", "org", "DE", "CN")
+	assert.Nil(c.T(), err)
+	other, err := CreateCertificate("unit", "org", "DE", "CN")
+	assert.Nil(c.T(), err)
+
+	csrPEM, err := CreateCertificateRequest(existing)
+	assert.Nil(c.T(), err)
+
+	_, err = ImportSignedCertificate(tls.Certificate{}, nil)
+	assert.ErrorIs(c.T(), err, ErrNoCertificate)
+
+	_, err = ImportSignedCertificate(existing, []byte("no pem"))
+	assert.ErrorIs(c.T(), err, ErrNoCertificate)
+
+	// issued for another key
+	signedPEM, err := ca.SignRequest(csrPEM, nil)
+	assert.Nil(c.T(), err)
+	_, err = ImportSignedCertificate(other, signedPEM)
+	assert.ErrorIs(c.T(), err, ErrKeyMismatch)
+
+	// the CA replaced the SKI
+	signedPEM, err = ca.SignRequest(csrPEM, func(t *x509.Certificate) {
+		t.SubjectKeyId = make([]byte, 20)
+	})
+	assert.Nil(c.T(), err)
+	_, err = ImportSignedCertificate(existing, signedPEM)
+	assert.ErrorIs(c.T(), err, ErrSkiChanged)
+}
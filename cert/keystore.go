@@ -0,0 +1,292 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	encryptedKeyVersion = 1
+	encryptedKeyKDF     = "argon2id"
+	encryptedKeyCipher  = "aes-256-gcm"
+
+	// the key length of AES-256
+	encryptedKeyLength = 32
+
+	// the upper limit of the KDF memory accepted when decrypting, in KiB
+	maxKeyEncryptionMemory = 4 * 1024 * 1024
+)
+
+// bound to the ciphertext, so the envelope can not be used in another context
+var encryptedKeyAdditionalData = []byte("ship-go encrypted private key")
+
+// ErrInvalidPassphrase is returned if an encrypted private key can not be decrypted with the passphrase
+var ErrInvalidPassphrase = errors.New("invalid passphrase or corrupted private key")
+
+// ErrEmptyPassphrase is returned if an empty passphrase is used to encrypt a private key
+var ErrEmptyPassphrase = errors.New("the passphrase must not be empty")
+
+// ErrInvalidEncryptedKey is returned if the encrypted private key data is malformed or uses unsupported parameters
+var ErrInvalidEncryptedKey = errors.New("invalid encrypted private key")
+
+// The parameters of the Argon2id key derivation used to encrypt private keys
+//
+// The defaults follow the recommendation of RFC 9106 for memory constrained environments.
+// Devices with little memory may reduce Memory, at the cost of a weaker protection.
+type KeyEncryptionOptions struct {
+	// The number of passes over the memory
+	Time uint32
+
+	// The memory used in KiB
+	Memory uint32
+
+	// The number of threads
+	Threads uint8
+}
+
+// Returns the default parameters of the key derivation
+func DefaultKeyEncryptionOptions() KeyEncryptionOptions {
+	return KeyEncryptionOptions{
+		Time:    3,
+		Memory:  64 * 1024,
+		Threads: 4,
+	}
+}
+
+func (o KeyEncryptionOptions) validate() error {
+	if o.Time < 1 || o.Threads < 1 || o.Memory < 8*uint32(o.Threads) || o.Memory > maxKeyEncryptionMemory {
+		return fmt.Errorf("%w: unsupported key derivation parameters", ErrInvalidEncryptedKey)
+	}
+
+	return nil
+}
+
+// the envelope of an encrypted private key
+type encryptedKey struct {
+	Version int `json:"version"`
+
+	KDF     string `json:"kdf"`
+	Salt    []byte `json:"salt"`
+	Time    uint32 `json:"time"`
+	Memory  uint32 `json:"memory"`
+	Threads uint8  `json:"threads"`
+
+	Cipher     string `json:"cipher"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+func (e *encryptedKey) aead(passphrase []byte) (cipher.AEAD, error) {
+	key := argon2.IDKey(passphrase, e.Salt, e.Time, e.Memory, e.Threads, encryptedKeyLength)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// Encrypt a private key with a passphrase
+//
+// The PKCS #8 encoded key is encrypted using AES-256-GCM with a key derived from the
+// passphrase using Argon2id. The result is a JSON document containing the parameters
+// needed for decryption. If opts is nil, DefaultKeyEncryptionOptions is used.
+func EncryptPrivateKey(privateKey crypto.PrivateKey, passphrase []byte, opts *KeyEncryptionOptions) ([]byte, error) {
+	if len(passphrase) == 0 {
+		return nil, ErrEmptyPassphrase
+	}
+
+	options := DefaultKeyEncryptionOptions()
+	if opts != nil {
+		options = *opts
+	}
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+
+	plaintext, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnsupportedKey, err)
+	}
+
+	envelope := &encryptedKey{
+		Version: encryptedKeyVersion,
+		KDF:     encryptedKeyKDF,
+		Salt:    make([]byte, 16),
+		Time:    options.Time,
+		Memory:  options.Memory,
+		Threads: options.Threads,
+		Cipher:  encryptedKeyCipher,
+	}
+	if _, err := rand.Read(envelope.Salt); err != nil {
+		return nil, err
+	}
+
+	aead, err := envelope.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	envelope.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(envelope.Nonce); err != nil {
+		return nil, err
+	}
+	envelope.Ciphertext = aead.Seal(nil, envelope.Nonce, plaintext, encryptedKeyAdditionalData)
+
+	return json.MarshalIndent(envelope, "", "  ")
+}
+
+// Decrypt a private key created by EncryptPrivateKey
+func DecryptPrivateKey(data, passphrase []byte) (crypto.PrivateKey, error) {
+	var envelope encryptedKey
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidEncryptedKey, err)
+	}
+
+	if envelope.Version != encryptedKeyVersion || envelope.KDF != encryptedKeyKDF || envelope.Cipher != encryptedKeyCipher {
+		return nil, fmt.Errorf("%w: unsupported version %d, %s, %s", ErrInvalidEncryptedKey, envelope.Version, envelope.KDF, envelope.Cipher)
+	}
+
+	options := KeyEncryptionOptions{Time: envelope.Time, Memory: envelope.Memory, Threads: envelope.Threads}
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+
+	aead, err := envelope.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(envelope.Nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("%w: invalid nonce", ErrInvalidEncryptedKey)
+	}
+
+	plaintext, err := aead.Open(nil, envelope.Nonce, envelope.Ciphertext, encryptedKeyAdditionalData)
+	if err != nil {
+		return nil, ErrInvalidPassphrase
+	}
+
+	return x509.ParsePKCS8PrivateKey(plaintext)
+}
+
+// Decrypt the private key and encrypt it again with a new passphrase
+func ChangeKeyPassphrase(data, oldPassphrase, newPassphrase []byte, opts *KeyEncryptionOptions) ([]byte, error) {
+	privateKey, err := DecryptPrivateKey(data, oldPassphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	return EncryptPrivateKey(privateKey, newPassphrase, opts)
+}
+
+// Decode a PEM encoded certificate chain and an encrypted private key into a SHIP ready certificate
+//
+// See DecodeCertificatePEM for the checks applied
+func DecodeEncryptedCertificate(certPEM, encryptedKey, passphrase []byte) (tls.Certificate, error) {
+	chain := decodeCertificateChainPEM(certPEM)
+	if len(chain) == 0 {
+		return tls.Certificate{}, ErrNoCertificate
+	}
+
+	privateKey, err := DecryptPrivateKey(encryptedKey, passphrase)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	return newShipCertificate(chain, privateKey)
+}
+
+// Write the certificate chain as PEM and the private key encrypted with the passphrase to files
+//
+// Existing files are replaced, the private key file is only readable by the owner
+func SaveEncryptedCertificate(certificate tls.Certificate, certPath, keyPath string, passphrase []byte, opts *KeyEncryptionOptions) error {
+	certPEM, _, err := EncodeCertificatePEM(certificate)
+	if err != nil {
+		return err
+	}
+
+	encryptedKey, err := EncryptPrivateKey(certificate.PrivateKey, passphrase, opts)
+	if err != nil {
+		return err
+	}
+
+	return writeCertificateFiles(certPath, certPEM, keyPath, encryptedKey)
+}
+
+// Load a certificate chain from a PEM file and the private key from an encrypted file
+// into a SHIP ready certificate
+func LoadEncryptedCertificate(certPath, keyPath string, passphrase []byte) (tls.Certificate, error) {
+	certPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	encryptedKey, err := os.ReadFile(keyPath)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	certificate, err := DecodeEncryptedCertificate(certPEM, encryptedKey, passphrase)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("%s: %w", keyPath, err)
+	}
+
+	return certificate, nil
+}
+
+// Change the passphrase of an encrypted private key file
+//
+// The file is replaced atomically, so the key is not lost if writing fails
+func ChangeKeyFilePassphrase(keyPath string, oldPassphrase, newPassphrase []byte, opts *KeyEncryptionOptions) error {
+	encryptedKey, err := os.ReadFile(keyPath)
+	if err != nil {
+		return err
+	}
+
+	encryptedKey, err = ChangeKeyPassphrase(encryptedKey, oldPassphrase, newPassphrase, opts)
+	if err != nil {
+		return fmt.Errorf("%s: %w", keyPath, err)
+	}
+
+	return writeFileAtomic(keyPath, encryptedKey, 0o600)
+}
+
+// write the data into a temporary file, which replaces the file at path
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmpPath := file.Name()
+
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpPath, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+	}
+
+	return err
+}
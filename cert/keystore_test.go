@@ -0,0 +1,168 @@
+package cert
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/enbility/ship-go/cert/certtest"
+	"github.com/stretchr/testify/assert"
+)
+
+// fast parameters for the tests
+var testKeyEncryptionOptions = &KeyEncryptionOptions{Time: 1, Memory: 8 * 1024, Threads: 1}
+
+func (c *CertSuite) Test_EncryptDecryptPrivateKey() {
+	certificate, err := CreateCertificate("unit", "org", "DE", "CN")
+	assert.Nil(c.T(), err)
+
+	passphrase := []byte("secret")
+	data, err := EncryptPrivateKey(certificate.PrivateKey, passphrase, testKeyEncryptionOptions)
+	assert.Nil(c.T(), err)
+
+	// the key is not stored in plaintext
+	_, keyPEM, err := EncodeCertificatePEM(certificate)
+	assert.Nil(c.T(), err)
+	assert.NotContains(c.T(), string(data), string(keyPEM))
+
+	var envelope map[string]any
+	assert.Nil(c.T(), json.Unmarshal(data, &envelope))
+	assert.Equal(c.T(), "argon2id", envelope["kdf"])
+	assert.Equal(c.T(), "aes-256-gcm", envelope["cipher"])
+
+	privateKey, err := DecryptPrivateKey(data, passphrase)
+	assert.Nil(c.T(), err)
+	assert.True(c.T(), certificate.PrivateKey.(*ecdsa.PrivateKey).Equal(privateKey))
+
+	_, err = DecryptPrivateKey(data, []byte("wrong"))
+	assert.ErrorIs(c.T(), err, ErrInvalidPassphrase)
+
+	// a new salt and nonce is used for every encryption
+	other, err := EncryptPrivateKey(certificate.PrivateKey, passphrase, testKeyEncryptionOptions)
+	assert.Nil(c.T(), err)
+	assert.NotEqual(c.T(), data, other)
+
+	_, err = EncryptPrivateKey(certificate.PrivateKey, nil, testKeyEncryptionOptions)
+	assert.ErrorIs(c.T(), err, ErrEmptyPassphrase)
+
+	_, err = EncryptPrivateKey(certificate.PrivateKey, passphrase, &KeyEncryptionOptions{Time: 1, Memory: 1, Threads: 1})
+	assert.ErrorIs(c.T(), err, ErrInvalidEncryptedKey)
+
+	signer, err := certtest.NewSigner()
+	assert.Nil(c.T(), err)
+	_, err = EncryptPrivateKey(signer, passphrase, testKeyEncryptionOptions)
+	assert.ErrorIs(c.T(), err, ErrUnsupportedKey)
+}
+
+func (c *CertSuite) Test_DecryptPrivateKey_Invalid() {
+	certificate, err := CreateCertificate("unit", "org", "DE", "CN")
+	assert.Nil(c.T(), err)
+
+	passphrase := []byte("secret")
+	data, err := EncryptPrivateKey(certificate.PrivateKey, passphrase, testKeyEncryptionOptions)
+	assert.Nil(c.T(), err)
+
+	_, err = DecryptPrivateKey([]byte("no json"), passphrase)
+	assert.ErrorIs(c.T(), err, ErrInvalidEncryptedKey)
+
+	modify := func(update func(envelope *encryptedKey)) []byte {
+		var envelope encryptedKey
+		assert.Nil(c.T(), json.Unmarshal(data, &envelope))
+		update(&envelope)
+		modified, err := json.Marshal(envelope)
+		assert.Nil(c.T(), err)
+		return modified
+	}
+
+	_, err = DecryptPrivateKey(modify(func(e *encryptedKey) { e.Version = 2 }), passphrase)
+	assert.ErrorIs(c.T(), err, ErrInvalidEncryptedKey)
+
+	// parameters which would require too much memory are refused
+	_, err = DecryptPrivateKey(modify(func(e *encryptedKey) { e.Memory = maxKeyEncryptionMemory + 1 }), passphrase)
+	assert.ErrorIs(c.T(), err, ErrInvalidEncryptedKey)
+
+	_, err = DecryptPrivateKey(modify(func(e *encryptedKey) { e.Nonce = e.Nonce[1:] }), passphrase)
+	assert.ErrorIs(c.T(), err, ErrInvalidEncryptedKey)
+
+	_, err = DecryptPrivateKey(modify(func(e *encryptedKey) { e.Ciphertext[0] ^= 0xff }), passphrase)
+	assert.ErrorIs(c.T(), err, ErrInvalidPassphrase)
+}
+
+func (c *CertSuite) Test_ChangeKeyPassphrase() {
+	certificate, err := CreateCertificate("unit", "org", "DE", "CN")
+	assert.Nil(c.T(), err)
+
+	data, err := EncryptPrivateKey(certificate.PrivateKey, []byte("old"), testKeyEncryptionOptions)
+	assert.Nil(c.T(), err)
+
+	rotated, err := ChangeKeyPassphrase(data, []byte("old"), []byte("new"), testKeyEncryptionOptions)
+	assert.Nil(c.T(), err)
+
+	_, err = DecryptPrivateKey(rotated, []byte("old"))
+	assert.ErrorIs(c.T(), err, ErrInvalidPassphrase)
+
+	privateKey, err := DecryptPrivateKey(rotated, []byte("new"))
+	assert.Nil(c.T(), err)
+	assert.True(c.T(), certificate.PrivateKey.(*ecdsa.PrivateKey).Equal(privateKey))
+
+	_, err = ChangeKeyPassphrase(data, []byte("wrong"), []byte("new"), testKeyEncryptionOptions)
+	assert.ErrorIs(c.T(), err, ErrInvalidPassphrase)
+}
+
+func (c *CertSuite) Test_SaveLoadEncryptedCertificate() {
+	certificate, err := CreateCertificate("unit", "org", "DE", "CN")
+	assert.Nil(c.T(), err)
+
+	dir := c.T().TempDir()
+	certPath := filepath.Join(dir, "cert.pem")
+	keyPath := filepath.Join(dir, "key.json")
+
+	err = SaveEncryptedCertificate(certificate, certPath, keyPath, []byte("old"), testKeyEncryptionOptions)
+	assert.Nil(c.T(), err)
+
+	info, err := os.Stat(keyPath)
+	assert.Nil(c.T(), err)
+	assert.Equal(c.T(), os.FileMode(0o600), info.Mode().Perm())
+
+	loaded, err := LoadEncryptedCertificate(certPath, keyPath, []byte("old"))
+	assert.Nil(c.T(), err)
+	assert.Equal(c.T(), certificate.Certificate, loaded.Certificate)
+	assert.Equal(c.T(), certificate.PrivateKey, loaded.PrivateKey)
+	assert.NotNil(c.T(), loaded.Leaf)
+
+	_, err = LoadEncryptedCertificate(certPath, keyPath, []byte("new"))
+	assert.ErrorIs(c.T(), err, ErrInvalidPassphrase)
+
+	err = ChangeKeyFilePassphrase(keyPath, []byte("old"), []byte("new"), testKeyEncryptionOptions)
+	assert.Nil(c.T(), err)
+
+	loaded, err = LoadEncryptedCertificate(certPath, keyPath, []byte("new"))
+	assert.Nil(c.T(), err)
+	assert.Equal(c.T(), certificate.PrivateKey, loaded.PrivateKey)
+
+	// no temporary files are left
+	entries, err := os.ReadDir(dir)
+	assert.Nil(c.T(), err)
+	assert.Len(c.T(), entries, 2)
+
+	// the certificate file is replaced atomically and kept if the key can not be written
+	other, err := CreateCertificate("unit", "org", "DE", "other")
+	assert.Nil(c.T(), err)
+	err = SaveEncryptedCertificate(other, certPath, filepath.Join(dir, "missing", "key.json"), []byte("new"), testKeyEncryptionOptions)
+	assert.NotNil(c.T(), err)
+
+	loaded, err = LoadEncryptedCertificate(certPath, keyPath, []byte("new"))
+	assert.Nil(c.T(), err)
+	assert.Equal(c.T(), certificate.Certificate, loaded.Certificate)
+
+	entries, err = os.ReadDir(dir)
+	assert.Nil(c.T(), err)
+	assert.Len(c.T(), entries, 2)
+
+	err = ChangeKeyFilePassphrase(keyPath, []byte("old"), []byte("other"), testKeyEncryptionOptions)
+	assert.ErrorIs(c.T(), err, ErrInvalidPassphrase)
+
+	_, err = LoadEncryptedCertificate(filepath.Join(dir, "missing.pem"), keyPath, []byte("new"))
+	assert.NotNil(c.T(), err)
+}
@@ -0,0 +1,130 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"slices"
+	"time"
+)
+
+// The default validity period of created and renewed certificates
+const DefaultValidity = time.Hour * 24 * 365 * 10
+
+// ErrInvalidCertificateOptions is returned if the options would create a certificate violating SHIP
+var ErrInvalidCertificateOptions = errors.New("invalid certificate options")
+
+// Options for creating a certificate
+//
+// The SHIP mandatory properties can not be changed: the key is an ECDSA P-256 key,
+// the certificate is signed with ECDSA-SHA256, the key usage contains the digital
+// signature and the SKI is the SHA-1 hash of the public key.
+type CertificateOptions struct {
+	// The subject of the certificate, including optional fields like Locality or SerialNumber
+	// Example for CommonName: "deviceModel-deviceSerialNumber"
+	Subject pkix.Name
+
+	// Optional, the start of the validity period, defaults to now
+	NotBefore time.Time
+
+	// Optional, the validity period, defaults to DefaultValidity
+	Validity time.Duration
+
+	// Optional, the subject alternative names, e.g. the hostname of the device
+	DNSNames    []string
+	IPAddresses []net.IP
+
+	// Optional, the serial number, defaults to a random value.
+	// It has to be positive and may have up to 20 bytes (RFC 5280 4.1.2.2)
+	SerialNumber *big.Int
+
+	// Optional, additional key usages, the digital signature is always included
+	KeyUsage x509.KeyUsage
+
+	// Optional, the extended key usages. If set, server and client authentication
+	// have to be included, as the certificate is used for both sides of connections
+	ExtKeyUsage []x509.ExtKeyUsage
+
+	// Mark the certificate as CA certificate, as done by CreateCertificate
+	IsCA bool
+
+	// Optional, the private key, a new P-256 key is generated if not provided.
+	// The public key has to be an ECDSA P-256 key
+	Signer crypto.Signer
+}
+
+// Create a ship compatible self signed certificate using the options
+func CreateCertificateWithOptions(opts CertificateOptions) (tls.Certificate, error) {
+	if err := opts.validate(); err != nil {
+		return tls.Certificate{}, err
+	}
+
+	signer := opts.Signer
+	if signer == nil {
+		privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return tls.Certificate{}, err
+		}
+		signer = privateKey
+	}
+
+	// Create the EEBUS service SKI using the public key
+	ski, err := skiForPublicKey(signer.Public())
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	notBefore := opts.NotBefore
+	if notBefore.IsZero() {
+		notBefore = time.Now()
+	}
+
+	validity := opts.Validity
+	if validity == 0 {
+		validity = DefaultValidity
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: opts.SerialNumber,
+		Subject:      opts.Subject,
+		NotBefore:    notBefore,
+		NotAfter:     notBefore.Add(validity),
+		KeyUsage:     opts.KeyUsage,
+		ExtKeyUsage:  slices.Clone(opts.ExtKeyUsage),
+		IsCA:         opts.IsCA,
+		DNSNames:     slices.Clone(opts.DNSNames),
+		IPAddresses:  slices.Clone(opts.IPAddresses),
+		SubjectKeyId: ski,
+	}
+
+	return issueCertificate(template, signer)
+}
+
+func (o CertificateOptions) validate() error {
+	if o.Validity < 0 {
+		return fmt.Errorf("%w: negative validity", ErrInvalidCertificateOptions)
+	}
+
+	if o.SerialNumber != nil && (o.SerialNumber.Sign() <= 0 || len(o.SerialNumber.Bytes()) > 20) {
+		return fmt.Errorf("%w: the serial number has to be positive and may have up to 20 bytes", ErrInvalidCertificateOptions)
+	}
+
+	if o.KeyUsage&x509.KeyUsageCertSign != 0 && !o.IsCA {
+		return fmt.Errorf("%w: the certificate signing key usage requires a CA certificate", ErrInvalidCertificateOptions)
+	}
+
+	if len(o.ExtKeyUsage) > 0 && !slices.Contains(o.ExtKeyUsage, x509.ExtKeyUsageAny) &&
+		(!slices.Contains(o.ExtKeyUsage, x509.ExtKeyUsageServerAuth) || !slices.Contains(o.ExtKeyUsage, x509.ExtKeyUsageClientAuth)) {
+		return fmt.Errorf("%w: the extended key usage has to include server and client authentication", ErrInvalidCertificateOptions)
+	}
+
+	return nil
+}
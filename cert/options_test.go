@@ -0,0 +1,109 @@
+package cert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"time"
+
+	"github.com/enbility/ship-go/cert/certtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func (c *CertSuite) Test_CreateCertificateWithOptions() {
+	notBefore := time.Now().Add(-time.Hour).Truncate(time.Second)
+	certificate, err := CreateCertificateWithOptions(CertificateOptions{
+		Subject: pkix.Name{
+			CommonName:   "Model-1234",
+			Organization: []string{"org"},
+			Locality:     []string{"Berlin"},
+			SerialNumber: "1234",
+		},
+		NotBefore:    notBefore,
+		Validity:     365 * 24 * time.Hour,
+		DNSNames:     []string{"device.local"},
+		IPAddresses:  []net.IP{net.ParseIP("192.168.1.10")},
+		SerialNumber: big.NewInt(42),
+		KeyUsage:     x509.KeyUsageKeyAgreement,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	})
+	assert.Nil(c.T(), err)
+
+	leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+	assert.Nil(c.T(), err)
+
+	assert.Equal(c.T(), "Model-1234", leaf.Subject.CommonName)
+	assert.Equal(c.T(), []string{"Berlin"}, leaf.Subject.Locality)
+	assert.Equal(c.T(), "1234", leaf.Subject.SerialNumber)
+	assert.True(c.T(), notBefore.Equal(leaf.NotBefore))
+	assert.True(c.T(), notBefore.Add(365*24*time.Hour).Equal(leaf.NotAfter))
+	assert.Equal(c.T(), []string{"device.local"}, leaf.DNSNames)
+	assert.True(c.T(), net.ParseIP("192.168.1.10").Equal(leaf.IPAddresses[0]))
+	assert.Equal(c.T(), big.NewInt(42), leaf.SerialNumber)
+	assert.Equal(c.T(), x509.KeyUsageDigitalSignature|x509.KeyUsageKeyAgreement, leaf.KeyUsage)
+	assert.False(c.T(), leaf.IsCA)
+
+	// SHIP mandatory properties
+	assert.Equal(c.T(), x509.ECDSAWithSHA256, leaf.SignatureAlgorithm)
+	_, err = VerifiedSkiFromCertificate(leaf)
+	assert.Nil(c.T(), err)
+	assert.Empty(c.T(), ValidateShipCertificate(leaf))
+
+	// renewing keeps the extensions
+	renewed, err := RenewCertificate(certificate, RenewOptions{})
+	assert.Nil(c.T(), err)
+	renewedLeaf, err := x509.ParseCertificate(renewed.Certificate[0])
+	assert.Nil(c.T(), err)
+	assert.Equal(c.T(), leaf.DNSNames, renewedLeaf.DNSNames)
+	assert.Equal(c.T(), leaf.ExtKeyUsage, renewedLeaf.ExtKeyUsage)
+	assert.Equal(c.T(), leaf.SubjectKeyId, renewedLeaf.SubjectKeyId)
+}
+
+func (c *CertSuite) Test_CreateCertificateWithOptions_Defaults() {
+	signer, err := certtest.NewSigner()
+	assert.Nil(c.T(), err)
+
+	certificate, err := CreateCertificateWithOptions(CertificateOptions{
+		Subject: pkix.Name{CommonName: "CN"},
+		Signer:  signer,
+	})
+	assert.Nil(c.T(), err)
+	assert.Equal(c.T(), signer, certificate.PrivateKey)
+
+	leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+	assert.Nil(c.T(), err)
+	assert.Equal(c.T(), x509.KeyUsageDigitalSignature, leaf.KeyUsage)
+	assert.Equal(c.T(), 1, leaf.SerialNumber.Sign())
+	assert.WithinDuration(c.T(), time.Now().Add(DefaultValidity), leaf.NotAfter, time.Minute)
+}
+
+func (c *CertSuite) Test_CreateCertificateWithOptions_Invalid() {
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.Nil(c.T(), err)
+
+	_, err = CreateCertificateWithOptions(CertificateOptions{Signer: p384Key})
+	assert.ErrorIs(c.T(), err, ErrUnsupportedKey)
+
+	tests := []CertificateOptions{
+		{Validity: -time.Hour},
+		{SerialNumber: big.NewInt(0)},
+		{SerialNumber: big.NewInt(-1)},
+		{SerialNumber: new(big.Int).Lsh(big.NewInt(1), 160)},
+		{KeyUsage: x509.KeyUsageCertSign},
+		{ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}},
+	}
+
+	for _, opts := range tests {
+		_, err := CreateCertificateWithOptions(opts)
+		assert.ErrorIs(c.T(), err, ErrInvalidCertificateOptions, opts)
+	}
+
+	_, err = CreateCertificateWithOptions(CertificateOptions{KeyUsage: x509.KeyUsageCertSign, IsCA: true})
+	assert.Nil(c.T(), err)
+	_, err = CreateCertificateWithOptions(CertificateOptions{ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}})
+	assert.Nil(c.T(), err)
+}
@@ -0,0 +1,216 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+const (
+	pemTypeCertificate  = "CERTIFICATE"
+	pemTypePrivateKey   = "PRIVATE KEY"
+	pemTypeECPrivateKey = "EC PRIVATE KEY"
+)
+
+// ErrNoCertificate is returned if the PEM data does not contain a certificate
+var ErrNoCertificate = errors.New("no certificate found")
+
+// ErrNoPrivateKey is returned if the PEM data does not contain a private key
+var ErrNoPrivateKey = errors.New("no private key found")
+
+// ErrUnsupportedKey is returned if the key is not an ECDSA P-256 key as required by SHIP
+var ErrUnsupportedKey = errors.New("unsupported key, SHIP requires an ECDSA P-256 key")
+
+// ErrKeyMismatch is returned if the private key does not belong to the certificate
+var ErrKeyMismatch = errors.New("private key does not match the certificate")
+
+// Encode the certificate chain and the private key as PEM
+//
+// The private key is encoded as PKCS #8. Keys which are only accessible via
+// a crypto.Signer can not be encoded, ErrUnsupportedKey is returned for them
+func EncodeCertificatePEM(certificate tls.Certificate) (certPEM, keyPEM []byte, err error) {
+	if len(certificate.Certificate) == 0 {
+		return nil, nil, ErrNoCertificate
+	}
+
+	privateKey, ok := certificate.PrivateKey.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, nil, ErrUnsupportedKey
+	}
+
+	for _, der := range certificate.Certificate {
+		certPEM = append(certPEM, pem.EncodeToMemory(&pem.Block{Type: pemTypeCertificate, Bytes: der})...)
+	}
+
+	keyBytes, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: pemTypePrivateKey, Bytes: keyBytes})
+
+	return certPEM, keyPEM, nil
+}
+
+// Decode a PEM encoded certificate chain and private key into a SHIP ready certificate
+//
+// The private key may be encoded as PKCS #8 or SEC 1. The first certificate has to
+// provide a SKI matching its public key and has to match the private key.
+func DecodeCertificatePEM(certPEM, keyPEM []byte) (tls.Certificate, error) {
+	chain := decodeCertificateChainPEM(certPEM)
+	if len(chain) == 0 {
+		return tls.Certificate{}, ErrNoCertificate
+	}
+
+	privateKey, err := decodePrivateKeyPEM(keyPEM)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	return newShipCertificate(chain, privateKey)
+}
+
+// Decode a PEM encoded certificate chain into a SHIP ready certificate using a crypto.Signer
+// as private key, e.g. for a key kept in a secure element
+//
+// The first certificate has to provide a SKI matching its public key and has to match the public key of the signer
+func DecodeCertificatePEMWithSigner(certPEM []byte, signer crypto.Signer) (tls.Certificate, error) {
+	chain := decodeCertificateChainPEM(certPEM)
+	if len(chain) == 0 {
+		return tls.Certificate{}, ErrNoCertificate
+	}
+
+	return newShipCertificate(chain, signer)
+}
+
+// returns the DER encoded certificates of the PEM data
+func decodeCertificateChainPEM(certPEM []byte) [][]byte {
+	var chain [][]byte
+	for block, rest := pem.Decode(certPEM); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type == pemTypeCertificate {
+			chain = append(chain, block.Bytes)
+		}
+	}
+
+	return chain
+}
+
+// find and parse the first private key of the PEM data
+func decodePrivateKeyPEM(keyPEM []byte) (crypto.PrivateKey, error) {
+	for block, rest := pem.Decode(keyPEM); block != nil; block, rest = pem.Decode(rest) {
+		switch block.Type {
+		case pemTypePrivateKey:
+			return x509.ParsePKCS8PrivateKey(block.Bytes)
+		case pemTypeECPrivateKey:
+			return x509.ParseECPrivateKey(block.Bytes)
+		}
+	}
+
+	return nil, ErrNoPrivateKey
+}
+
+// create a SHIP ready certificate and check the SKI and the private key
+func newShipCertificate(chain [][]byte, privateKey crypto.PrivateKey) (tls.Certificate, error) {
+	leaf, err := x509.ParseCertificate(chain[0])
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	// peers verify that the SKI is the hash of the public key
+	if _, err := VerifiedSkiFromCertificate(leaf); err != nil {
+		return tls.Certificate{}, err
+	}
+
+	signer, err := signerForCertificate(leaf, privateKey)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	return tls.Certificate{
+		Certificate:                  chain,
+		PrivateKey:                   signer,
+		SupportedSignatureAlgorithms: []tls.SignatureScheme{tls.ECDSAWithP256AndSHA256},
+		Leaf:                         leaf,
+	}, nil
+}
+
+// check that the private key is a P-256 ECDSA key matching the certificate
+func signerForCertificate(leaf *x509.Certificate, privateKey crypto.PrivateKey) (crypto.Signer, error) {
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return nil, ErrUnsupportedKey
+	}
+
+	publicKey, ok := signer.Public().(*ecdsa.PublicKey)
+	if !ok || publicKey.Curve != elliptic.P256() {
+		return nil, ErrUnsupportedKey
+	}
+
+	if !publicKey.Equal(leaf.PublicKey) {
+		return nil, ErrKeyMismatch
+	}
+
+	return signer, nil
+}
+
+// Write the certificate chain and the private key to PEM files
+//
+// Existing files are replaced, the private key file is only readable by the owner
+func SaveCertificate(certificate tls.Certificate, certPath, keyPath string) error {
+	certPEM, keyPEM, err := EncodeCertificatePEM(certificate)
+	if err != nil {
+		return err
+	}
+
+	return writeCertificateFiles(certPath, certPEM, keyPath, keyPEM)
+}
+
+// replace the certificate file and the private key file
+//
+// The certificate is written first, if the private key can not be written the previous
+// certificate file is restored, so the files always belong together
+func writeCertificateFiles(certPath string, certPEM []byte, keyPath string, keyData []byte) error {
+	previousCertPEM, previousErr := os.ReadFile(certPath)
+
+	if err := writeFileAtomic(certPath, certPEM, 0o644); err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(keyPath, keyData, 0o600); err != nil {
+		if previousErr == nil {
+			_ = writeFileAtomic(certPath, previousCertPEM, 0o644)
+		} else {
+			_ = os.Remove(certPath)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Load a certificate chain and private key from PEM files into a SHIP ready certificate
+//
+// See DecodeCertificatePEM for the checks applied
+func LoadCertificate(certPath, keyPath string) (tls.Certificate, error) {
+	certPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	keyPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	certificate, err := DecodeCertificatePEM(certPEM, keyPEM)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("%s: %w", certPath, err)
+	}
+
+	return certificate, nil
+}
@@ -0,0 +1,154 @@
+package cert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (c *CertSuite) Test_EncodeDecodeCertificatePEM() {
+	certificate, err := CreateCertificate("unit", "org", "DE", "CN")
+	assert.Nil(c.T(), err)
+
+	certPEM, keyPEM, err := EncodeCertificatePEM(certificate)
+	assert.Nil(c.T(), err)
+
+	decoded, err := DecodeCertificatePEM(certPEM, keyPEM)
+	assert.Nil(c.T(), err)
+	assert.Equal(c.T(), certificate.Certificate, decoded.Certificate)
+	assert.Equal(c.T(), certificate.PrivateKey, decoded.PrivateKey)
+	assert.Equal(c.T(), []tls.SignatureScheme{tls.ECDSAWithP256AndSHA256}, decoded.SupportedSignatureAlgorithms)
+	assert.NotNil(c.T(), decoded.Leaf)
+
+	// SEC 1 encoded keys are supported
+	keyBytes, err := x509.MarshalECPrivateKey(certificate.PrivateKey.(*ecdsa.PrivateKey))
+	assert.Nil(c.T(), err)
+	sec1PEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes})
+
+	decoded, err = DecodeCertificatePEM(certPEM, sec1PEM)
+	assert.Nil(c.T(), err)
+	assert.Equal(c.T(), certificate.PrivateKey, decoded.PrivateKey)
+
+	// certificate and key in a single file
+	combined := append(append([]byte{}, certPEM...), keyPEM...)
+	_, err = DecodeCertificatePEM(combined, combined)
+	assert.Nil(c.T(), err)
+
+	_, _, err = EncodeCertificatePEM(tls.Certificate{})
+	assert.ErrorIs(c.T(), err, ErrNoCertificate)
+
+	_, _, err = EncodeCertificatePEM(tls.Certificate{Certificate: certificate.Certificate})
+	assert.ErrorIs(c.T(), err, ErrUnsupportedKey)
+}
+
+func (c *CertSuite) Test_DecodeCertificatePEM_Invalid() {
+	certificate, err := CreateCertificate("unit", "org", "DE", "CN")
+	assert.Nil(c.T(), err)
+	certPEM, keyPEM, err := EncodeCertificatePEM(certificate)
+	assert.Nil(c.T(), err)
+
+	other, err := CreateCertificate("unit", "org", "DE", "other")
+	assert.Nil(c.T(), err)
+	_, otherKeyPEM, err := EncodeCertificatePEM(other)
+	assert.Nil(c.T(), err)
+
+	_, err = DecodeCertificatePEM(certPEM, otherKeyPEM)
+	assert.ErrorIs(c.T(), err, ErrKeyMismatch)
+
+	_, err = DecodeCertificatePEM(keyPEM, keyPEM)
+	assert.ErrorIs(c.T(), err, ErrNoCertificate)
+
+	_, err = DecodeCertificatePEM(certPEM, certPEM)
+	assert.ErrorIs(c.T(), err, ErrNoPrivateKey)
+
+	invalidKeyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte{1, 2, 3}})
+	_, err = DecodeCertificatePEM(certPEM, invalidKeyPEM)
+	assert.NotNil(c.T(), err)
+
+	invalidCertPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte{1, 2, 3}})
+	_, err = DecodeCertificatePEM(invalidCertPEM, keyPEM)
+	assert.NotNil(c.T(), err)
+
+	// SHIP requires a P-256 key
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.Nil(c.T(), err)
+	p384Bytes, err := x509.MarshalPKCS8PrivateKey(p384Key)
+	assert.Nil(c.T(), err)
+	_, err = DecodeCertificatePEM(certPEM, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: p384Bytes}))
+	assert.ErrorIs(c.T(), err, ErrUnsupportedKey)
+
+	// the certificate has to provide a SKI
+	noSKI, err := createInvalidCertificate("unit", "org", "DE", "CN")
+	assert.Nil(c.T(), err)
+	noSKICertPEM, noSKIKeyPEM, err := EncodeCertificatePEM(noSKI)
+	assert.Nil(c.T(), err)
+	_, err = DecodeCertificatePEM(noSKICertPEM, noSKIKeyPEM)
+	assert.NotNil(c.T(), err)
+
+	// the SKI has to be the hash of the public key
+	privateKey := certificate.PrivateKey.(*ecdsa.PrivateKey)
+	wrongSKI := createValidationCertificate(c, privateKey, func(template *x509.Certificate) {
+		template.SubjectKeyId = make([]byte, 20)
+	})
+	wrongSKICertPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: wrongSKI.Raw})
+	_, err = DecodeCertificatePEM(wrongSKICertPEM, keyPEM)
+	assert.ErrorIs(c.T(), err, ErrSkiMismatch)
+}
+
+func (c *CertSuite) Test_SaveLoadCertificate() {
+	certificate, err := CreateCertificate("unit", "org", "DE", "CN")
+	assert.Nil(c.T(), err)
+
+	dir := c.T().TempDir()
+	certPath := filepath.Join(dir, "cert.pem")
+	keyPath := filepath.Join(dir, "key.pem")
+
+	// an existing key file readable by others is restricted as well
+	err = os.WriteFile(keyPath, nil, 0o644) // #nosec G306
+	assert.Nil(c.T(), err)
+
+	err = SaveCertificate(certificate, certPath, keyPath)
+	assert.Nil(c.T(), err)
+
+	info, err := os.Stat(keyPath)
+	assert.Nil(c.T(), err)
+	assert.Equal(c.T(), os.FileMode(0o600), info.Mode().Perm())
+
+	loaded, err := LoadCertificate(certPath, keyPath)
+	assert.Nil(c.T(), err)
+	assert.Equal(c.T(), certificate.Certificate, loaded.Certificate)
+
+	_, err = LoadCertificate(filepath.Join(dir, "missing.pem"), keyPath)
+	assert.NotNil(c.T(), err)
+
+	_, err = LoadCertificate(certPath, filepath.Join(dir, "missing.pem"))
+	assert.NotNil(c.T(), err)
+
+	_, err = LoadCertificate(keyPath, keyPath)
+	assert.ErrorIs(c.T(), err, ErrNoCertificate)
+
+	err = SaveCertificate(tls.Certificate{}, certPath, keyPath)
+	assert.NotNil(c.T(), err)
+
+	// the previous certificate is kept if the key can not be written
+	other, err := CreateCertificate("unit", "org", "DE", "other")
+	assert.Nil(c.T(), err)
+	err = SaveCertificate(other, certPath, filepath.Join(dir, "missing", "key.pem"))
+	assert.NotNil(c.T(), err)
+
+	loaded, err = LoadCertificate(certPath, keyPath)
+	assert.Nil(c.T(), err)
+	assert.Equal(c.T(), certificate.Certificate, loaded.Certificate)
+
+	newCertPath := filepath.Join(dir, "new.pem")
+	err = SaveCertificate(other, newCertPath, filepath.Join(dir, "missing", "key.pem"))
+	assert.NotNil(c.T(), err)
+	assert.NoFileExists(c.T(), newCertPath)
+}
@@ -0,0 +1,96 @@
+package cert
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"time"
+)
+
+// Options for renewing a certificate
+type RenewOptions struct {
+	// Optional, the subject of the renewed certificate,
+	// empty values keep the value of the existing certificate
+	OrganizationalUnit string
+	Organization       string
+	Country            string
+	CommonName         string
+
+	// Optional, the start of the validity period, defaults to now
+	NotBefore time.Time
+
+	// Optional, the validity period, defaults to DefaultValidity
+	Validity time.Duration
+}
+
+// Re-issue a certificate using the private key of the existing certificate
+//
+// The renewed certificate keeps the SKI and the extensions like SANs of the existing certificate,
+// so pairings with remote services stay valid. The validity period and the subject fields
+// are set according to the options. The private key may also be a crypto.Signer,
+// see CreateCertificateWithSigner.
+func RenewCertificate(existing tls.Certificate, opts RenewOptions) (tls.Certificate, error) {
+	if len(existing.Certificate) == 0 {
+		return tls.Certificate{}, ErrNoCertificate
+	}
+
+	if opts.Validity < 0 {
+		return tls.Certificate{}, fmt.Errorf("%w: negative validity", ErrInvalidCertificateOptions)
+	}
+
+	leaf := existing.Leaf
+	if leaf == nil {
+		var err error
+		if leaf, err = x509.ParseCertificate(existing.Certificate[0]); err != nil {
+			return tls.Certificate{}, err
+		}
+	}
+
+	if _, err := SkiFromCertificate(leaf); err != nil {
+		return tls.Certificate{}, err
+	}
+
+	privateKey, err := signerForCertificate(leaf, existing.PrivateKey)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	subject := leaf.Subject
+	if opts.OrganizationalUnit != "" {
+		subject.OrganizationalUnit = []string{opts.OrganizationalUnit}
+	}
+	if opts.Organization != "" {
+		subject.Organization = []string{opts.Organization}
+	}
+	if opts.Country != "" {
+		subject.Country = []string{opts.Country}
+	}
+	if opts.CommonName != "" {
+		subject.CommonName = opts.CommonName
+	}
+
+	notBefore := opts.NotBefore
+	if notBefore.IsZero() {
+		notBefore = time.Now()
+	}
+
+	validity := opts.Validity
+	if validity == 0 {
+		validity = DefaultValidity
+	}
+
+	// the extensions of the existing certificate are kept
+	template := &x509.Certificate{
+		Subject:      subject,
+		NotBefore:    notBefore,
+		NotAfter:     notBefore.Add(validity),
+		KeyUsage:     leaf.KeyUsage,
+		ExtKeyUsage:  leaf.ExtKeyUsage,
+		IsCA:         leaf.IsCA,
+		DNSNames:     leaf.DNSNames,
+		IPAddresses:  leaf.IPAddresses,
+		SubjectKeyId: leaf.SubjectKeyId,
+	}
+
+	return issueCertificate(template, privateKey)
+}
@@ -0,0 +1,83 @@
+package cert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (c *CertSuite) Test_RenewCertificate() {
+	existing, err := CreateCertificate("unit", "org", "DE", "CN")
+	assert.Nil(c.T(), err)
+
+	existingLeaf, err := x509.ParseCertificate(existing.Certificate[0])
+	assert.Nil(c.T(), err)
+
+	notBefore := time.Now().Add(time.Hour).Truncate(time.Second)
+	renewed, err := RenewCertificate(existing, RenewOptions{
+		CommonName: "renewed",
+		NotBefore:  notBefore,
+		Validity:   24 * time.Hour,
+	})
+	assert.Nil(c.T(), err)
+	assert.Equal(c.T(), existing.PrivateKey, renewed.PrivateKey)
+	assert.NotEqual(c.T(), existing.Certificate, renewed.Certificate)
+
+	leaf, err := x509.ParseCertificate(renewed.Certificate[0])
+	assert.Nil(c.T(), err)
+	assert.Equal(c.T(), existingLeaf.SubjectKeyId, leaf.SubjectKeyId)
+	assert.NotEqual(c.T(), existingLeaf.SerialNumber, leaf.SerialNumber)
+	assert.Equal(c.T(), "renewed", leaf.Subject.CommonName)
+	assert.Equal(c.T(), []string{"org"}, leaf.Subject.Organization)
+	assert.Equal(c.T(), []string{"unit"}, leaf.Subject.OrganizationalUnit)
+	assert.Equal(c.T(), []string{"DE"}, leaf.Subject.Country)
+	assert.True(c.T(), notBefore.Equal(leaf.NotBefore))
+	assert.True(c.T(), notBefore.Add(24*time.Hour).Equal(leaf.NotAfter))
+	assert.Empty(c.T(), validateShipCertificate(leaf, notBefore, ValidationOptions{}))
+
+	// the PEM helpers accept the renewed certificate
+	certPEM, keyPEM, err := EncodeCertificatePEM(renewed)
+	assert.Nil(c.T(), err)
+	_, err = DecodeCertificatePEM(certPEM, keyPEM)
+	assert.Nil(c.T(), err)
+
+	// defaults
+	renewed, err = RenewCertificate(renewed, RenewOptions{})
+	assert.Nil(c.T(), err)
+	leaf, err = x509.ParseCertificate(renewed.Certificate[0])
+	assert.Nil(c.T(), err)
+	assert.Equal(c.T(), existingLeaf.SubjectKeyId, leaf.SubjectKeyId)
+	assert.Equal(c.T(), "renewed", leaf.Subject.CommonName)
+	assert.WithinDuration(c.T(), time.Now().Add(DefaultValidity), leaf.NotAfter, time.Minute)
+}
+
+func (c *CertSuite) Test_RenewCertificate_Invalid() {
+	existing, err := CreateCertificate("unit", "org", "DE", "CN")
+	assert.Nil(c.T(), err)
+
+	_, err = RenewCertificate(tls.Certificate{}, RenewOptions{})
+	assert.ErrorIs(c.T(), err, ErrNoCertificate)
+
+	_, err = RenewCertificate(existing, RenewOptions{Validity: -time.Hour})
+	assert.ErrorIs(c.T(), err, ErrInvalidCertificateOptions)
+
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(c.T(), err)
+	_, err = RenewCertificate(tls.Certificate{Certificate: existing.Certificate, PrivateKey: otherKey}, RenewOptions{})
+	assert.ErrorIs(c.T(), err, ErrKeyMismatch)
+
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.Nil(c.T(), err)
+	_, err = RenewCertificate(tls.Certificate{Certificate: existing.Certificate, PrivateKey: p384Key}, RenewOptions{})
+	assert.ErrorIs(c.T(), err, ErrUnsupportedKey)
+
+	invalid, err := createInvalidCertificate("unit", "org", "DE", "CN")
+	assert.Nil(c.T(), err)
+	_, err = RenewCertificate(invalid, RenewOptions{})
+	assert.NotNil(c.T(), err)
+}
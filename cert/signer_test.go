@@ -0,0 +1,73 @@
+package cert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+
+	"github.com/enbility/ship-go/cert/certtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func (c *CertSuite) Test_CreateCertificateWithSigner() {
+	signer, err := certtest.NewSigner()
+	assert.Nil(c.T(), err)
+
+	certificate, err := CreateCertificateWithSigner(signer, "unit", "org", "DE", "CN")
+	assert.Nil(c.T(), err)
+	assert.Equal(c.T(), signer, certificate.PrivateKey)
+	assert.Equal(c.T(), 1, signer.Signatures())
+
+	leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+	assert.Nil(c.T(), err)
+	assert.Empty(c.T(), ValidateShipCertificate(leaf))
+
+	_, err = VerifiedSkiFromCertificate(leaf)
+	assert.Nil(c.T(), err)
+
+	// the private key can not be exported
+	_, err = x509.MarshalPKCS8PrivateKey(signer)
+	assert.NotNil(c.T(), err)
+	_, _, err = EncodeCertificatePEM(certificate)
+	assert.ErrorIs(c.T(), err, ErrUnsupportedKey)
+
+	// renewing uses the signer as well
+	renewed, err := RenewCertificate(certificate, RenewOptions{CommonName: "renewed"})
+	assert.Nil(c.T(), err)
+	assert.Equal(c.T(), 2, signer.Signatures())
+
+	renewedLeaf, err := x509.ParseCertificate(renewed.Certificate[0])
+	assert.Nil(c.T(), err)
+	assert.Equal(c.T(), leaf.SubjectKeyId, renewedLeaf.SubjectKeyId)
+
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.Nil(c.T(), err)
+	_, err = CreateCertificateWithSigner(p384Key, "unit", "org", "DE", "CN")
+	assert.ErrorIs(c.T(), err, ErrUnsupportedKey)
+}
+
+func (c *CertSuite) Test_DecodeCertificatePEMWithSigner() {
+	signer, err := certtest.NewSigner()
+	assert.Nil(c.T(), err)
+
+	certificate, err := CreateCertificateWithSigner(signer, "unit", "org", "DE", "CN")
+	assert.Nil(c.T(), err)
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Certificate[0]})
+
+	decoded, err := DecodeCertificatePEMWithSigner(certPEM, signer)
+	assert.Nil(c.T(), err)
+	assert.Equal(c.T(), certificate.Certificate, decoded.Certificate)
+	assert.Equal(c.T(), signer, decoded.PrivateKey)
+	assert.NotNil(c.T(), decoded.Leaf)
+
+	otherSigner, err := certtest.NewSigner()
+	assert.Nil(c.T(), err)
+	_, err = DecodeCertificatePEMWithSigner(certPEM, otherSigner)
+	assert.ErrorIs(c.T(), err, ErrKeyMismatch)
+
+	_, err = DecodeCertificatePEMWithSigner(nil, signer)
+	assert.ErrorIs(c.T(), err, ErrNoCertificate)
+}
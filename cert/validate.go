@@ -0,0 +1,229 @@
+package cert
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrNonConformingCertificate is returned if a certificate violates the SHIP specification
+var ErrNonConformingCertificate = errors.New("certificate does not conform to SHIP")
+
+// The severity of a finding
+type Severity uint
+
+const (
+	SeverityInfo    Severity = iota // deviates from common practice, but conforms to SHIP
+	SeverityWarning                 // may cause interoperability problems
+	SeverityError                   // violates the SHIP specification
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return fmt.Sprintf("severity(%d)", uint(s))
+	}
+}
+
+// The check which created a finding
+type Check string
+
+const (
+	CheckPublicKey          Check = "public-key"          // SHIP 9.1: ECDSA key on curve secp256r1
+	CheckSignatureAlgorithm Check = "signature-algorithm" // SHIP 9.1: ECDSA signature with SHA-256
+	CheckSubjectKeyID       Check = "subject-key-id"      // SHIP 12.2: SKI with 20 bytes
+	CheckSubjectKeyIDHash   Check = "subject-key-id-hash" // SHIP 12.2: SKI derived from the public key, RFC 3280 4.2.1.2
+	CheckValidity           Check = "validity"            // the certificate is currently valid
+	CheckSelfSignature      Check = "self-signature"      // SHIP 12.1: the certificate is self signed
+	CheckKeyUsage           Check = "key-usage"           // the key may be used for TLS client and server authentication
+	CheckCommonName         Check = "subject-common-name" // the subject contains a common name
+)
+
+// A deviation of a certificate from the SHIP specification
+type Finding struct {
+	Check    Check
+	Severity Severity
+	Message  string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s: %s", f.Severity, f.Check, f.Message)
+}
+
+// The findings of a certificate validation
+type Findings []Finding
+
+// Returns true if a finding has the error severity
+func (f Findings) HasErrors() bool {
+	for _, finding := range f {
+		if finding.Severity == SeverityError {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns the findings created by one of the checks
+func (f Findings) ForChecks(checks ...Check) Findings {
+	var result Findings
+	for _, finding := range f {
+		if slices.Contains(checks, finding.Check) {
+			result = append(result, finding)
+		}
+	}
+
+	return result
+}
+
+// Returns an error wrapping ErrNonConformingCertificate if a finding has the error severity
+func (f Findings) Err() error {
+	return f.ErrAtLeast(SeverityError)
+}
+
+// Returns an error wrapping ErrNonConformingCertificate if a finding has at least the severity
+func (f Findings) ErrAtLeast(severity Severity) error {
+	var messages []string
+	for _, finding := range f {
+		if finding.Severity >= severity {
+			messages = append(messages, fmt.Sprintf("%s: %s", finding.Check, finding.Message))
+		}
+	}
+
+	if len(messages) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrNonConformingCertificate, strings.Join(messages, ", "))
+}
+
+// Defines how the findings of a certificate validation are handled
+type ValidationMode uint
+
+const (
+	ValidationModeOff    ValidationMode = iota // the certificate is not validated
+	ValidationModeWarn                         // findings are logged
+	ValidationModeReject                       // findings are logged and certificates with errors are rejected
+)
+
+// The default tolerance for clocks of devices which deviate from the local clock
+const DefaultClockSkew = 5 * time.Minute
+
+// Options of a certificate validation
+type ValidationOptions struct {
+	// The tolerance applied to NotBefore and NotAfter
+	ClockSkew time.Duration
+}
+
+// Check a certificate against the requirements of SHIP 9 and 12
+//
+// Returns an empty list if the certificate conforms to SHIP
+func ValidateShipCertificate(certificate *x509.Certificate) Findings {
+	return validateShipCertificate(certificate, time.Now(), ValidationOptions{})
+}
+
+// Check a certificate against the requirements of SHIP 9 and 12 using the options
+func ValidateShipCertificateWithOptions(certificate *x509.Certificate, options ValidationOptions) Findings {
+	return validateShipCertificate(certificate, time.Now(), options)
+}
+
+func validateShipCertificate(certificate *x509.Certificate, now time.Time, options ValidationOptions) Findings {
+	var findings Findings
+
+	add := func(check Check, severity Severity, format string, args ...any) {
+		findings = append(findings, Finding{
+			Check:    check,
+			Severity: severity,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	key, isECDSA := certificate.PublicKey.(*ecdsa.PublicKey)
+	switch {
+	case !isECDSA:
+		add(CheckPublicKey, SeverityError, "%s key instead of ECDSA", certificate.PublicKeyAlgorithm)
+	case key.Curve != elliptic.P256():
+		add(CheckPublicKey, SeverityError, "curve %s instead of P-256", key.Curve.Params().Name)
+	}
+
+	switch certificate.SignatureAlgorithm {
+	case x509.ECDSAWithSHA256:
+	case x509.ECDSAWithSHA1, x509.ECDSAWithSHA384, x509.ECDSAWithSHA512:
+		add(CheckSignatureAlgorithm, SeverityWarning, "%s instead of ECDSA-SHA256", certificate.SignatureAlgorithm)
+	default:
+		add(CheckSignatureAlgorithm, SeverityError, "%s is no ECDSA signature", certificate.SignatureAlgorithm)
+	}
+
+	switch length := len(certificate.SubjectKeyId); {
+	case length == 0:
+		add(CheckSubjectKeyID, SeverityError, "missing subject key identifier")
+	case length != 20:
+		add(CheckSubjectKeyID, SeverityError, "subject key identifier has %d instead of 20 bytes", length)
+	case isECDSA:
+		// RFC 3280 4.2.1.2 allows other methods, but only this one binds the SKI to the key,
+		// so the hub rejects other SKIs by default (Hub.SetSkiVerification)
+		if _, err := VerifiedSkiFromCertificate(certificate); err != nil {
+			add(CheckSubjectKeyIDHash, SeverityError, "subject key identifier is not the SHA-1 hash of the public key")
+		}
+	}
+
+	switch {
+	case now.Add(options.ClockSkew).Before(certificate.NotBefore):
+		add(CheckValidity, SeverityError, "not valid before %s", certificate.NotBefore.Format(time.RFC3339))
+	case now.Add(-options.ClockSkew).After(certificate.NotAfter):
+		add(CheckValidity, SeverityError, "expired at %s", certificate.NotAfter.Format(time.RFC3339))
+	}
+
+	// the signature of certificates signed by a CA can not be checked without the CA certificate
+	if bytes.Equal(certificate.RawIssuer, certificate.RawSubject) {
+		err := certificate.CheckSignature(certificate.SignatureAlgorithm, certificate.RawTBSCertificate, certificate.Signature)
+		if err != nil {
+			add(CheckSelfSignature, SeverityError, "invalid self signature: %s", err)
+		}
+	} else {
+		add(CheckSelfSignature, SeverityWarning, "certificate is not self signed")
+	}
+
+	// SHIP 9.1: the ECDHE_ECDSA cipher suites require digital signatures,
+	// and the certificate is used for both sides of a connection
+	switch {
+	case certificate.KeyUsage == 0:
+		add(CheckKeyUsage, SeverityInfo, "no key usage extension")
+	case certificate.KeyUsage&x509.KeyUsageDigitalSignature == 0:
+		add(CheckKeyUsage, SeverityError, "key usage does not allow digital signatures")
+	}
+
+	if len(certificate.ExtKeyUsage) > 0 && !slices.Contains(certificate.ExtKeyUsage, x509.ExtKeyUsageAny) {
+		for _, usage := range []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth} {
+			if !slices.Contains(certificate.ExtKeyUsage, usage) {
+				add(CheckKeyUsage, SeverityWarning, "extended key usage does not allow %s", extKeyUsageName(usage))
+			}
+		}
+	}
+
+	if strings.TrimSpace(certificate.Subject.CommonName) == "" {
+		add(CheckCommonName, SeverityInfo, "subject has no common name")
+	}
+
+	return findings
+}
+
+func extKeyUsageName(usage x509.ExtKeyUsage) string {
+	if usage == x509.ExtKeyUsageServerAuth {
+		return "server authentication"
+	}
+
+	return "client authentication"
+}
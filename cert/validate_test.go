@@ -0,0 +1,252 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+) // #nosec G505
+
+// create a self signed certificate, the template can be changed by the update function
+func createValidationCertificate(c *CertSuite, privateKey crypto.Signer, update func(*x509.Certificate)) *x509.Certificate {
+	template := &x509.Certificate{
+		SignatureAlgorithm: x509.ECDSAWithSHA256,
+		SerialNumber:       big.NewInt(1),
+		Subject:            pkix.Name{CommonName: "CN"},
+		NotBefore:          time.Now().Add(-time.Hour),
+		NotAfter:           time.Now().Add(time.Hour),
+		SubjectKeyId:       make([]byte, 20),
+		KeyUsage:           x509.KeyUsageDigitalSignature,
+	}
+
+	if key, ok := privateKey.Public().(*ecdsa.PublicKey); ok && key.Curve == elliptic.P256() {
+		publicKey, err := key.ECDH()
+		assert.Nil(c.T(), err)
+		// #nosec G401
+		ski := sha1.Sum(publicKey.Bytes())
+		template.SubjectKeyId = ski[:]
+	}
+
+	if update != nil {
+		update(template)
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, privateKey.Public(), privateKey)
+	assert.Nil(c.T(), err)
+
+	certificate, err := x509.ParseCertificate(der)
+	assert.Nil(c.T(), err)
+
+	return certificate
+}
+
+func findingFor(findings Findings, check Check) (Finding, bool) {
+	for _, finding := range findings {
+		if finding.Check == check {
+			return finding, true
+		}
+	}
+
+	return Finding{}, false
+}
+
+func (c *CertSuite) Test_ValidateShipCertificate() {
+	certificate, err := CreateCertificate("unit", "org", "DE", "CN")
+	assert.Nil(c.T(), err)
+
+	leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+	assert.Nil(c.T(), err)
+
+	findings := ValidateShipCertificate(leaf)
+	assert.Empty(c.T(), findings)
+	assert.False(c.T(), findings.HasErrors())
+	assert.Nil(c.T(), findings.Err())
+}
+
+func (c *CertSuite) Test_ValidateShipCertificate_Findings() {
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(c.T(), err)
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.Nil(c.T(), err)
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(c.T(), err)
+
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		key      crypto.Signer
+		update   func(*x509.Certificate)
+		check    Check
+		severity Severity
+	}{
+		{
+			name:     "curve",
+			key:      p384Key,
+			update:   func(t *x509.Certificate) { t.SignatureAlgorithm = x509.ECDSAWithSHA384 },
+			check:    CheckPublicKey,
+			severity: SeverityError,
+		},
+		{
+			name:     "key type",
+			key:      ed25519Key,
+			update:   func(t *x509.Certificate) { t.SignatureAlgorithm = x509.PureEd25519 },
+			check:    CheckPublicKey,
+			severity: SeverityError,
+		},
+		{
+			name:     "signature hash",
+			key:      p256Key,
+			update:   func(t *x509.Certificate) { t.SignatureAlgorithm = x509.ECDSAWithSHA384 },
+			check:    CheckSignatureAlgorithm,
+			severity: SeverityWarning,
+		},
+		{
+			name:     "short ski",
+			key:      p256Key,
+			update:   func(t *x509.Certificate) { t.SubjectKeyId = t.SubjectKeyId[:19] },
+			check:    CheckSubjectKeyID,
+			severity: SeverityError,
+		},
+		{
+			name:     "ski hash",
+			key:      p256Key,
+			update:   func(t *x509.Certificate) { t.SubjectKeyId = make([]byte, 20) },
+			check:    CheckSubjectKeyIDHash,
+			severity: SeverityError,
+		},
+		{
+			name:     "expired",
+			key:      p256Key,
+			update:   func(t *x509.Certificate) { t.NotAfter = now.Add(-time.Minute) },
+			check:    CheckValidity,
+			severity: SeverityError,
+		},
+		{
+			name:     "not yet valid",
+			key:      p256Key,
+			update:   func(t *x509.Certificate) { t.NotBefore = now.Add(time.Minute) },
+			check:    CheckValidity,
+			severity: SeverityError,
+		},
+		{
+			name:     "no key usage",
+			key:      p256Key,
+			update:   func(t *x509.Certificate) { t.KeyUsage = 0 },
+			check:    CheckKeyUsage,
+			severity: SeverityInfo,
+		},
+		{
+			name:     "key usage",
+			key:      p256Key,
+			update:   func(t *x509.Certificate) { t.KeyUsage = x509.KeyUsageKeyAgreement },
+			check:    CheckKeyUsage,
+			severity: SeverityError,
+		},
+		{
+			name:     "extended key usage",
+			key:      p256Key,
+			update:   func(t *x509.Certificate) { t.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth} },
+			check:    CheckKeyUsage,
+			severity: SeverityWarning,
+		},
+		{
+			name:     "common name",
+			key:      p256Key,
+			update:   func(t *x509.Certificate) { t.Subject = pkix.Name{Organization: []string{"org"}} },
+			check:    CheckCommonName,
+			severity: SeverityInfo,
+		},
+	}
+
+	for _, test := range tests {
+		certificate := createValidationCertificate(c, test.key, test.update)
+
+		findings := validateShipCertificate(certificate, now, ValidationOptions{})
+		finding, ok := findingFor(findings, test.check)
+		if assert.True(c.T(), ok, test.name) {
+			assert.Equal(c.T(), test.severity, finding.Severity, test.name)
+		}
+
+		assert.Equal(c.T(), test.severity == SeverityError, findings.HasErrors(), test.name)
+		if test.severity == SeverityError {
+			assert.True(c.T(), errors.Is(findings.Err(), ErrNonConformingCertificate), test.name)
+		} else {
+			assert.Nil(c.T(), findings.Err(), test.name)
+		}
+	}
+}
+
+func (c *CertSuite) Test_ValidateShipCertificate_SelfSignature() {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(c.T(), err)
+
+	certificate := createValidationCertificate(c, privateKey, nil)
+	assert.Empty(c.T(), validateShipCertificate(certificate, time.Now(), ValidationOptions{}))
+
+	// a corrupted signature
+	certificate.Signature[len(certificate.Signature)-1] ^= 0xff
+	finding, ok := findingFor(validateShipCertificate(certificate, time.Now(), ValidationOptions{}), CheckSelfSignature)
+	assert.True(c.T(), ok)
+	assert.Equal(c.T(), SeverityError, finding.Severity)
+
+	// signed by another certificate
+	certificate = createValidationCertificate(c, privateKey, nil)
+	certificate.RawIssuer = []byte("issuer")
+	finding, ok = findingFor(validateShipCertificate(certificate, time.Now(), ValidationOptions{}), CheckSelfSignature)
+	assert.True(c.T(), ok)
+	assert.Equal(c.T(), SeverityWarning, finding.Severity)
+}
+
+func (c *CertSuite) Test_ValidateShipCertificate_ClockSkew() {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(c.T(), err)
+
+	now := time.Now()
+	options := ValidationOptions{ClockSkew: time.Minute}
+
+	notYetValid := createValidationCertificate(c, privateKey, func(t *x509.Certificate) { t.NotBefore = now.Add(30 * time.Second) })
+	assert.True(c.T(), validateShipCertificate(notYetValid, now, ValidationOptions{}).HasErrors())
+	assert.Empty(c.T(), validateShipCertificate(notYetValid, now, options))
+	assert.True(c.T(), validateShipCertificate(notYetValid, now.Add(-time.Minute), options).HasErrors())
+
+	expired := createValidationCertificate(c, privateKey, func(t *x509.Certificate) { t.NotAfter = now.Add(-30 * time.Second) })
+	assert.True(c.T(), validateShipCertificate(expired, now, ValidationOptions{}).HasErrors())
+	assert.Empty(c.T(), validateShipCertificate(expired, now, options))
+	assert.True(c.T(), validateShipCertificate(expired, now.Add(time.Minute), options).HasErrors())
+}
+
+func (c *CertSuite) Test_Findings() {
+	findings := Findings{
+		{Check: CheckValidity, Severity: SeverityError, Message: "expired"},
+		{Check: CheckSelfSignature, Severity: SeverityWarning, Message: "not self signed"},
+		{Check: CheckKeyUsage, Severity: SeverityInfo, Message: "no key usage"},
+	}
+
+	assert.Len(c.T(), findings.ForChecks(CheckSelfSignature, CheckKeyUsage), 2)
+	assert.Empty(c.T(), findings.ForChecks(CheckPublicKey))
+
+	selfSignature := findings.ForChecks(CheckSelfSignature)
+	assert.Nil(c.T(), selfSignature.Err())
+	assert.True(c.T(), errors.Is(selfSignature.ErrAtLeast(SeverityWarning), ErrNonConformingCertificate))
+	assert.Nil(c.T(), findings.ForChecks(CheckKeyUsage).ErrAtLeast(SeverityWarning))
+}
+
+func (c *CertSuite) Test_Severity_String() {
+	assert.Equal(c.T(), "info", SeverityInfo.String())
+	assert.Equal(c.T(), "warning", SeverityWarning.String())
+	assert.Equal(c.T(), "error", SeverityError.String())
+	assert.Equal(c.T(), "severity(5)", Severity(5).String())
+
+	finding := Finding{Check: CheckValidity, Severity: SeverityError, Message: "expired"}
+	assert.Equal(c.T(), "error: validity: expired", finding.String())
+}
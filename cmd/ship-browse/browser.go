@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/enbility/ship-go/api"
+)
+
+// a discovered SHIP service, as printed by the browser
+type service struct {
+	SKI        string   `json:"ski"`
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Brand      string   `json:"brand,omitempty"`
+	Model      string   `json:"model,omitempty"`
+	Type       string   `json:"type,omitempty"`
+	Serial     string   `json:"serial,omitempty"`
+	Categories []uint   `json:"categories"`
+	Register   bool     `json:"register"`
+	Host       string   `json:"host"`
+	Port       int      `json:"port"`
+	Path       string   `json:"path"`
+	Addresses  []string `json:"addresses"`
+}
+
+func newService(entry *api.MdnsEntry) service {
+	result := service{
+		SKI:        entry.Ski,
+		ID:         entry.Identifier,
+		Name:       entry.Name,
+		Brand:      entry.Brand,
+		Model:      entry.Model,
+		Type:       entry.Type,
+		Serial:     entry.Serial,
+		Categories: []uint{},
+		Register:   entry.Register,
+		Host:       entry.Host,
+		Port:       entry.Port,
+		Path:       entry.Path,
+		Addresses:  []string{},
+	}
+
+	for _, category := range entry.Categories {
+		result.Categories = append(result.Categories, uint(category))
+	}
+
+	for _, address := range entry.Addresses {
+		result.Addresses = append(result.Addresses, address.String())
+	}
+
+	return result
+}
+
+// the type of a change in watch mode
+type eventType string
+
+const (
+	eventAdded   eventType = "added"
+	eventUpdated eventType = "updated"
+	eventRemoved eventType = "removed"
+)
+
+// a change of a service in watch mode
+type event struct {
+	Event   eventType `json:"event"`
+	Service service   `json:"service"`
+}
+
+// Collects the services reported by the mDNS manager
+//
+// In watch mode every change is printed immediately
+type browser struct {
+	out   io.Writer
+	watch bool
+	json  bool
+
+	services map[string]service
+
+	mux sync.Mutex
+}
+
+var _ api.MdnsReportInterface = (*browser)(nil)
+
+func newBrowser(out io.Writer, watch, json bool) *browser {
+	return &browser{
+		out:      out,
+		watch:    watch,
+		json:     json,
+		services: make(map[string]service),
+	}
+}
+
+// the mDNS manager always reports all currently known entries
+func (b *browser) ReportMdnsEntries(entries map[string]*api.MdnsEntry, newEntries bool) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	var events []event
+
+	for ski, entry := range entries {
+		current := newService(entry)
+		previous, exists := b.services[ski]
+
+		switch {
+		case !exists:
+			events = append(events, event{Event: eventAdded, Service: current})
+		case !reflect.DeepEqual(previous, current):
+			events = append(events, event{Event: eventUpdated, Service: current})
+		default:
+			continue
+		}
+
+		b.services[ski] = current
+	}
+
+	for ski, previous := range b.services {
+		if _, exists := entries[ski]; exists {
+			continue
+		}
+
+		events = append(events, event{Event: eventRemoved, Service: previous})
+		delete(b.services, ski)
+	}
+
+	if !b.watch {
+		return
+	}
+
+	slices.SortFunc(events, func(a, b event) int {
+		return strings.Compare(a.Service.SKI, b.Service.SKI)
+	})
+
+	for _, item := range events {
+		b.printEvent(item)
+	}
+}
+
+func (b *browser) printEvent(item event) {
+	if b.json {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return
+		}
+		fmt.Fprintln(b.out, string(data))
+		return
+	}
+
+	s := item.Service
+	fmt.Fprintf(b.out, "%-7s %s id=%s brand=%s model=%s categories=%s register=%t host=%s port=%d addresses=%s\n",
+		item.Event, s.SKI, s.ID, s.Brand, s.Model, categoriesString(s.Categories), s.Register,
+		s.Host, s.Port, strings.Join(s.Addresses, ","))
+}
+
+// print all currently known services, sorted by SKI
+func (b *browser) printServices() error {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	services := make([]service, 0, len(b.services))
+	for _, item := range b.services {
+		services = append(services, item)
+	}
+	slices.SortFunc(services, func(a, b service) int {
+		return strings.Compare(a.SKI, b.SKI)
+	})
+
+	if b.json {
+		encoder := json.NewEncoder(b.out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(services)
+	}
+
+	if len(services) == 0 {
+		_, err := fmt.Fprintln(b.out, "No SHIP services found")
+		return err
+	}
+
+	writer := tabwriter.NewWriter(b.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "SKI\tID\tBRAND\tMODEL\tCATEGORIES\tREGISTER\tHOST\tPORT\tADDRESSES")
+	for _, s := range services {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%t\t%s\t%d\t%s\n",
+			s.SKI, s.ID, s.Brand, s.Model, categoriesString(s.Categories), s.Register,
+			s.Host, s.Port, strings.Join(s.Addresses, ","))
+	}
+
+	return writer.Flush()
+}
+
+func categoriesString(categories []uint) string {
+	values := make([]string, 0, len(categories))
+	for _, category := range categories {
+		values = append(values, strconv.FormatUint(uint64(category), 10))
+	}
+
+	return strings.Join(values, ",")
+}
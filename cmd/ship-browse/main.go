@@ -0,0 +1,146 @@
+// Command ship-browse lists the SHIP services announced via mDNS in the local network
+//
+// Usage:
+//
+//	ship-browse [-provider auto|avahi|zeroconf] [-iface <name>[,<name>]] [-timeout 5s] [-watch] [-json]
+//	            [-static <file>] [-dns-server <address>] [-dns-domain <domain>]
+//
+// With -static or -dns-server, services are resolved from a static file or via
+// unicast DNS-SD queries instead of mDNS, e.g. in networks blocking multicast traffic.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/enbility/ship-go/mdns"
+)
+
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		if !errors.Is(err, errUsage) {
+			fmt.Fprintln(os.Stderr, "error:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// the options given on the command line
+type options struct {
+	provider   mdns.MdnsProviderSelection
+	interfaces []string
+	timeout    time.Duration
+	watch      bool
+	json       bool
+
+	// the sources of the unicast provider
+	staticFile string
+	dnsServer  string
+	dnsDomain  string
+}
+
+func parseOptions(args []string) (options, error) {
+	flags := flag.NewFlagSet("ship-browse", flag.ContinueOnError)
+	provider := flags.String("provider", "auto", "the mDNS provider: auto, avahi or zeroconf")
+	interfaces := flags.String("iface", "", "comma separated network interfaces to browse, default all")
+	timeout := flags.Duration("timeout", 5*time.Second, "the time to browse before printing the services, unless -watch is used")
+	watch := flags.Bool("watch", false, "continuously print added, updated and removed services until interrupted")
+	json := flags.Bool("json", false, "print JSON instead of text, in watch mode one JSON object per line")
+	staticFile := flags.String("static", "", "read the services from a JSON file instead of using mDNS")
+	dnsServer := flags.String("dns-server", "", "query the services from a DNS server instead of using mDNS")
+	dnsDomain := flags.String("dns-domain", "local.", "the DNS-SD domain queried with -dns-server")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return options{}, err
+		}
+		return options{}, errUsage
+	}
+
+	result := options{
+		timeout:    *timeout,
+		watch:      *watch,
+		json:       *json,
+		staticFile: *staticFile,
+		dnsServer:  *dnsServer,
+		dnsDomain:  *dnsDomain,
+	}
+
+	switch *provider {
+	case "auto":
+		result.provider = mdns.MdnsProviderSelectionAll
+	case "avahi":
+		result.provider = mdns.MdnsProviderSelectionAvahiOnly
+	case "zeroconf":
+		result.provider = mdns.MdnsProviderSelectionGoZeroConfOnly
+	default:
+		fmt.Fprintf(flags.Output(), "invalid provider %q\n", *provider)
+		flags.Usage()
+		return options{}, errUsage
+	}
+
+	if *interfaces != "" {
+		for _, name := range strings.Split(*interfaces, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				result.interfaces = append(result.interfaces, name)
+			}
+		}
+	}
+
+	if flags.NArg() != 0 || result.timeout <= 0 {
+		flags.Usage()
+		return options{}, errUsage
+	}
+
+	return result, nil
+}
+
+// browse for SHIP services and write them to out
+func run(args []string, out io.Writer) error {
+	opts, err := parseOptions(args)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	if !opts.watch {
+		var timeoutCancel context.CancelFunc
+		ctx, timeoutCancel = context.WithTimeout(ctx, opts.timeout)
+		defer timeoutCancel()
+	}
+
+	browser := newBrowser(out, opts.watch, opts.json)
+
+	// only browsing, so no local service data is required
+	manager := mdns.NewMDNS("", "", "", "", "", nil, "", "", 0, opts.interfaces, opts.provider)
+	if opts.staticFile != "" || opts.dnsServer != "" {
+		manager.SetMdnsProvider(mdns.NewUnicastProvider(opts.staticFile, opts.dnsServer, opts.dnsDomain))
+	}
+	if err := manager.StartBrowsing(browser); err != nil {
+		return err
+	}
+	defer manager.Shutdown()
+
+	<-ctx.Done()
+
+	if opts.watch {
+		return nil
+	}
+
+	return browser.printServices()
+}
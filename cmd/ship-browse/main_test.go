@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/enbility/ship-go/api"
+	"github.com/enbility/ship-go/mdns"
+	"github.com/stretchr/testify/assert"
+)
+
+func testEntry(ski string) *api.MdnsEntry {
+	return &api.MdnsEntry{
+		Name:       "Demo-EVSE-" + ski,
+		Ski:        ski,
+		Identifier: "Demo-EVSE-" + ski,
+		Path:       "/ship/",
+		Register:   true,
+		Brand:      "Demo",
+		Model:      "EVSE",
+		Categories: []api.DeviceCategoryType{api.DeviceCategoryTypeEMobility},
+		Host:       "evse.local",
+		Port:       4712,
+		Addresses:  []net.IP{net.ParseIP("192.168.1.2")},
+	}
+}
+
+func TestParseOptions(t *testing.T) {
+	opts, err := parseOptions(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, mdns.MdnsProviderSelectionAll, opts.provider)
+	assert.Equal(t, 5*time.Second, opts.timeout)
+	assert.False(t, opts.watch)
+
+	opts, err = parseOptions([]string{"-provider", "zeroconf", "-iface", "eth0, wlan0", "-watch", "-json"})
+	assert.Nil(t, err)
+	assert.Equal(t, mdns.MdnsProviderSelectionGoZeroConfOnly, opts.provider)
+	assert.Equal(t, []string{"eth0", "wlan0"}, opts.interfaces)
+	assert.True(t, opts.watch)
+	assert.True(t, opts.json)
+
+	opts, err = parseOptions([]string{"-provider", "avahi"})
+	assert.Nil(t, err)
+	assert.Equal(t, mdns.MdnsProviderSelectionAvahiOnly, opts.provider)
+
+	opts, err = parseOptions([]string{"-static", "services.json", "-dns-server", "192.168.1.1", "-dns-domain", "example.com"})
+	assert.Nil(t, err)
+	assert.Equal(t, "services.json", opts.staticFile)
+	assert.Equal(t, "192.168.1.1", opts.dnsServer)
+	assert.Equal(t, "example.com", opts.dnsDomain)
+
+	_, err = parseOptions([]string{"-provider", "invalid"})
+	assert.ErrorIs(t, err, errUsage)
+
+	_, err = parseOptions([]string{"-timeout", "0s"})
+	assert.ErrorIs(t, err, errUsage)
+
+	_, err = parseOptions([]string{"argument"})
+	assert.ErrorIs(t, err, errUsage)
+
+	_, err = parseOptions([]string{"-invalid"})
+	assert.ErrorIs(t, err, errUsage)
+
+	err = run([]string{"-iface", "noifacename"}, &bytes.Buffer{})
+	assert.NotNil(t, err)
+}
+
+func TestBrowserWatch(t *testing.T) {
+	var out bytes.Buffer
+	sut := newBrowser(&out, true, false)
+
+	sut.ReportMdnsEntries(map[string]*api.MdnsEntry{"a": testEntry("a")}, true)
+	assert.True(t, strings.HasPrefix(out.String(), "added   a id=Demo-EVSE-a brand=Demo model=EVSE categories=3 register=true"))
+
+	// unchanged entries are not printed again
+	out.Reset()
+	sut.ReportMdnsEntries(map[string]*api.MdnsEntry{"a": testEntry("a")}, false)
+	assert.Equal(t, "", out.String())
+
+	updated := testEntry("a")
+	updated.Addresses = append(updated.Addresses, net.ParseIP("fd00::2"))
+	sut.ReportMdnsEntries(map[string]*api.MdnsEntry{"a": updated, "b": testEntry("b")}, true)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if assert.Equal(t, 2, len(lines)) {
+		assert.True(t, strings.HasPrefix(lines[0], "updated a"))
+		assert.Contains(t, lines[0], "addresses=192.168.1.2,fd00::2")
+		assert.True(t, strings.HasPrefix(lines[1], "added   b"))
+	}
+
+	out.Reset()
+	sut.ReportMdnsEntries(map[string]*api.MdnsEntry{"b": testEntry("b")}, true)
+	assert.True(t, strings.HasPrefix(out.String(), "removed a"))
+}
+
+func TestBrowserWatchJSON(t *testing.T) {
+	var out bytes.Buffer
+	sut := newBrowser(&out, true, true)
+
+	sut.ReportMdnsEntries(map[string]*api.MdnsEntry{"a": testEntry("a")}, true)
+	sut.ReportMdnsEntries(map[string]*api.MdnsEntry{}, true)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Equal(t, 2, len(lines))
+
+	var item event
+	err := json.Unmarshal([]byte(lines[1]), &item)
+	assert.Nil(t, err)
+	assert.Equal(t, eventRemoved, item.Event)
+	assert.Equal(t, "a", item.Service.SKI)
+	assert.Equal(t, []uint{3}, item.Service.Categories)
+	assert.Equal(t, []string{"192.168.1.2"}, item.Service.Addresses)
+}
+
+func TestBrowserPrintServices(t *testing.T) {
+	var out bytes.Buffer
+	sut := newBrowser(&out, false, false)
+
+	err := sut.printServices()
+	assert.Nil(t, err)
+	assert.Equal(t, "No SHIP services found\n", out.String())
+
+	sut.ReportMdnsEntries(map[string]*api.MdnsEntry{"b": testEntry("b"), "a": testEntry("a")}, true)
+
+	out.Reset()
+	err = sut.printServices()
+	assert.Nil(t, err)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if assert.Equal(t, 3, len(lines)) {
+		assert.True(t, strings.HasPrefix(lines[0], "SKI"))
+		assert.True(t, strings.HasPrefix(lines[1], "a "))
+		assert.True(t, strings.HasPrefix(lines[2], "b "))
+	}
+
+	sut.json = true
+	out.Reset()
+	err = sut.printServices()
+	assert.Nil(t, err)
+
+	var services []service
+	err = json.Unmarshal(out.Bytes(), &services)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(services))
+	assert.Equal(t, "evse.local", services[0].Host)
+	assert.Equal(t, 4712, services[0].Port)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/enbility/ship-go/cert"
+)
+
+// create a new certificate and write the certificate and private key as PEM files
+func runGenerate(args []string, out io.Writer) error {
+	flags := newFlagSet("generate", "-ou <OU> -o <O> -c <C> -cn <CN> [flags]")
+	organizationalUnit := flags.String("ou", "", "the organizational unit (OU) of the certificate, required")
+	organization := flags.String("o", "", "the organization (O) of the certificate, required")
+	country := flags.String("c", "", "the country (C) of the certificate, required")
+	commonName := flags.String("cn", "", "the common name (CN) of the certificate, e.g. deviceModel-deviceSerialNumber, required")
+	certPath := flags.String("cert", "cert.pem", "the file the certificate is written to")
+	keyPath := flags.String("key", "key.pem", "the file the private key is written to")
+	validity := flags.Duration("validity", cert.DefaultValidity, "the validity period of the certificate")
+	dnsNames := flags.String("dns", "", "comma separated DNS names added as subject alternative names, e.g. the hostname of the device")
+	force := flags.Bool("force", false, "overwrite existing files")
+
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if *organizationalUnit == "" || *organization == "" || *country == "" || *commonName == "" || flags.NArg() != 0 {
+		flags.Usage()
+		return errUsage
+	}
+
+	opts := cert.CertificateOptions{
+		Subject: pkix.Name{
+			OrganizationalUnit: []string{*organizationalUnit},
+			Organization:       []string{*organization},
+			Country:            []string{*country},
+			CommonName:         *commonName,
+		},
+		Validity: *validity,
+		IsCA:     true,
+	}
+	for _, name := range strings.Split(*dnsNames, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			opts.DNSNames = append(opts.DNSNames, name)
+		}
+	}
+
+	certificate, err := cert.CreateCertificateWithOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	certPEM, keyPEM, err := cert.EncodeCertificatePEM(certificate)
+	if err != nil {
+		return err
+	}
+
+	if err := writeFile(*certPath, certPEM, 0o644, *force); err != nil {
+		return err
+	}
+	if err := writeFile(*keyPath, keyPEM, 0o600, *force); err != nil {
+		return err
+	}
+
+	x509Certificate, err := x509.ParseCertificate(certificate.Certificate[0])
+	if err != nil {
+		return err
+	}
+
+	ski, err := cert.SkiFromCertificate(x509Certificate)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Certificate: %s\n", *certPath)
+	fmt.Fprintf(out, "Private key: %s\n", *keyPath)
+	fmt.Fprintf(out, "SKI:         %s\n", ski)
+
+	return nil
+}
+
+// write a file, existing files are only replaced if force is set
+func writeFile(path string, data []byte, perm os.FileMode, force bool) error {
+	flag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if !force {
+		flag |= os.O_EXCL
+	}
+
+	file, err := os.OpenFile(path, flag, perm)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/enbility/ship-go/cert"
+)
+
+// print the details of a certificate file and check its SHIP conformance
+func runInspect(args []string, out io.Writer) error {
+	flags := newFlagSet("inspect", "<cert.pem>")
+
+	path, err := parseCertificateArgument(flags, args)
+	if err != nil {
+		return err
+	}
+
+	certificate, err := readCertificate(path)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Subject:             %s\n", certificate.Subject)
+	fmt.Fprintf(out, "Issuer:              %s\n", certificate.Issuer)
+	fmt.Fprintf(out, "Serial number:       %s\n", certificate.SerialNumber)
+	fmt.Fprintf(out, "Not before:          %s\n", certificate.NotBefore.Format(time.RFC3339))
+	fmt.Fprintf(out, "Not after:           %s\n", certificate.NotAfter.Format(time.RFC3339))
+	fmt.Fprintf(out, "Public key:          %s\n", publicKeyDescription(certificate))
+	fmt.Fprintf(out, "Signature algorithm: %s\n", certificate.SignatureAlgorithm)
+	if ski, err := cert.SkiFromCertificate(certificate); err == nil {
+		fmt.Fprintf(out, "SKI:                 %s\n", ski)
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "SHIP conformance:")
+
+	findings := cert.ValidateShipCertificate(certificate)
+	if len(findings) == 0 {
+		fmt.Fprintln(out, "  no findings")
+	}
+	for _, finding := range findings {
+		fmt.Fprintf(out, "  [%s] %s: %s\n", strings.ToUpper(finding.Severity.String()), finding.Check, finding.Message)
+	}
+
+	return findings.Err()
+}
+
+func publicKeyDescription(certificate *x509.Certificate) string {
+	if key, ok := certificate.PublicKey.(*ecdsa.PublicKey); ok {
+		return fmt.Sprintf("%s %s", certificate.PublicKeyAlgorithm, key.Curve.Params().Name)
+	}
+
+	return certificate.PublicKeyAlgorithm.String()
+}
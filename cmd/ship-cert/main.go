@@ -0,0 +1,106 @@
+// Command ship-cert creates and inspects SHIP certificates
+//
+// Usage:
+//
+//	ship-cert generate -ou <OU> -o <O> -c <C> -cn <CN> [-cert cert.pem] [-key key.pem] [-validity 87600h] [-dns host.local]
+//	ship-cert ski <cert.pem>
+//	ship-cert inspect <cert.pem>
+//	ship-cert qr -id <SHIP ID> [-brand <brand>] [-type <type>] [-model <model>] [-serial <serial>] [-categories <categories>] <cert.pem>
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `Usage: ship-cert <command> [flags]
+
+Commands:
+  generate  create a new SHIP certificate and private key as PEM files
+  ski       print the SKI of a certificate
+  inspect   print details of a certificate and check its SHIP conformance
+  qr        print the SHIP QR code text for a certificate and device
+
+Run "ship-cert <command> -h" for the flags of a command.
+`
+
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		if !errors.Is(err, errUsage) {
+			fmt.Fprintln(os.Stderr, "error:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// run the command given by args and write its output to out
+func run(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return errUsage
+	}
+
+	command, args := args[0], args[1:]
+
+	switch command {
+	case "generate":
+		return runGenerate(args, out)
+	case "ski":
+		return runSKI(args, out)
+	case "inspect":
+		return runInspect(args, out)
+	case "qr":
+		return runQR(args, out)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(out, usage)
+		return nil
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", command, usage)
+		return errUsage
+	}
+}
+
+// create the flag set of a command, the errors are returned to run
+func newFlagSet(name, arguments string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: ship-cert %s %s\n", name, arguments)
+		flags.PrintDefaults()
+	}
+
+	return flags
+}
+
+// parse the flags of a command, the flag package already reported invalid flags
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+
+	return nil
+}
+
+// parse the flags of a command which expects a single certificate file argument
+func parseCertificateArgument(flags *flag.FlagSet, args []string) (string, error) {
+	if err := parseFlags(flags, args); err != nil {
+		return "", err
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return "", errUsage
+	}
+
+	return flags.Arg(0), nil
+}
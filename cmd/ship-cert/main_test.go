@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"crypto/x509/pkix"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/enbility/ship-go/cert"
+	"github.com/stretchr/testify/assert"
+)
+
+func generateTestCertificate(t *testing.T) (string, string) {
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "cert.pem")
+	keyPath := filepath.Join(dir, "key.pem")
+
+	var out bytes.Buffer
+	err := run([]string{"generate", "-ou", "unit", "-o", "org", "-c", "DE", "-cn", "model-serial",
+		"-cert", certPath, "-key", keyPath}, &out)
+	assert.Nil(t, err)
+	assert.Contains(t, out.String(), "SKI:")
+
+	return certPath, keyPath
+}
+
+func TestRun(t *testing.T) {
+	var out bytes.Buffer
+
+	err := run(nil, &out)
+	assert.ErrorIs(t, err, errUsage)
+
+	err = run([]string{"unknown"}, &out)
+	assert.ErrorIs(t, err, errUsage)
+
+	err = run([]string{"help"}, &out)
+	assert.Nil(t, err)
+	assert.Contains(t, out.String(), "generate")
+}
+
+func TestGenerate(t *testing.T) {
+	certPath, keyPath := generateTestCertificate(t)
+
+	_, err := cert.LoadCertificate(certPath, keyPath)
+	assert.Nil(t, err)
+
+	var out bytes.Buffer
+	// existing files are not overwritten
+	err = run([]string{"generate", "-ou", "unit", "-o", "org", "-c", "DE", "-cn", "model-serial",
+		"-cert", certPath, "-key", keyPath}, &out)
+	assert.NotNil(t, err)
+
+	err = run([]string{"generate", "-ou", "unit", "-o", "org", "-c", "DE", "-cn", "model-serial",
+		"-cert", certPath, "-key", keyPath, "-force"}, &out)
+	assert.Nil(t, err)
+
+	err = run([]string{"generate", "-ou", "unit", "-o", "org", "-c", "DE", "-cn", "model-serial",
+		"-cert", certPath, "-key", keyPath, "-force", "-validity", "24h", "-dns", "device.local, device"}, &out)
+	assert.Nil(t, err)
+
+	certificate, err := cert.LoadCertificate(certPath, keyPath)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"device.local", "device"}, certificate.Leaf.DNSNames)
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), certificate.Leaf.NotAfter, time.Minute)
+
+	err = run([]string{"generate", "-ou", "unit", "-o", "org", "-c", "DE", "-cn", "model-serial",
+		"-cert", certPath, "-key", keyPath, "-force", "-validity", "-1h"}, &out)
+	assert.ErrorIs(t, err, cert.ErrInvalidCertificateOptions)
+
+	err = run([]string{"generate", "-ou", "unit"}, &out)
+	assert.ErrorIs(t, err, errUsage)
+
+	err = run([]string{"generate", "-invalid"}, &out)
+	assert.ErrorIs(t, err, errUsage)
+}
+
+func TestSKI(t *testing.T) {
+	certPath, _ := generateTestCertificate(t)
+
+	var out bytes.Buffer
+	err := run([]string{"ski", certPath}, &out)
+	assert.Nil(t, err)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Equal(t, 2, len(lines))
+	assert.Equal(t, 40, len(strings.TrimSpace(strings.TrimPrefix(lines[0], "Normalized:"))))
+	assert.Equal(t, 49, len(strings.TrimSpace(strings.TrimPrefix(lines[1], "Grouped:"))))
+
+	err = run([]string{"ski"}, &out)
+	assert.ErrorIs(t, err, errUsage)
+
+	err = run([]string{"ski", filepath.Join(t.TempDir(), "missing.pem")}, &out)
+	assert.NotNil(t, err)
+
+	assert.Equal(t, "1234 5678 9", groupSKI("123456789"))
+}
+
+func TestInspect(t *testing.T) {
+	certPath, keyPath := generateTestCertificate(t)
+
+	var out bytes.Buffer
+	err := run([]string{"inspect", certPath}, &out)
+	assert.Nil(t, err)
+	assert.Contains(t, out.String(), "ECDSA P-256")
+	assert.NotContains(t, out.String(), "[ERROR]")
+
+	// the key file does not contain a certificate
+	err = run([]string{"inspect", keyPath}, &out)
+	assert.NotNil(t, err)
+
+	// findings with the error severity fail the inspection
+	expired, err := cert.CreateCertificateWithOptions(cert.CertificateOptions{
+		Subject:   pkix.Name{CommonName: "expired"},
+		NotBefore: time.Now().Add(-2 * time.Hour),
+		Validity:  time.Hour,
+	})
+	assert.Nil(t, err)
+	expiredPath := filepath.Join(t.TempDir(), "expired.pem")
+	err = cert.SaveCertificate(expired, expiredPath, filepath.Join(t.TempDir(), "expired.key"))
+	assert.Nil(t, err)
+
+	out.Reset()
+	err = run([]string{"inspect", expiredPath}, &out)
+	assert.ErrorIs(t, err, cert.ErrNonConformingCertificate)
+	assert.Contains(t, out.String(), "[ERROR] validity: expired at")
+}
+
+func TestQR(t *testing.T) {
+	certPath, _ := generateTestCertificate(t)
+
+	certificate, err := readCertificate(certPath)
+	assert.Nil(t, err)
+
+	var out bytes.Buffer
+	err = run([]string{"qr", "-id", "Demo-EVSE-123", "-brand", "Demo", "-type", "EVSE", "-model", "EVSE", "-serial", "123",
+		"-categories", "3, 4", certPath}, &out)
+	assert.Nil(t, err)
+
+	text := strings.TrimSpace(out.String())
+	expected := fmt.Sprintf("SHIP;SKI:%0x;ID:Demo-EVSE-123;BRAND:Demo;TYPE:EVSE;MODEL:EVSE;SERIAL:123;CAT:3,4;ENDSHIP;", certificate.SubjectKeyId)
+	assert.Equal(t, expected, text)
+
+	err = run([]string{"qr", certPath}, &out)
+	assert.ErrorIs(t, err, errUsage)
+
+	err = run([]string{"qr", "-id", "id", "-categories", "invalid", certPath}, &out)
+	assert.NotNil(t, err)
+}
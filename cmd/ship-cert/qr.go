@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/enbility/ship-go/api"
+	"github.com/enbility/ship-go/cert"
+	"github.com/enbility/ship-go/mdns"
+)
+
+// print the SHIP QR code text for a certificate and device
+//
+// The text is created by MdnsManager.QRCodeText, so it is identical to the one of a running service
+func runQR(args []string, out io.Writer) error {
+	flags := newFlagSet("qr", "-id <SHIP ID> [flags] <cert.pem>")
+	identifier := flags.String("id", "", "the SHIP ID of the device, required")
+	brand := flags.String("brand", "", "the brand of the device")
+	deviceType := flags.String("type", "", "the device type, e.g. EnergyManagementSystem")
+	model := flags.String("model", "", "the model of the device")
+	serial := flags.String("serial", "", "the serial number of the device")
+	categories := flags.String("categories", "", "the comma separated device categories, e.g. 2,3")
+
+	path, err := parseCertificateArgument(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if *identifier == "" {
+		flags.Usage()
+		return errUsage
+	}
+
+	deviceCategories, err := parseCategories(*categories)
+	if err != nil {
+		return err
+	}
+
+	certificate, err := readCertificate(path)
+	if err != nil {
+		return err
+	}
+
+	ski, err := cert.SkiFromCertificate(certificate)
+	if err != nil {
+		return err
+	}
+
+	// the manager is not started, it is only used to create the text
+	manager := mdns.NewMDNS(ski, *brand, *model, *deviceType, *serial, deviceCategories,
+		*identifier, *identifier, 0, nil, mdns.MdnsProviderSelectionAll)
+
+	fmt.Fprintln(out, manager.QRCodeText())
+
+	return nil
+}
+
+// parse comma separated device category numbers
+func parseCategories(value string) ([]api.DeviceCategoryType, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	var categories []api.DeviceCategoryType
+	for _, item := range strings.Split(value, ",") {
+		category, err := strconv.ParseUint(strings.TrimSpace(item), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid device category %q", item)
+		}
+
+		categories = append(categories, api.DeviceCategoryType(category))
+	}
+
+	return categories, nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/enbility/ship-go/cert"
+)
+
+// print the SKI of a certificate file
+func runSKI(args []string, out io.Writer) error {
+	flags := newFlagSet("ski", "<cert.pem>")
+
+	path, err := parseCertificateArgument(flags, args)
+	if err != nil {
+		return err
+	}
+
+	certificate, err := readCertificate(path)
+	if err != nil {
+		return err
+	}
+
+	ski, err := cert.SkiFromCertificate(certificate)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Normalized: %s\n", ski)
+	fmt.Fprintf(out, "Grouped:    %s\n", groupSKI(ski))
+
+	return nil
+}
+
+// format a normalized SKI in groups of 4 characters, as shown by many devices
+func groupSKI(ski string) string {
+	var groups []string
+	for len(ski) > 4 {
+		groups = append(groups, ski[:4])
+		ski = ski[4:]
+	}
+	groups = append(groups, ski)
+
+	return strings.Join(groups, " ")
+}
+
+// read the first certificate of a PEM or DER encoded file
+func readCertificate(path string) (*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.Contains(string(data), "-----BEGIN") {
+		return x509.ParseCertificate(data)
+	}
+
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, errors.New("no certificate found in " + path)
+		}
+
+		if block.Type == "CERTIFICATE" {
+			return x509.ParseCertificate(block.Bytes)
+		}
+	}
+}
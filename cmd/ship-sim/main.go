@@ -0,0 +1,178 @@
+// Command ship-sim runs a simulated SHIP service following a scenario file
+//
+// Usage:
+//
+//	ship-sim -scenario <scenario.json> [-cert cert.pem -key key.pem] [-port 4711] [-id <SHIP ID>] [-mdns] [flags]
+//
+// See the simulator package for the scenario format.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/enbility/ship-go/api"
+	"github.com/enbility/ship-go/cert"
+	"github.com/enbility/ship-go/mdns"
+	"github.com/enbility/ship-go/model"
+	"github.com/enbility/ship-go/simulator"
+)
+
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		if !errors.Is(err, errUsage) {
+			fmt.Fprintln(os.Stderr, "error:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// parse the command line into a simulator configuration
+func parseConfig(args []string, out io.Writer) (simulator.Config, error) {
+	flags := flag.NewFlagSet("ship-sim", flag.ContinueOnError)
+	scenarioPath := flags.String("scenario", "", "the JSON scenario file, required")
+	certPath := flags.String("cert", "", "the PEM certificate file, a temporary certificate is created if not set")
+	keyPath := flags.String("key", "", "the PEM private key file of the certificate")
+	port := flags.Int("port", 4711, "the port of the websocket server")
+	shipID := flags.String("id", "ship-sim", "the SHIP ID of the simulated service")
+	brand := flags.String("brand", "ship-go", "the brand announced via mDNS")
+	deviceModel := flags.String("model", "ship-sim", "the model announced via mDNS")
+	deviceType := flags.String("type", "EnergyManagementSystem", "the device type announced via mDNS")
+	serial := flags.String("serial", "", "the serial number announced via mDNS")
+	categories := flags.String("categories", "", "the comma separated device categories announced via mDNS, e.g. 2,3")
+	announce := flags.Bool("mdns", false, "announce the service via mDNS")
+	provider := flags.String("provider", "auto", "the mDNS provider: auto, avahi or zeroconf")
+	interfaces := flags.String("iface", "", "comma separated network interfaces used for mDNS, default all")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return simulator.Config{}, err
+		}
+		return simulator.Config{}, errUsage
+	}
+
+	if *scenarioPath == "" || flags.NArg() != 0 || (*certPath == "") != (*keyPath == "") {
+		flags.Usage()
+		return simulator.Config{}, errUsage
+	}
+
+	config := simulator.Config{
+		Port:   *port,
+		ShipID: *shipID,
+		Brand:  *brand,
+		Model:  *deviceModel,
+		Type:   *deviceType,
+		Serial: *serial,
+		Mdns:   *announce,
+		StateUpdate: func(ski string, state model.ShipState) {
+			if state.Error != nil {
+				fmt.Fprintf(out, "%s %s state %d: %s\n", time.Now().Format(time.TimeOnly), ski, state.State, state.Error)
+				return
+			}
+			fmt.Fprintf(out, "%s %s state %d\n", time.Now().Format(time.TimeOnly), ski, state.State)
+		},
+	}
+
+	switch *provider {
+	case "auto":
+		config.MdnsProvider = mdns.MdnsProviderSelectionAll
+	case "avahi":
+		config.MdnsProvider = mdns.MdnsProviderSelectionAvahiOnly
+	case "zeroconf":
+		config.MdnsProvider = mdns.MdnsProviderSelectionGoZeroConfOnly
+	default:
+		fmt.Fprintf(flags.Output(), "invalid provider %q\n", *provider)
+		flags.Usage()
+		return simulator.Config{}, errUsage
+	}
+
+	if *interfaces != "" {
+		for _, name := range strings.Split(*interfaces, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				config.Interfaces = append(config.Interfaces, name)
+			}
+		}
+	}
+
+	var err error
+	if config.Categories, err = parseCategories(*categories); err != nil {
+		return simulator.Config{}, err
+	}
+
+	if config.Scenario, err = simulator.LoadScenario(*scenarioPath); err != nil {
+		return simulator.Config{}, err
+	}
+
+	if *certPath == "" {
+		config.Certificate, err = cert.CreateCertificate("ship-sim", "ship-go", "DE", *shipID)
+	} else {
+		config.Certificate, err = cert.LoadCertificate(*certPath, *keyPath)
+	}
+	if err != nil {
+		return simulator.Config{}, err
+	}
+
+	return config, nil
+}
+
+// parse comma separated device category numbers
+func parseCategories(value string) ([]api.DeviceCategoryType, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	var categories []api.DeviceCategoryType
+	for _, item := range strings.Split(value, ",") {
+		category, err := strconv.ParseUint(strings.TrimSpace(item), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid device category %q", item)
+		}
+
+		categories = append(categories, api.DeviceCategoryType(category))
+	}
+
+	return categories, nil
+}
+
+// run the simulator until interrupted
+func run(args []string, out io.Writer) error {
+	config, err := parseConfig(args, out)
+	if err != nil {
+		return err
+	}
+
+	sim, err := simulator.New(config)
+	if err != nil {
+		return err
+	}
+
+	if err := sim.Start(); err != nil {
+		return err
+	}
+	defer sim.Close()
+
+	fmt.Fprintf(out, "Simulating %q on %s\n", config.Scenario.Name, sim.Addr())
+	fmt.Fprintf(out, "SHIP ID: %s\n", config.ShipID)
+	fmt.Fprintf(out, "SKI:     %s\n", sim.SKI())
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	<-ctx.Done()
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/enbility/ship-go/api"
+	"github.com/enbility/ship-go/cert"
+	"github.com/enbility/ship-go/mdns"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseConfig(t *testing.T) {
+	var out bytes.Buffer
+
+	config, err := parseConfig([]string{"-scenario", "testdata/pending_accept.json"}, &out)
+	assert.Nil(t, err)
+	assert.Equal(t, "pending then accept", config.Scenario.Name)
+	assert.Equal(t, 4711, config.Port)
+	assert.Equal(t, "ship-sim", config.ShipID)
+	assert.Equal(t, mdns.MdnsProviderSelectionAll, config.MdnsProvider)
+	assert.NotEqual(t, 0, len(config.Certificate.Certificate))
+
+	config, err = parseConfig([]string{"-scenario", "testdata/reject.json", "-port", "0", "-id", "sim",
+		"-mdns", "-provider", "zeroconf", "-iface", "eth0", "-categories", "2,3"}, &out)
+	assert.Nil(t, err)
+	assert.Equal(t, "sim", config.ShipID)
+	assert.True(t, config.Mdns)
+	assert.Equal(t, mdns.MdnsProviderSelectionGoZeroConfOnly, config.MdnsProvider)
+	assert.Equal(t, []string{"eth0"}, config.Interfaces)
+	assert.Equal(t, []api.DeviceCategoryType{2, 3}, config.Categories)
+
+	invalid := [][]string{
+		{},
+		{"-invalid"},
+		{"-scenario", "testdata/reject.json", "-cert", "cert.pem"},
+		{"-scenario", "testdata/reject.json", "-provider", "invalid"},
+		{"-scenario", "testdata/reject.json", "-categories", "invalid"},
+		{"-scenario", "testdata/missing.json"},
+		{"-scenario", "testdata/reject.json", "-cert", "missing.pem", "-key", "missing.pem"},
+	}
+	for _, args := range invalid {
+		_, err = parseConfig(args, &out)
+		assert.NotNil(t, err, args)
+	}
+}
+
+func TestParseConfigCertificate(t *testing.T) {
+	certificate, err := cert.CreateCertificate("unit", "org", "DE", "sim")
+	assert.Nil(t, err)
+
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "cert.pem")
+	keyPath := filepath.Join(dir, "key.pem")
+	err = cert.SaveCertificate(certificate, certPath, keyPath)
+	assert.Nil(t, err)
+
+	config, err := parseConfig([]string{"-scenario", "testdata/reject.json", "-cert", certPath, "-key", keyPath}, &bytes.Buffer{})
+	assert.Nil(t, err)
+	assert.Equal(t, certificate.Certificate, config.Certificate.Certificate)
+}
@@ -3,12 +3,21 @@ package hub
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/enbility/ship-go/api"
+	"github.com/enbility/ship-go/cert"
 	"github.com/enbility/ship-go/logging"
+	"github.com/enbility/ship-go/quirks"
+	"github.com/enbility/ship-go/ship"
 	"github.com/enbility/ship-go/util"
+	"github.com/enbility/ship-go/ws"
 )
 
 // used for randomizing the connection initiation delay
@@ -33,6 +42,8 @@ type Hub struct {
 	// which attempt is it to initate an connection to the remote SKI
 	connectionAttemptCounter map[string]int
 	connectionAttemptRunning map[string]bool
+	// increased to invalidate all pending connection attempts
+	connectionAttemptGeneration uint64
 
 	port        int
 	certifciate tls.Certificate
@@ -43,6 +54,49 @@ type Hub struct {
 
 	autoaccept bool
 
+	// the timer values used for new SHIP handshakes
+	handshakeTimings ship.HandshakeTimings
+
+	// the timer values used for new websocket connections
+	websocketTimings ws.Timings
+
+	// the round trip time limits used for new websocket connections
+	latencyThresholds ws.LatencyThresholds
+
+	// the workaround profiles for remote devices deviating from the SHIP specification
+	quirkRegistry *quirks.Registry
+
+	// the limits of the SPINE message buffer used for new connections
+	spineBufferLimits ship.SpineBufferLimits
+
+	// the chain of interceptors invoked for every SPINE payload of new connections
+	payloadInterceptors []api.PayloadInterceptorInterface
+
+	// if set, every SHIP message of new connections is recorded into a file in this directory
+	recordingDirectory string
+
+	// require the SKI of peer certificates to be derived from their public key
+	skiVerification bool
+
+	// how SHIP conformance findings of the local and of peer certificates are handled
+	localCertificateValidation cert.ValidationMode
+	peerCertificateValidation  cert.ValidationMode
+
+	// the checks which have to pass for peer certificates, independent of the validation mode
+	enforcedPeerCertificateChecks []cert.Check
+
+	// the tolerance applied to the validity period of peer certificates
+	clockSkewTolerance time.Duration
+
+	// if set, peers with certificates chaining to one of these CAs are trusted automatically
+	installerCAs *x509.CertPool
+
+	// the SKIs which were unregistered and are not trusted by the installer CAs again
+	unregisteredSKIs map[string]bool
+
+	// the SHIP conformance findings of the local certificate
+	certificateFindings cert.Findings
+
 	// The list of known remote services
 	remoteServices map[string]*api.ServiceDetails
 
@@ -74,21 +128,292 @@ func NewHub(hubReader api.HubReaderInterface,
 		connectionAttemptCounter: make(map[string]int),
 		connectionAttemptRunning: make(map[string]bool),
 		remoteServices:           make(map[string]*api.ServiceDetails),
+		unregisteredSKIs:         make(map[string]bool),
 		knownMdnsEntries:         make([]*api.MdnsEntry, 0),
 		hubReader:                hubReader,
 		port:                     port,
 		certifciate:              certificate,
 		localService:             localService,
 		mdns:                     mdns,
+		handshakeTimings:         ship.DefaultHandshakeTimings(),
+		websocketTimings:         ws.DefaultTimings(),
+		quirkRegistry:            quirks.NewLegacyRegistry(),
+		spineBufferLimits:        ship.DefaultSpineBufferLimits(),
+
+		skiVerification:            true,
+		localCertificateValidation: cert.ValidationModeWarn,
+		peerCertificateValidation:  cert.ValidationModeWarn,
+		clockSkewTolerance:         cert.DefaultClockSkew,
+	}
+
+	if len(certificate.Certificate) > 0 {
+		if leaf, err := x509.ParseCertificate(certificate.Certificate[0]); err == nil {
+			hub.certificateFindings = cert.ValidateShipCertificate(leaf)
+		}
 	}
 
 	return hub
 }
 
+// Set the timer values used for the SHIP handshake of new connections
+//
+// returns an error if the values do not conform to the SHIP specification
+func (h *Hub) SetHandshakeTimings(timings ship.HandshakeTimings) error {
+	if err := timings.Validate(); err != nil {
+		return err
+	}
+
+	h.muxReg.Lock()
+	defer h.muxReg.Unlock()
+
+	h.handshakeTimings = timings
+
+	return nil
+}
+
+// Set the timer values and round trip time limits used for new websocket connections
+//
+// Both are set together, as the limits have to be reachable with the timer values.
+// returns an error if the timer values can not be used, or an error wrapping
+// ws.ErrInvalidLatencyThresholds if a limit can not be reached with them
+func (h *Hub) SetWebsocketTimings(timings ws.Timings, thresholds ws.LatencyThresholds) error {
+	if err := timings.Validate(); err != nil {
+		return err
+	}
+
+	if err := thresholds.Validate(timings); err != nil {
+		return err
+	}
+
+	h.muxReg.Lock()
+	defer h.muxReg.Unlock()
+
+	h.websocketTimings = timings
+	h.latencyThresholds = thresholds
+
+	return nil
+}
+
+// Set the limits of the buffer for SPINE messages received before the handshake of new connections completed
+//
+// returns an error if the limits can not be used
+func (h *Hub) SetSpineBufferLimits(limits ship.SpineBufferLimits) error {
+	if err := limits.Validate(); err != nil {
+		return err
+	}
+
+	h.muxReg.Lock()
+	defer h.muxReg.Unlock()
+
+	h.spineBufferLimits = limits
+
+	return nil
+}
+
+// Set the chain of interceptors invoked for every incoming and outgoing SPINE payload of new connections
+//
+// The interceptors are invoked in the provided order and can observe, modify or drop payloads
+func (h *Hub) SetPayloadInterceptors(interceptors ...api.PayloadInterceptorInterface) {
+	h.muxReg.Lock()
+	defer h.muxReg.Unlock()
+
+	h.payloadInterceptors = interceptors
+}
+
+// Record every SHIP message of new connections into a JSON lines file per connection
+//
+// The files are named `<SKI>-<timestamp>.jsonl` and can be replayed using the replay package.
+// An empty directory disables recording, which is the default
+func (h *Hub) SetRecordingDirectory(directory string) {
+	h.muxReg.Lock()
+	defer h.muxReg.Unlock()
+
+	h.recordingDirectory = directory
+}
+
+// Enable or disable the verification of the SKI of peer certificates
+//
+// If enabled, the SKI has to be the SHA-1 hash of the public key of the certificate,
+// see cert.VerifiedSkiFromCertificate. Enabled by default.
+// Independent of this setting, the public key of a paired SKI is pinned
+// on the first connection and a different key for the same SKI is refused
+func (h *Hub) SetSkiVerification(enabled bool) {
+	h.muxReg.Lock()
+	defer h.muxReg.Unlock()
+
+	h.skiVerification = enabled
+}
+
+// Set how SHIP conformance findings of the local certificate are handled
+//
+// The certificate is validated in NewHub, the findings are handled in Start.
+// With cert.ValidationModeReject the hub does not start if the certificate
+// violates the SHIP specification. Defaults to cert.ValidationModeWarn
+func (h *Hub) SetLocalCertificateValidation(mode cert.ValidationMode) {
+	h.muxReg.Lock()
+	defer h.muxReg.Unlock()
+
+	h.localCertificateValidation = mode
+}
+
+// Set how SHIP conformance findings of peer certificates are handled
+//
+// With cert.ValidationModeReject the TLS handshake fails if a peer certificate
+// violates the SHIP specification. Defaults to cert.ValidationModeWarn
+func (h *Hub) SetPeerCertificateValidation(mode cert.ValidationMode) {
+	h.muxReg.Lock()
+	defer h.muxReg.Unlock()
+
+	h.peerCertificateValidation = mode
+}
+
+// Set the checks which peer certificates have to pass, independent of the peer certificate validation mode
+//
+// A certificate is rejected if one of the checks reports a warning or an error, e.g.
+// cert.CheckSelfSignature rejects certificates with an invalid self signature and
+// certificates which are not self signed. Supported are all checks of cert.ValidateShipCertificate,
+// e.g. cert.CheckSelfSignature, cert.CheckValidity and cert.CheckKeyUsage. Defaults to none
+func (h *Hub) SetEnforcedPeerCertificateChecks(checks ...cert.Check) {
+	h.muxReg.Lock()
+	defer h.muxReg.Unlock()
+
+	h.enforcedPeerCertificateChecks = slices.Clone(checks)
+}
+
+// Set the tolerance for clocks of remote devices applied to the validity period of peer certificates
+//
+// Defaults to cert.DefaultClockSkew, returns an error for negative values
+func (h *Hub) SetClockSkewTolerance(tolerance time.Duration) error {
+	if tolerance < 0 {
+		return errors.New("the clock skew tolerance must not be negative")
+	}
+
+	h.muxReg.Lock()
+	defer h.muxReg.Unlock()
+
+	h.clockSkewTolerance = tolerance
+
+	return nil
+}
+
+// Set the installer CAs used to trust remote services automatically
+//
+// In addition to pairing services with self signed certificates, services are trusted
+// without user interaction if their certificate chains to one of the CAs, e.g. in large
+// installations where the installer issues the certificates of all devices,
+// see cert.CreateCertificateRequest and cert.ImportSignedCertificate.
+// A service is trusted once the TLS handshake of a connection succeeded, so discovered
+// services still have to be registered or queued to be connected to.
+// Automatically trusted services are reported with api.ConnectionStateTrustedByInstallerCA
+// via ServicePairingDetailUpdate. Services removed with UnregisterRemoteSKI are not
+// trusted again until they are registered, UnregisterRemoteSKI should therefore be
+// called again for removed services after a restart.
+// A nil pool disables the automatic trust, which is the default
+func (h *Hub) SetInstallerCAs(roots *x509.CertPool) {
+	h.muxReg.Lock()
+	defer h.muxReg.Unlock()
+
+	h.installerCAs = roots
+}
+
+// Returns the SHIP conformance findings of the local certificate
+func (h *Hub) CertificateFindings() cert.Findings {
+	h.muxReg.Lock()
+	defer h.muxReg.Unlock()
+
+	return slices.Clone(h.certificateFindings)
+}
+
+// Replace the local certificate used for new connections
+//
+// Existing connections are not affected. The certificate has to provide the SKI
+// of the current certificate, as it identifies the local service towards paired
+// services, e.g. use cert.RenewCertificate to create it.
+// With cert.ValidationModeReject as local certificate validation, certificates
+// violating the SHIP specification are refused
+func (h *Hub) SetCertificate(certificate tls.Certificate) error {
+	if len(certificate.Certificate) == 0 {
+		return cert.ErrNoCertificate
+	}
+
+	leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+	if err != nil {
+		return err
+	}
+
+	ski, err := cert.SkiFromCertificate(leaf)
+	if err != nil {
+		return err
+	}
+
+	findings := cert.ValidateShipCertificate(leaf)
+
+	h.muxReg.Lock()
+	defer h.muxReg.Unlock()
+
+	if len(h.certifciate.Certificate) > 0 {
+		if current, err := x509.ParseCertificate(h.certifciate.Certificate[0]); err == nil {
+			if currentSKI, err := cert.SkiFromCertificate(current); err == nil && currentSKI != ski {
+				return fmt.Errorf("the SKI %s of the certificate does not match the SKI %s of the current certificate", ski, currentSKI)
+			}
+		}
+	}
+
+	if h.localCertificateValidation != cert.ValidationModeOff {
+		logCertificateFindings("local certificate", findings)
+	}
+
+	if h.localCertificateValidation == cert.ValidationModeReject {
+		if err := findings.Err(); err != nil {
+			return err
+		}
+	}
+
+	h.certifciate = certificate
+	h.certificateFindings = findings
+
+	return nil
+}
+
+// returns the local certificate used for new connections
+func (h *Hub) currentCertificate() *tls.Certificate {
+	h.muxReg.Lock()
+	defer h.muxReg.Unlock()
+
+	certificate := h.certifciate
+
+	return &certificate
+}
+
+// Set the registry used to find the workarounds for new connections
+//
+// defaults to quirks.NewLegacyRegistry, which applies the legacy workarounds to all remote devices
+// like ShipConnection does. Use quirks.NewRegistry to apply workarounds only to matching remote devices,
+// nil restores the default
+func (h *Hub) SetQuirkRegistry(registry *quirks.Registry) {
+	if registry == nil {
+		registry = quirks.NewLegacyRegistry()
+	}
+
+	h.muxReg.Lock()
+	defer h.muxReg.Unlock()
+
+	h.quirkRegistry = registry
+}
+
 var _ api.HubInterface = (*Hub)(nil)
+var _ api.HubLatencyInterface = (*Hub)(nil)
 
 // Start the ConnectionsHub with all its services
+//
+// The hub is not started if the local certificate validation is set to
+// cert.ValidationModeReject and the certificate violates the SHIP specification
 func (h *Hub) Start() {
+	if err := h.checkLocalCertificate(); err != nil {
+		logging.Log().Error("not starting the hub:", err)
+		return
+	}
+
 	h.muxStarted.Lock()
 	h.hasStarted = true
 	h.muxStarted.Unlock()
@@ -105,6 +430,39 @@ func (h *Hub) Start() {
 	}
 }
 
+// log the findings of the local certificate according to the validation mode
+//
+// returns an error if the certificate has to be rejected
+func (h *Hub) checkLocalCertificate() error {
+	h.muxReg.Lock()
+	mode := h.localCertificateValidation
+	findings := h.certificateFindings
+	h.muxReg.Unlock()
+
+	if mode == cert.ValidationModeOff {
+		return nil
+	}
+
+	logCertificateFindings("local certificate", findings)
+
+	if mode == cert.ValidationModeReject {
+		return findings.Err()
+	}
+
+	return nil
+}
+
+// log certificate findings, errors are logged with the error level
+func logCertificateFindings(prefix string, findings cert.Findings) {
+	for _, finding := range findings {
+		if finding.Severity == cert.SeverityError {
+			logging.Log().Errorf("%s: %s", prefix, finding)
+		} else {
+			logging.Log().Infof("%s: %s", prefix, finding)
+		}
+	}
+}
+
 // close all connections
 func (h *Hub) Shutdown() {
 	h.mdns.Shutdown()
@@ -8,6 +8,7 @@ import (
 	"math/rand"
 	"net"
 	"net/http"
+	"path/filepath"
 	"slices"
 	"strconv"
 	"time"
@@ -15,32 +16,210 @@ import (
 	"github.com/enbility/ship-go/api"
 	"github.com/enbility/ship-go/cert"
 	"github.com/enbility/ship-go/logging"
+	"github.com/enbility/ship-go/quirks"
 	"github.com/enbility/ship-go/ship"
+	"github.com/enbility/ship-go/util"
 	"github.com/enbility/ship-go/ws"
 	"github.com/gorilla/websocket"
 )
 
 // Websocket connection handling
 func (h *Hub) verifyPeerCertificate(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
-	skiFound := false
-	for _, v := range rawCerts {
+	h.muxReg.Lock()
+	skiVerification := h.skiVerification
+	validationMode := h.peerCertificateValidation
+	enforcedChecks := h.enforcedPeerCertificateChecks
+	options := cert.ValidationOptions{ClockSkew: h.clockSkewTolerance}
+	installerCAs := h.installerCAs
+	h.muxReg.Unlock()
+
+	var skiCertificate *x509.Certificate
+	var skiIndex int
+	for i, v := range rawCerts {
 		cerificate, err := x509.ParseCertificate(v)
 		if err != nil {
 			return err
 		}
 
 		if _, err := cert.SkiFromCertificate(cerificate); err == nil {
-			skiFound = true
+			skiCertificate = cerificate
+			skiIndex = i
 			break
 		}
 	}
-	if !skiFound {
+	if skiCertificate == nil {
 		return errors.New("no valid SKI provided in certificate")
 	}
 
+	if skiVerification {
+		if _, err := cert.VerifiedSkiFromCertificate(skiCertificate); err != nil {
+			return err
+		}
+	}
+
+	// the certificates following the SKI certificate are the intermediates of its chain
+	trustedByInstallerCA := false
+	if installerCAs != nil {
+		var intermediates []*x509.Certificate
+		for _, v := range rawCerts[skiIndex+1:] {
+			if intermediate, err := x509.ParseCertificate(v); err == nil {
+				intermediates = append(intermediates, intermediate)
+			}
+		}
+		trustedByInstallerCA = verifyInstallerTrust(skiCertificate, intermediates, installerCAs)
+	}
+
+	if err := h.verifyPublicKeyPin(skiCertificate); err != nil {
+		return err
+	}
+
+	if validationMode == cert.ValidationModeOff && len(enforcedChecks) == 0 {
+		return nil
+	}
+
+	findings := cert.ValidateShipCertificateWithOptions(skiCertificate, options)
+	if trustedByInstallerCA {
+		// the certificate is signed by the installer CA instead of being self signed
+		findings = slices.DeleteFunc(findings, func(finding cert.Finding) bool {
+			return finding.Check == cert.CheckSelfSignature
+		})
+	}
+	if validationMode != cert.ValidationModeOff {
+		logCertificateFindings(fmt.Sprintf("peer certificate %0x", skiCertificate.SubjectKeyId), findings)
+	}
+
+	if err := findings.ForChecks(enforcedChecks...).ErrAtLeast(cert.SeverityWarning); err != nil {
+		return err
+	}
+
+	if validationMode == cert.ValidationModeReject {
+		return findings.Err()
+	}
+
+	return nil
+}
+
+// check the public key of a paired SKI against the pinned public key
+func (h *Hub) verifyPublicKeyPin(certificate *x509.Certificate) error {
+	ski := util.NormalizeSKI(fmt.Sprintf("%0x", certificate.SubjectKeyId))
+
+	h.muxReg.Lock()
+	service, ok := h.remoteServices[ski]
+	h.muxReg.Unlock()
+
+	if !ok || !service.Trusted() {
+		return nil
+	}
+
+	if err := cert.VerifyPublicKeyPin(certificate, service.PublicKeyPin()); err != nil {
+		return fmt.Errorf("%s: %w", ski, err)
+	}
+
 	return nil
 }
 
+// pin the public key of a paired SKI, if no pin is available yet
+//
+// invoked once the TLS handshake completed, so all checks of the certificate succeeded
+func (h *Hub) pinPublicKey(certificate *x509.Certificate) {
+	ski := util.NormalizeSKI(fmt.Sprintf("%0x", certificate.SubjectKeyId))
+
+	h.muxReg.Lock()
+	service, ok := h.remoteServices[ski]
+	h.muxReg.Unlock()
+
+	if !ok || !service.Trusted() || service.PublicKeyPin() != "" {
+		return
+	}
+
+	logging.Log().Debug(ski, "pinning public key")
+	pin := cert.PublicKeyPin(certificate)
+	service.SetPublicKeyPin(pin)
+	if pinReader, ok := h.hubReader.(api.HubPublicKeyPinReaderInterface); ok {
+		pinReader.ServicePublicKeyPinUpdate(ski, pin)
+	}
+}
+
+// check if the certificate chains to one of the installer CAs
+func verifyInstallerTrust(certificate *x509.Certificate, intermediates []*x509.Certificate, roots *x509.CertPool) bool {
+	intermediatePool := x509.NewCertPool()
+	for _, intermediate := range intermediates {
+		intermediatePool.AddCert(intermediate)
+	}
+
+	_, err := certificate.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediatePool,
+		// the certificate is used for both sides of SHIP connections
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		logging.Log().Debug(fmt.Sprintf("%0x", certificate.SubjectKeyId), "certificate is not trusted by the installer CAs:", err)
+		return false
+	}
+
+	return true
+}
+
+// trust the service of the certificate, if it chains to one of the installer CAs
+//
+// invoked once the TLS handshake completed, so all checks of the certificate succeeded.
+// Services which were unregistered are not trusted again until they are registered
+func (h *Hub) trustByInstallerCA(certificate *x509.Certificate, intermediates []*x509.Certificate) {
+	ski := util.NormalizeSKI(fmt.Sprintf("%0x", certificate.SubjectKeyId))
+
+	h.muxReg.Lock()
+	roots := h.installerCAs
+	unregistered := h.unregisteredSKIs[ski]
+	h.muxReg.Unlock()
+
+	if roots == nil || unregistered {
+		return
+	}
+
+	service := h.ServiceForSKI(ski)
+	if service.Trusted() || !verifyInstallerTrust(certificate, intermediates, roots) {
+		return
+	}
+
+	logging.Log().Debug(ski, "trusting service, as its certificate chains to an installer CA")
+	service.SetTrusted(true)
+
+	detail := api.NewConnectionStateDetail(api.ConnectionStateTrustedByInstallerCA, nil)
+	service.SetConnectionStateDetail(detail)
+	h.hubReader.ServicePairingDetailUpdate(ski, detail)
+}
+
+// the TLS configuration of the websocket server
+//
+// the local certificate is requested for every connection, as it may be replaced
+// at runtime. The private key may be any crypto.Signer with a P-256 key
+func (h *Hub) serverTLSConfig() *tls.Config {
+	return &tls.Config{
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return h.currentCertificate(), nil
+		},
+		ClientAuth:            tls.RequireAnyClientCert, // SHIP 9: Client authentication is required
+		CipherSuites:          cert.CipherSuites,        // #nosec G402 // SHIP 9.1: the ciphers are reported insecure but are defined to be used by SHIP
+		VerifyPeerCertificate: h.verifyPeerCertificate,
+		MinVersion:            tls.VersionTLS12, // SHIP 9: Mandatory TLS version
+	}
+}
+
+// the TLS configuration of outgoing websocket connections
+func (h *Hub) clientTLSConfig() *tls.Config {
+	return &tls.Config{
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return h.currentCertificate(), nil
+		},
+		// SHIP 12.1: all certificates are locally signed
+		InsecureSkipVerify:    true, // #nosec G402
+		VerifyPeerCertificate: h.verifyPeerCertificate,
+		// SHIP 9.1: the ciphers are reported insecure but are defined to be used by SHIP
+		CipherSuites: cert.CipherSuites, // #nosec G402
+	}
+}
+
 // start the ship websocket server
 func (h *Hub) startWebsocketServer() error {
 	addr := fmt.Sprintf(":%d", h.port)
@@ -50,13 +229,7 @@ func (h *Hub) startWebsocketServer() error {
 		Addr:              addr,
 		Handler:           h,
 		ReadHeaderTimeout: time.Duration(time.Second * 10),
-		TLSConfig: &tls.Config{
-			Certificates:          []tls.Certificate{h.certifciate},
-			ClientAuth:            tls.RequireAnyClientCert, // SHIP 9: Client authentication is required
-			CipherSuites:          cert.CipherSuites,        // #nosec G402 // SHIP 9.1: the ciphers are reported insecure but are defined to be used by SHIP
-			VerifyPeerCertificate: h.verifyPeerCertificate,
-			MinVersion:            tls.VersionTLS12, // SHIP 9: Mandatory TLS version
-		},
+		TLSConfig:         h.serverTLSConfig(),
 	}
 
 	go func() {
@@ -127,14 +300,78 @@ func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.runShipConnection(conn, ship.ShipRoleServer, remoteService)
+}
+
+// create the websocket and SHIP handlers for a new connection,
+// start the SHIP handshake and register the connection
+func (h *Hub) runShipConnection(conn *websocket.Conn, role ship.ShipRole, remoteService *api.ServiceDetails) {
+	if tlsConn, ok := conn.UnderlyingConn().(*tls.Conn); ok {
+		if peerCertificates := tlsConn.ConnectionState().PeerCertificates; len(peerCertificates) > 0 {
+			h.trustByInstallerCA(peerCertificates[0], peerCertificates[1:])
+			h.pinPublicKey(peerCertificates[0])
+		}
+	}
+
+	h.muxReg.Lock()
+	handshakeTimings := h.handshakeTimings
+	websocketTimings := h.websocketTimings
+	latencyThresholds := h.latencyThresholds
+	quirkRegistry := h.quirkRegistry
+	spineBufferLimits := h.spineBufferLimits
+	payloadInterceptors := h.payloadInterceptors
+	recordingDirectory := h.recordingDirectory
+	h.muxReg.Unlock()
+
+	quirkSet := quirkRegistry.Lookup(h.quirksPeer(conn, remoteService.SKI()))
+	if len(quirkSet) > 0 {
+		logging.Log().Debug(remoteService.SKI(), "applying quirks:", quirkSet)
+	}
+
 	dataHandler := ws.NewWebsocketConnection(conn, remoteService.SKI())
-	shipConnection := ship.NewConnectionHandler(h, dataHandler, ship.ShipRoleServer,
+	// the values are validated when being set
+	_ = dataHandler.SetTimings(websocketTimings)
+	_ = dataHandler.SetLatencyThresholds(latencyThresholds)
+	if recordingDirectory != "" {
+		fileName := fmt.Sprintf("%s-%s.jsonl", remoteService.SKI(), time.Now().Format("20060102T150405.000"))
+		if recorder, err := ws.NewFileFrameRecorder(filepath.Join(recordingDirectory, fileName)); err == nil {
+			dataHandler.SetRecorder(recorder)
+		} else {
+			logging.Log().Debug(remoteService.SKI(), "error creating recording:", err)
+		}
+	}
+
+	shipConnection := ship.NewConnectionHandler(h, dataHandler, role,
 		h.localService.ShipID(), remoteService.SKI(), remoteService.ShipID())
+	_ = shipConnection.SetHandshakeTimings(handshakeTimings)
+	_ = shipConnection.SetSpineBufferLimits(spineBufferLimits)
+	shipConnection.SetQuirks(quirkSet)
+	shipConnection.SetPayloadInterceptors(payloadInterceptors...)
 	shipConnection.Run()
 
 	h.registerConnection(shipConnection)
 }
 
+// collect the properties of a remote device used for finding its quirks
+func (h *Hub) quirksPeer(conn *websocket.Conn, ski string) quirks.Peer {
+	peer := quirks.Peer{
+		SKI: ski,
+	}
+
+	if tlsConn, ok := conn.UnderlyingConn().(*tls.Conn); ok {
+		if remoteCerts := tlsConn.ConnectionState().PeerCertificates; len(remoteCerts) > 0 {
+			peer.Certificate = remoteCerts[0]
+		}
+	}
+
+	if entry := h.knownMdnsEntry(ski); entry != nil {
+		peer.Brand = entry.Brand
+		peer.Model = entry.Model
+	}
+
+	return peer
+}
+
 // return if there is a connection for a SKI
 func (h *Hub) isSkiConnected(ski string) bool {
 	h.muxCon.Lock()
@@ -158,14 +395,8 @@ func (h *Hub) connectFoundService(remoteService *api.ServiceDetails, host, port,
 	dialer := &websocket.Dialer{
 		Proxy:            http.ProxyFromEnvironment,
 		HandshakeTimeout: 5 * time.Second,
-		TLSClientConfig: &tls.Config{
-			Certificates: []tls.Certificate{h.certifciate},
-			// SHIP 12.1: all certificates are locally signed
-			InsecureSkipVerify: true, // #nosec G402
-			// SHIP 9.1: the ciphers are reported insecure but are defined to be used by SHIP
-			CipherSuites: cert.CipherSuites, // #nosec G402
-		},
-		Subprotocols: []string{api.ShipWebsocketSubProtocol},
+		TLSClientConfig:  h.clientTLSConfig(),
+		Subprotocols:     []string{api.ShipWebsocketSubProtocol},
 	}
 
 	address := fmt.Sprintf("wss://%s:%s%s", host, port, path)
@@ -206,17 +437,20 @@ func (h *Hub) connectFoundService(remoteService *api.ServiceDetails, host, port,
 		return errors.New(errorString)
 	}
 
+	// services are connected to without being paired if installer CAs are set,
+	// they have to be trusted by the installer CAs now
+	if !remoteService.Trusted() && remoteService.ConnectionStateDetail().State() != api.ConnectionStateQueued {
+		errorString := fmt.Sprintf("closing connection to %s: service is not trusted", remoteService.SKI())
+		_ = conn.Close()
+		return errors.New(errorString)
+	}
+
 	if !h.keepThisConnection(conn, false, remoteService) {
 		errorString := fmt.Sprintf("closing connection to %s: ignoring this connection", remoteService.SKI())
 		return errors.New(errorString)
 	}
 
-	dataHandler := ws.NewWebsocketConnection(conn, remoteService.SKI())
-	shipConnection := ship.NewConnectionHandler(h, dataHandler, ship.ShipRoleClient,
-		h.localService.ShipID(), remoteService.SKI(), remoteService.ShipID())
-	shipConnection.Run()
-
-	h.registerConnection(shipConnection)
+	h.runShipConnection(conn, ship.ShipRoleClient, remoteService)
 
 	return nil
 }
@@ -275,17 +509,16 @@ func (h *Hub) sendWSCloseMessage(conn *websocket.Conn) {
 
 // coordinate connection initiation attempts to a remove service
 func (h *Hub) coordinateConnectionInitations(ski string, entry *api.MdnsEntry) {
-	if h.isConnectionAttemptRunning(ski) {
+	generation, ok := h.startConnectionAttempt(ski)
+	if !ok {
 		return
 	}
 
-	h.setConnectionAttemptRunning(ski, true)
-
 	counter, duration := h.getConnectionInitiationDelayTime(ski)
 
 	service := h.ServiceForSKI(ski)
 	if service.ConnectionStateDetail().State() == api.ConnectionStateQueued {
-		go h.prepareConnectionInitation(ski, counter, entry)
+		go h.prepareConnectionInitation(ski, generation, counter, entry)
 		return
 	}
 
@@ -297,14 +530,17 @@ func (h *Hub) coordinateConnectionInitations(ski string, entry *api.MdnsEntry) {
 		// wait
 		<-time.After(duration)
 
-		h.prepareConnectionInitation(ski, counter, entry)
+		h.prepareConnectionInitation(ski, generation, counter, entry)
 	}()
 }
 
 // invoked by coordinateConnectionInitations either with a delay or directly
 // when initating a pairing process
-func (h *Hub) prepareConnectionInitation(ski string, counter int, entry *api.MdnsEntry) {
-	h.setConnectionAttemptRunning(ski, false)
+func (h *Hub) prepareConnectionInitation(ski string, generation uint64, counter int, entry *api.MdnsEntry) {
+	// the attempt was invalidated, e.g. by a network change which started a new attempt
+	if !h.finishConnectionAttempt(ski, generation) {
+		return
+	}
 
 	// check if the current counter is still the same, otherwise this counter is irrelevant
 	currentCounter, exists := h.getCurrentConnectionAttemptCounter(ski)
@@ -314,8 +550,7 @@ func (h *Hub) prepareConnectionInitation(ski string, counter int, entry *api.Mdn
 
 	// connection attempt is not relevant if the device is no longer paired
 	// or it is not queued for pairing
-	pairingState := h.ServiceForSKI(ski).ConnectionStateDetail().State()
-	if !h.IsRemoteServiceForSKIPaired(ski) && pairingState != api.ConnectionStateQueued {
+	if !h.isConnectionCandidate(ski) {
 		return
 	}
 
@@ -324,6 +559,11 @@ func (h *Hub) prepareConnectionInitation(ski string, counter int, entry *api.Mdn
 		return
 	}
 
+	// use the latest reported addresses, as addresses may have been removed while waiting
+	if current := h.knownMdnsEntry(ski); current != nil {
+		entry = current
+	}
+
 	// now initiate the connection
 	// check if the remoteService still exists
 	service := h.ServiceForSKI(ski)
@@ -340,8 +580,7 @@ func (h *Hub) initateConnection(remoteService *api.ServiceDetails, entry *api.Md
 
 	// connection attempt is not relevant if the device is no longer paired
 	// or it is not queued for pairing
-	pairingState := h.ServiceForSKI(remoteService.SKI()).ConnectionStateDetail().State()
-	if !h.IsRemoteServiceForSKIPaired(remoteService.SKI()) && pairingState != api.ConnectionStateQueued {
+	if !h.isConnectionCandidate(remoteService.SKI()) {
 		return false
 	}
 
@@ -452,6 +691,48 @@ func (h *Hub) setConnectionAttemptRunning(ski string, active bool) {
 	h.connectionAttemptRunning[ski] = active
 }
 
+// mark a connection attempt as running, if none is running yet
+//
+// returns the generation of the attempt and false if an attempt is already running
+func (h *Hub) startConnectionAttempt(ski string) (uint64, bool) {
+	h.muxConAttempt.Lock()
+	defer h.muxConAttempt.Unlock()
+
+	if h.connectionAttemptRunning[ski] {
+		return 0, false
+	}
+
+	h.connectionAttemptRunning[ski] = true
+
+	return h.connectionAttemptGeneration, true
+}
+
+// mark the connection attempt of the generation as no longer running
+//
+// returns false if the attempt was invalidated by resetConnectionAttempts
+func (h *Hub) finishConnectionAttempt(ski string, generation uint64) bool {
+	h.muxConAttempt.Lock()
+	defer h.muxConAttempt.Unlock()
+
+	if generation != h.connectionAttemptGeneration {
+		return false
+	}
+
+	h.connectionAttemptRunning[ski] = false
+
+	return true
+}
+
+// invalidate all pending connection attempts and reset the attempt counters
+func (h *Hub) resetConnectionAttempts() {
+	h.muxConAttempt.Lock()
+	defer h.muxConAttempt.Unlock()
+
+	h.connectionAttemptGeneration++
+	clear(h.connectionAttemptCounter)
+	clear(h.connectionAttemptRunning)
+}
+
 // return if a connection attempt is runnning/in progress
 func (h *Hub) isConnectionAttemptRunning(ski string) bool {
 	h.muxConAttempt.Lock()
@@ -484,3 +765,33 @@ func (h *Hub) connectionForSKI(ski string) api.ShipConnectionInterface {
 	}
 	return con
 }
+
+// Provide the round trip time statistic of the connection to a SKI
+//
+// returns ErrConnectionNotFound if there is no connection for the SKI,
+// ErrLatencyNotSupported if the connection does not measure round trip times
+func (h *Hub) LatencyForSKI(ski string) (api.WebsocketLatency, error) {
+	conn := h.connectionForSKI(util.NormalizeSKI(ski))
+	if conn == nil {
+		return api.WebsocketLatency{}, api.ErrConnectionNotFound
+	}
+
+	latencyProvider, ok := conn.DataHandler().(api.WebsocketLatencyInterface)
+	if !ok {
+		return api.WebsocketLatency{}, api.ErrLatencyNotSupported
+	}
+
+	return latencyProvider.Latency(), nil
+}
+
+// Provide the statistic of the buffer for SPINE messages received before the handshake completed
+//
+// returns ErrConnectionNotFound if there is no connection for the SKI
+func (h *Hub) SpineBufferStatisticForSKI(ski string) (api.SpineBufferStatistic, error) {
+	conn := h.connectionForSKI(util.NormalizeSKI(ski))
+	if conn == nil {
+		return api.SpineBufferStatistic{}, api.ErrConnectionNotFound
+	}
+
+	return conn.SpineBufferStatistic(), nil
+}
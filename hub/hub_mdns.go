@@ -2,13 +2,17 @@ package hub
 
 import (
 	"net"
+	"slices"
 	"sort"
 	"strings"
 
 	"github.com/enbility/ship-go/api"
+	"github.com/enbility/ship-go/logging"
+	"github.com/enbility/ship-go/util"
 )
 
 var _ api.MdnsReportInterface = (*Hub)(nil)
+var _ api.MdnsNetworkChangeReportInterface = (*Hub)(nil)
 
 // Process reported mDNS services
 func (h *Hub) ReportMdnsEntries(entries map[string]*api.MdnsEntry, newEntries bool) {
@@ -23,12 +27,12 @@ func (h *Hub) ReportMdnsEntries(entries map[string]*api.MdnsEntry, newEntries bo
 		}
 
 		// Check if the remote service is paired or queued for connection
-		service := h.ServiceForSKI(ski)
-		if !h.IsRemoteServiceForSKIPaired(ski) &&
-			service.ConnectionStateDetail().State() != api.ConnectionStateQueued {
+		if !h.isConnectionCandidate(ski) {
 			continue
 		}
 
+		service := h.ServiceForSKI(ski)
+
 		service.SetAutoAccept(entry.Register)
 
 		// patch the addresses list if an IPv4 address was provided
@@ -74,3 +78,52 @@ func (h *Hub) ReportMdnsEntries(entries map[string]*api.MdnsEntry, newEntries bo
 
 	h.hubReader.VisibleRemoteServicesUpdated(remoteServices)
 }
+
+// return if a connection to a discovered service should be initiated
+//
+// this is the case if the service is paired or queued for connection
+func (h *Hub) isConnectionCandidate(ski string) bool {
+	if h.IsRemoteServiceForSKIPaired(ski) {
+		return true
+	}
+
+	return h.ServiceForSKI(ski).ConnectionStateDetail().State() == api.ConnectionStateQueued
+}
+
+// return the latest reported mDNS entry for a SKI, nil if there is none
+func (h *Hub) knownMdnsEntry(ski string) *api.MdnsEntry {
+	h.muxMdns.Lock()
+	defer h.muxMdns.Unlock()
+
+	for _, entry := range h.knownMdnsEntries {
+		if util.NormalizeSKI(entry.Ski) == ski {
+			return entry
+		}
+	}
+
+	return nil
+}
+
+// Retry connections to the known services after network interfaces changed
+//
+// Pending connection attempts are invalidated and the attempt counters are reset,
+// so the services are connected to with the shortest delay instead of the delay of failed attempts
+func (h *Hub) NetworkChanged() {
+	h.resetConnectionAttempts()
+
+	h.muxMdns.Lock()
+	entries := slices.Clone(h.knownMdnsEntries)
+	h.muxMdns.Unlock()
+
+	for _, entry := range entries {
+		ski := util.NormalizeSKI(entry.Ski)
+
+		if h.isSkiConnected(ski) || !h.isConnectionCandidate(ski) {
+			continue
+		}
+
+		logging.Log().Debug("network changed, retrying connection to", ski)
+
+		h.coordinateConnectionInitations(ski, entry)
+	}
+}
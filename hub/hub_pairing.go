@@ -90,9 +90,16 @@ func (h *Hub) checkHasStarted() bool {
 // Sets the SKI as being paired or not
 // Should be used for services which completed the pairing process and
 // which were stored as having the process completed
+//
+// The public key pinned for the SKI (reported via HubPublicKeyPinReaderInterface.ServicePublicKeyPinUpdate)
+// should be stored as well and restored using ServiceDetails.SetPublicKeyPin
 func (h *Hub) RegisterRemoteSKI(ski string) {
 	ski = util.NormalizeSKI(ski)
 
+	h.muxReg.Lock()
+	delete(h.unregisteredSKIs, ski)
+	h.muxReg.Unlock()
+
 	// if the hub has not started, simply add it
 	if !h.checkHasStarted() {
 		service := h.ServiceForSKI(ski)
@@ -124,9 +131,18 @@ func (h *Hub) RegisterRemoteSKI(ski string) {
 }
 
 // Remove pairing for the SKI
+//
+// The service is not trusted by installer CAs again until it is registered
 func (h *Hub) UnregisterRemoteSKI(ski string) {
+	ski = util.NormalizeSKI(ski)
+
+	h.muxReg.Lock()
+	h.unregisteredSKIs[ski] = true
+	h.muxReg.Unlock()
+
 	service := h.ServiceForSKI(ski)
 	service.SetTrusted(false)
+	service.SetPublicKeyPin("")
 
 	h.removeConnectionAttemptCounter(ski)
 
@@ -9,20 +9,27 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/pem"
 	"errors"
+	"fmt"
 	"math/big"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/enbility/ship-go/api"
 	"github.com/enbility/ship-go/cert"
+	"github.com/enbility/ship-go/cert/certtest"
 	"github.com/enbility/ship-go/mocks"
 	"github.com/enbility/ship-go/model"
+	"github.com/enbility/ship-go/quirks"
+	"github.com/enbility/ship-go/ship"
+	"github.com/enbility/ship-go/ws"
 	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -140,6 +147,158 @@ func (s *HubSuite) Test_AutoAccept() {
 	assert.False(s.T(), value)
 }
 
+func (s *HubSuite) Test_SetTimings() {
+	timings := ship.DefaultHandshakeTimings()
+	timings.HelloInit = 120 * time.Second
+	err := s.sut.SetHandshakeTimings(timings)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), timings, s.sut.handshakeTimings)
+
+	timings.HelloInit = time.Second
+	err = s.sut.SetHandshakeTimings(timings)
+	assert.NotNil(s.T(), err)
+	assert.Equal(s.T(), 120*time.Second, s.sut.handshakeTimings.HelloInit)
+
+	wsTimings := ws.DefaultTimings()
+	wsTimings.PingPeriod = 20 * time.Second
+	err = s.sut.SetWebsocketTimings(wsTimings, ws.LatencyThresholds{})
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), wsTimings, s.sut.websocketTimings)
+
+	wsTimings.PongWait = 10 * time.Second
+	err = s.sut.SetWebsocketTimings(wsTimings, ws.LatencyThresholds{})
+	assert.NotNil(s.T(), err)
+	assert.Equal(s.T(), ws.DefaultTimings().PongWait, s.sut.websocketTimings.PongWait)
+
+	// the limit can not be reached with the default timings
+	err = s.sut.SetWebsocketTimings(ws.DefaultTimings(), ws.LatencyThresholds{MaxMissedPongs: 2})
+	assert.ErrorIs(s.T(), err, ws.ErrInvalidLatencyThresholds)
+	assert.Equal(s.T(), wsTimings.PingPeriod, s.sut.websocketTimings.PingPeriod)
+
+	wsTimings = ws.DefaultTimings()
+	wsTimings.PingPeriod = 15 * time.Second
+	err = s.sut.SetWebsocketTimings(wsTimings, ws.LatencyThresholds{MaxMissedPongs: 2})
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), wsTimings, s.sut.websocketTimings)
+	assert.Equal(s.T(), uint(2), s.sut.latencyThresholds.MaxMissedPongs)
+}
+
+func (s *HubSuite) Test_LatencyForSKI() {
+	_, err := s.sut.LatencyForSKI(s.remoteSki)
+	assert.ErrorIs(s.T(), err, api.ErrConnectionNotFound)
+
+	// the data handler does not measure round trip times
+	s.sut.registerConnection(s.shipConnection)
+	_, err = s.sut.LatencyForSKI(s.remoteSki)
+	assert.ErrorIs(s.T(), err, api.ErrLatencyNotSupported)
+
+	latencyProvider := mocks.NewWebsocketLatencyInterface(s.T())
+	latencyProvider.EXPECT().Latency().Return(api.WebsocketLatency{Samples: 1}).Once()
+	dataHandler := struct {
+		*mocks.WebsocketDataWriterInterface
+		*mocks.WebsocketLatencyInterface
+	}{s.wsDataWriter, latencyProvider}
+
+	shipConnection := mocks.NewShipConnectionInterface(s.T())
+	shipConnection.EXPECT().RemoteSKI().Return(s.remoteSki).Maybe()
+	shipConnection.EXPECT().CloseConnection(mock.Anything, mock.Anything, mock.Anything).Return().Maybe()
+	shipConnection.EXPECT().DataHandler().Return(dataHandler).Once()
+	s.sut.registerConnection(shipConnection)
+
+	latency, err := s.sut.LatencyForSKI(s.remoteSki)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 1, latency.Samples)
+}
+
+func (s *HubSuite) Test_SpineBufferStatisticForSKI() {
+	_, err := s.sut.SpineBufferStatisticForSKI(s.remoteSki)
+	assert.ErrorIs(s.T(), err, api.ErrConnectionNotFound)
+
+	s.shipConnection.EXPECT().SpineBufferStatistic().Return(api.SpineBufferStatistic{DroppedMessages: 3}).Once()
+	s.sut.registerConnection(s.shipConnection)
+
+	statistic, err := s.sut.SpineBufferStatisticForSKI(s.remoteSki)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), uint64(3), statistic.DroppedMessages)
+
+	limits := ship.SpineBufferLimits{MaxMessages: 10, OverflowPolicy: ship.SpineBufferClose}
+	err = s.sut.SetSpineBufferLimits(limits)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), limits, s.sut.spineBufferLimits)
+
+	err = s.sut.SetSpineBufferLimits(ship.SpineBufferLimits{MaxBytes: -1})
+	assert.NotNil(s.T(), err)
+	assert.Equal(s.T(), limits, s.sut.spineBufferLimits)
+}
+
+func (s *HubSuite) Test_SetPayloadInterceptors() {
+	assert.Equal(s.T(), 0, len(s.sut.payloadInterceptors))
+
+	interceptor := mocks.NewPayloadInterceptorInterface(s.T())
+	s.sut.SetPayloadInterceptors(interceptor, interceptor)
+	assert.Equal(s.T(), 2, len(s.sut.payloadInterceptors))
+
+	s.sut.SetPayloadInterceptors()
+	assert.Equal(s.T(), 0, len(s.sut.payloadInterceptors))
+}
+
+func (s *HubSuite) Test_SetRecordingDirectory() {
+	assert.Equal(s.T(), "", s.sut.recordingDirectory)
+
+	dir := s.T().TempDir()
+	s.sut.SetRecordingDirectory(dir)
+	assert.Equal(s.T(), dir, s.sut.recordingDirectory)
+
+	s.sut.SetRecordingDirectory("")
+	assert.Equal(s.T(), "", s.sut.recordingDirectory)
+}
+
+func (s *HubSuite) Test_QuirkRegistry() {
+	assert.Equal(s.T(), quirks.Legacy(), s.sut.quirkRegistry.Lookup(quirks.Peer{SKI: s.remoteSki}))
+
+	s.sut.SetQuirkRegistry(quirks.NewRegistry())
+	assert.Equal(s.T(), 0, len(s.sut.quirkRegistry.Lookup(quirks.Peer{SKI: s.remoteSki})))
+
+	s.sut.SetQuirkRegistry(nil)
+	assert.Equal(s.T(), quirks.Legacy(), s.sut.quirkRegistry.Lookup(quirks.Peer{SKI: s.remoteSki}))
+
+	registry := quirks.NewRegistry(quirks.Profile{
+		Name:   "test",
+		Match:  quirks.Match{Brand: "Brand", Model: "Model"},
+		Quirks: quirks.Set{quirks.TrailingZeroBytes},
+	})
+	s.sut.SetQuirkRegistry(registry)
+
+	s.sut.knownMdnsEntries = []*api.MdnsEntry{
+		{
+			Ski:   s.remoteSki,
+			Brand: "Brand",
+			Model: "Model",
+		},
+	}
+
+	server := httptest.NewServer(s.sut)
+	wsURL := strings.Replace(server.URL, "http://", "ws://", -1)
+
+	con, resp, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	assert.Nil(s.T(), err)
+
+	peer := s.sut.quirksPeer(con, s.remoteSki)
+	assert.Equal(s.T(), "Brand", peer.Brand)
+	assert.Equal(s.T(), "Model", peer.Model)
+	assert.Nil(s.T(), peer.Certificate)
+	assert.Equal(s.T(), quirks.Set{quirks.TrailingZeroBytes}, s.sut.quirkRegistry.Lookup(peer))
+
+	peer = s.sut.quirksPeer(con, "unknown")
+	assert.Equal(s.T(), "", peer.Brand)
+	assert.Equal(s.T(), 0, len(s.sut.quirkRegistry.Lookup(peer)))
+
+	resp.Body.Close()
+	_ = con.Close()
+	server.CloseClientConnections()
+	server.Close()
+}
+
 func (s *HubSuite) Test_SetupRemoteDevice() {
 	ski := "12af9e"
 	localService := api.NewServiceDetails(ski)
@@ -349,6 +508,382 @@ func (s *HubSuite) Test_VerifyPeerCertificate() {
 	assert.NotNil(s.T(), err)
 }
 
+func (s *HubSuite) Test_TLSHandshake_NonConformingCertificate() {
+	data, err := os.ReadFile("../cert/testdata/boolean_0x01.pem")
+	assert.Nil(s.T(), err)
+	block, _ := pem.Decode(data)
+	assert.NotNil(s.T(), block)
+
+	if _, err := x509.ParseCertificate(block.Bytes); err == nil {
+		s.T().Skip("the Go installation is patched to accept the certificate")
+	}
+
+	// the key does not belong to the certificate, the handshake fails before it is used
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(s.T(), err)
+
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	server := tls.Server(serverConn, &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{block.Bytes}, PrivateKey: key}},
+		CipherSuites: cert.CipherSuites,
+		MinVersion:   tls.VersionTLS12,
+	})
+	go func() {
+		_ = server.Handshake()
+	}()
+
+	// crypto/tls parses the peer certificate before the hub is able to handle it
+	client := tls.Client(clientConn, s.sut.clientTLSConfig())
+	err = client.Handshake()
+	if assert.NotNil(s.T(), err) {
+		assert.Contains(s.T(), err.Error(), "failed to parse certificate")
+	}
+}
+
+func (s *HubSuite) Test_VerifyPeerCertificate_Validation() {
+	expiredCert := createExpiredCertificate(s)
+	rawCerts := [][]byte{expiredCert}
+
+	// default: findings are only logged
+	err := s.sut.verifyPeerCertificate(rawCerts, nil)
+	assert.Nil(s.T(), err)
+
+	s.sut.SetPeerCertificateValidation(cert.ValidationModeReject)
+	err = s.sut.verifyPeerCertificate(rawCerts, nil)
+	assert.True(s.T(), errors.Is(err, cert.ErrNonConformingCertificate))
+
+	testCert, _ := cert.CreateCertificate("unit", "org", "DE", "CN")
+	err = s.sut.verifyPeerCertificate(testCert.Certificate, nil)
+	assert.Nil(s.T(), err)
+
+	s.sut.SetPeerCertificateValidation(cert.ValidationModeOff)
+	err = s.sut.verifyPeerCertificate(rawCerts, nil)
+	assert.Nil(s.T(), err)
+}
+
+func (s *HubSuite) Test_VerifyPeerCertificate_SkiVerification() {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(s.T(), err)
+
+	// a SKI which is not derived from the public key
+	rawCerts := [][]byte{createPeerCertificate(s, privateKey, func(t *x509.Certificate) {
+		t.SubjectKeyId = make([]byte, 20)
+	})}
+
+	err = s.sut.verifyPeerCertificate(rawCerts, nil)
+	assert.True(s.T(), errors.Is(err, cert.ErrSkiMismatch))
+
+	s.sut.SetSkiVerification(false)
+	err = s.sut.verifyPeerCertificate(rawCerts, nil)
+	assert.Nil(s.T(), err)
+}
+
+func (s *HubSuite) Test_VerifyPeerCertificate_PublicKeyPin() {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(s.T(), err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(s.T(), err)
+
+	// the SKI verification would refuse the other key
+	s.sut.SetSkiVerification(false)
+
+	rawCerts := [][]byte{createPeerCertificate(s, privateKey, nil)}
+	leaf, err := x509.ParseCertificate(rawCerts[0])
+	assert.Nil(s.T(), err)
+	ski := fmt.Sprintf("%0x", leaf.SubjectKeyId)
+	otherCerts := [][]byte{createPeerCertificate(s, otherKey, func(t *x509.Certificate) {
+		t.SubjectKeyId = leaf.SubjectKeyId
+	})}
+
+	// the key is not pinned for services which are not paired
+	err = s.sut.verifyPeerCertificate(rawCerts, nil)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), "", s.sut.ServiceForSKI(ski).PublicKeyPin())
+
+	s.sut.pinPublicKey(leaf)
+	assert.Equal(s.T(), "", s.sut.ServiceForSKI(ski).PublicKeyPin())
+
+	s.sut.RegisterRemoteSKI(ski)
+
+	// the key is only pinned once the TLS handshake completed
+	err = s.sut.verifyPeerCertificate(rawCerts, nil)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), "", s.sut.ServiceForSKI(ski).PublicKeyPin())
+
+	// the first seen key is pinned and reported to be persisted, if the hub reader supports it
+	pinReader := mocks.NewMockHubPublicKeyPinReaderInterface(gomock.NewController(s.T()))
+	pinReader.EXPECT().ServicePublicKeyPinUpdate(ski, cert.PublicKeyPin(leaf)).Times(1)
+	s.sut.hubReader = struct {
+		*mocks.MockHubReaderInterface
+		*mocks.MockHubPublicKeyPinReaderInterface
+	}{s.hubReader, pinReader}
+	s.sut.pinPublicKey(leaf)
+	assert.Equal(s.T(), cert.PublicKeyPin(leaf), s.sut.ServiceForSKI(ski).PublicKeyPin())
+
+	// an existing pin is kept
+	otherLeaf, err := x509.ParseCertificate(otherCerts[0])
+	assert.Nil(s.T(), err)
+	s.sut.pinPublicKey(otherLeaf)
+	assert.Equal(s.T(), cert.PublicKeyPin(leaf), s.sut.ServiceForSKI(ski).PublicKeyPin())
+
+	err = s.sut.verifyPeerCertificate(otherCerts, nil)
+	assert.True(s.T(), errors.Is(err, cert.ErrPublicKeyPinMismatch))
+
+	err = s.sut.verifyPeerCertificate(rawCerts, nil)
+	assert.Nil(s.T(), err)
+
+	// unpairing removes the pin
+	s.sut.UnregisterRemoteSKI(ski)
+	assert.Equal(s.T(), "", s.sut.ServiceForSKI(ski).PublicKeyPin())
+
+	// hub readers without support for persisting pins still get the key pinned
+	s.sut.hubReader = s.hubReader
+	s.sut.RegisterRemoteSKI(ski)
+	s.sut.pinPublicKey(leaf)
+	assert.Equal(s.T(), cert.PublicKeyPin(leaf), s.sut.ServiceForSKI(ski).PublicKeyPin())
+}
+
+func (s *HubSuite) Test_VerifyPeerCertificate_EnforcedChecks() {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(s.T(), err)
+
+	s.sut.SetPeerCertificateValidation(cert.ValidationModeOff)
+
+	// expired within the default clock skew tolerance
+	expired := [][]byte{createPeerCertificate(s, privateKey, func(t *x509.Certificate) {
+		t.NotAfter = time.Now().Add(-time.Minute)
+	})}
+	// the key usage does not allow digital signatures
+	keyAgreement := [][]byte{createPeerCertificate(s, privateKey, func(t *x509.Certificate) {
+		t.KeyUsage = x509.KeyUsageKeyAgreement
+	})}
+	// an invalid self signature
+	invalidSignature := createPeerCertificate(s, privateKey, nil)
+	invalidSignature[len(invalidSignature)-1] ^= 0xff
+
+	for _, rawCerts := range [][][]byte{expired, keyAgreement, {invalidSignature}} {
+		assert.Nil(s.T(), s.sut.verifyPeerCertificate(rawCerts, nil))
+	}
+
+	s.sut.SetEnforcedPeerCertificateChecks(cert.CheckValidity, cert.CheckKeyUsage, cert.CheckSelfSignature)
+
+	err = s.sut.verifyPeerCertificate(expired, nil)
+	assert.Nil(s.T(), err)
+
+	err = s.sut.verifyPeerCertificate(keyAgreement, nil)
+	assert.True(s.T(), errors.Is(err, cert.ErrNonConformingCertificate))
+
+	err = s.sut.verifyPeerCertificate([][]byte{invalidSignature}, nil)
+	assert.True(s.T(), errors.Is(err, cert.ErrNonConformingCertificate))
+
+	assert.NotNil(s.T(), s.sut.SetClockSkewTolerance(-time.Second))
+	assert.Nil(s.T(), s.sut.SetClockSkewTolerance(0))
+	err = s.sut.verifyPeerCertificate(expired, nil)
+	assert.True(s.T(), errors.Is(err, cert.ErrNonConformingCertificate))
+
+	s.sut.SetEnforcedPeerCertificateChecks()
+	err = s.sut.verifyPeerCertificate(expired, nil)
+	assert.Nil(s.T(), err)
+}
+
+func (s *HubSuite) Test_VerifyPeerCertificate_InstallerCA() {
+	ca, err := certtest.NewCA("Installer CA")
+	assert.Nil(s.T(), err)
+	otherCA, err := certtest.NewCA("Other CA")
+	assert.Nil(s.T(), err)
+
+	peerCert, err := cert.CreateCertificate("unit", "org", "DE", "peer")
+	assert.Nil(s.T(), err)
+	csrPEM, err := cert.CreateCertificateRequest(peerCert)
+	assert.Nil(s.T(), err)
+
+	signedPEM, err := ca.SignRequest(csrPEM, nil)
+	assert.Nil(s.T(), err)
+	signedCert, err := cert.ImportSignedCertificate(peerCert, signedPEM)
+	assert.Nil(s.T(), err)
+	ski, err := cert.SkiFromCertificate(signedCert.Leaf)
+	assert.Nil(s.T(), err)
+
+	otherPEM, err := otherCA.SignRequest(csrPEM, nil)
+	assert.Nil(s.T(), err)
+	otherCert, err := cert.ImportSignedCertificate(peerCert, otherPEM)
+	assert.Nil(s.T(), err)
+
+	hubReader := mocks.NewMockHubReaderInterface(gomock.NewController(s.T()))
+	var details []*api.ConnectionStateDetail
+	hubReader.EXPECT().ServicePairingDetailUpdate(ski, gomock.Any()).DoAndReturn(
+		func(_ string, detail *api.ConnectionStateDetail) {
+			details = append(details, detail)
+		}).AnyTimes()
+
+	hub := NewHub(hubReader, s.mdnsService, 4567, *s.sut.currentCertificate(), api.NewServiceDetails("localSKI"))
+	hub.SetEnforcedPeerCertificateChecks(cert.CheckSelfSignature)
+
+	// services are not trusted automatically without installer CAs
+	assert.False(s.T(), hub.isConnectionCandidate(ski))
+	err = hub.verifyPeerCertificate(signedCert.Certificate, nil)
+	assert.True(s.T(), errors.Is(err, cert.ErrNonConformingCertificate))
+	assert.False(s.T(), hub.IsRemoteServiceForSKIPaired(ski))
+
+	hub.SetInstallerCAs(ca.Pool())
+	// discovered services are only connected to if they are registered or queued
+	assert.False(s.T(), hub.isConnectionCandidate(ski))
+
+	parse := func(certificate tls.Certificate) []*x509.Certificate {
+		var certificates []*x509.Certificate
+		for _, v := range certificate.Certificate {
+			parsed, err := x509.ParseCertificate(v)
+			assert.Nil(s.T(), err)
+			certificates = append(certificates, parsed)
+		}
+		return certificates
+	}
+
+	// a certificate of another CA and a self signed certificate are not trusted
+	err = hub.verifyPeerCertificate(otherCert.Certificate, nil)
+	assert.True(s.T(), errors.Is(err, cert.ErrNonConformingCertificate))
+	err = hub.verifyPeerCertificate(peerCert.Certificate, nil)
+	assert.Nil(s.T(), err)
+	other := parse(otherCert)
+	hub.trustByInstallerCA(other[0], other[1:])
+	self := parse(peerCert)
+	hub.trustByInstallerCA(self[0], self[1:])
+	assert.False(s.T(), hub.IsRemoteServiceForSKIPaired(ski))
+	assert.Empty(s.T(), details)
+
+	// the enforced self signature check does not apply to certificates signed by the installer CA,
+	// the service is only trusted once the handshake completed
+	err = hub.verifyPeerCertificate(signedCert.Certificate, nil)
+	assert.Nil(s.T(), err)
+	assert.False(s.T(), hub.IsRemoteServiceForSKIPaired(ski))
+	assert.Empty(s.T(), details)
+
+	signed := parse(signedCert)
+	hub.trustByInstallerCA(signed[0], signed[1:])
+	assert.True(s.T(), hub.IsRemoteServiceForSKIPaired(ski))
+	if assert.Len(s.T(), details, 1) {
+		assert.Equal(s.T(), api.ConnectionStateTrustedByInstallerCA, details[0].State())
+		assert.Nil(s.T(), details[0].Error())
+	}
+
+	// trusted services are only reported once
+	hub.trustByInstallerCA(signed[0], signed[1:])
+	assert.Len(s.T(), details, 1)
+
+	// unregistered services are not trusted again until they are registered
+	hub.UnregisterRemoteSKI(ski)
+	hub.trustByInstallerCA(signed[0], signed[1:])
+	assert.False(s.T(), hub.IsRemoteServiceForSKIPaired(ski))
+
+	hub.RegisterRemoteSKI(ski)
+	assert.True(s.T(), hub.IsRemoteServiceForSKIPaired(ski))
+	hub.muxReg.Lock()
+	assert.Empty(s.T(), hub.unregisteredSKIs)
+	hub.muxReg.Unlock()
+}
+
+func (s *HubSuite) Test_SetCertificate() {
+	current := s.sut.currentCertificate()
+
+	renewed, err := cert.RenewCertificate(*current, cert.RenewOptions{CommonName: "renewed"})
+	assert.Nil(s.T(), err)
+
+	err = s.sut.SetCertificate(renewed)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), renewed.Certificate, s.sut.currentCertificate().Certificate)
+
+	// the SKI has to stay the same
+	other, _ := cert.CreateCertificate("unit", "org", "DE", "CN")
+	err = s.sut.SetCertificate(other)
+	assert.NotNil(s.T(), err)
+	assert.Equal(s.T(), renewed.Certificate, s.sut.currentCertificate().Certificate)
+
+	err = s.sut.SetCertificate(tls.Certificate{})
+	assert.ErrorIs(s.T(), err, cert.ErrNoCertificate)
+
+	// non conforming certificates are refused in reject mode
+	expired, err := cert.RenewCertificate(renewed, cert.RenewOptions{
+		NotBefore: time.Now().Add(-2 * time.Hour),
+		Validity:  time.Hour,
+	})
+	assert.Nil(s.T(), err)
+
+	s.sut.SetLocalCertificateValidation(cert.ValidationModeReject)
+	err = s.sut.SetCertificate(expired)
+	assert.ErrorIs(s.T(), err, cert.ErrNonConformingCertificate)
+	assert.Empty(s.T(), s.sut.CertificateFindings())
+
+	s.sut.SetLocalCertificateValidation(cert.ValidationModeWarn)
+	err = s.sut.SetCertificate(expired)
+	assert.Nil(s.T(), err)
+	assert.True(s.T(), s.sut.CertificateFindings().HasErrors())
+}
+
+func (s *HubSuite) Test_TLSWithSigner() {
+	serverSigner, err := certtest.NewSigner()
+	assert.Nil(s.T(), err)
+	serverCert, err := cert.CreateCertificateWithSigner(serverSigner, "unit", "org", "DE", "server")
+	assert.Nil(s.T(), err)
+
+	clientSigner, err := certtest.NewSigner()
+	assert.Nil(s.T(), err)
+	clientCert, err := cert.CreateCertificateWithSigner(clientSigner, "unit", "org", "DE", "client")
+	assert.Nil(s.T(), err)
+
+	serverHub := NewHub(s.hubReader, s.mdnsService, 4567, serverCert, api.NewServiceDetails("server"))
+	clientHub := NewHub(s.hubReader, s.mdnsService, 4567, clientCert, api.NewServiceDetails("client"))
+	assert.Empty(s.T(), serverHub.CertificateFindings())
+
+	// httptest would add its own certificate to the configuration
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", serverHub.serverTLSConfig())
+	assert.Nil(s.T(), err)
+	defer listener.Close()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			serverErr <- err
+			return
+		}
+		defer conn.Close()
+
+		serverErr <- conn.(*tls.Conn).Handshake()
+	}()
+
+	conn, err := tls.Dial("tcp", listener.Addr().String(), clientHub.clientTLSConfig())
+	assert.Nil(s.T(), err)
+	assert.Nil(s.T(), <-serverErr)
+
+	peerCerts := conn.ConnectionState().PeerCertificates
+	assert.Equal(s.T(), serverCert.Certificate[0], peerCerts[0].Raw)
+	_ = conn.Close()
+
+	// both private keys were only used via their signer
+	assert.Greater(s.T(), serverSigner.Signatures(), 1)
+	assert.Greater(s.T(), clientSigner.Signatures(), 1)
+}
+
+func (s *HubSuite) Test_LocalCertificateValidation() {
+	assert.Empty(s.T(), s.sut.CertificateFindings())
+
+	expiredCert := createExpiredCertificate(s)
+	localService := api.NewServiceDetails("localSKI")
+	hub := NewHub(s.hubReader, s.mdnsService, 4567, tls.Certificate{Certificate: [][]byte{expiredCert}}, localService)
+	assert.True(s.T(), hub.CertificateFindings().HasErrors())
+
+	// the hub is not started with a non conforming certificate
+	hub.SetLocalCertificateValidation(cert.ValidationModeReject)
+	hub.Start()
+	assert.False(s.T(), hub.checkHasStarted())
+
+	hub.SetLocalCertificateValidation(cert.ValidationModeWarn)
+	assert.Nil(s.T(), hub.checkLocalCertificate())
+}
+
 func (s *HubSuite) Test_ServeHTTP_01() {
 	req := httptest.NewRequest("GET", "http://example.com/foo", nil)
 	w := httptest.NewRecorder()
@@ -517,13 +1052,13 @@ func (s *HubSuite) Test_prepareConnectionInitiation() {
 	}
 	service := s.sut.ServiceForSKI(s.remoteSki)
 
-	s.sut.prepareConnectionInitation(s.remoteSki, 0, entry)
+	s.sut.prepareConnectionInitation(s.remoteSki, 0, 0, entry)
 
 	s.sut.setConnectionAttemptRunning(s.remoteSki, true)
 
 	counter := s.sut.increaseConnectionAttemptCounter(s.remoteSki)
 	assert.Equal(s.T(), 0, counter)
-	s.sut.prepareConnectionInitation(s.remoteSki, 0, entry)
+	s.sut.prepareConnectionInitation(s.remoteSki, 0, 0, entry)
 
 	s.sut.UnregisterRemoteSKI(s.remoteSki)
 	service.ConnectionStateDetail().SetState(api.ConnectionStateQueued)
@@ -531,7 +1066,33 @@ func (s *HubSuite) Test_prepareConnectionInitiation() {
 	counter = s.sut.increaseConnectionAttemptCounter(s.remoteSki)
 	assert.Equal(s.T(), 0, counter)
 
-	s.sut.prepareConnectionInitation(s.remoteSki, 0, entry)
+	s.sut.prepareConnectionInitation(s.remoteSki, 0, 0, entry)
+}
+
+func (s *HubSuite) Test_KnownMdnsEntry() {
+	assert.Nil(s.T(), s.sut.knownMdnsEntry(s.remoteSki))
+
+	s.mdnsService.EXPECT().AnnounceMdnsEntry().Return(nil).AnyTimes()
+	s.mdnsService.EXPECT().RequestMdnsEntries().AnyTimes()
+	s.hubReader.EXPECT().VisibleRemoteServicesUpdated(gomock.Any()).AnyTimes()
+
+	entry := &api.MdnsEntry{
+		Ski:       s.remoteSki,
+		Addresses: []net.IP{net.ParseIP("192.168.1.10")},
+	}
+	s.sut.ReportMdnsEntries(map[string]*api.MdnsEntry{s.remoteSki: entry}, true)
+	assert.Equal(s.T(), entry, s.sut.knownMdnsEntry(s.remoteSki))
+
+	// a removed address is reported with the current set of entries
+	updated := &api.MdnsEntry{
+		Ski:       s.remoteSki,
+		Addresses: []net.IP{net.ParseIP("192.168.1.11")},
+	}
+	s.sut.ReportMdnsEntries(map[string]*api.MdnsEntry{s.remoteSki: updated}, true)
+	assert.Equal(s.T(), updated, s.sut.knownMdnsEntry(s.remoteSki))
+
+	s.sut.ReportMdnsEntries(map[string]*api.MdnsEntry{}, true)
+	assert.Nil(s.T(), s.sut.knownMdnsEntry(s.remoteSki))
 }
 
 func (s *HubSuite) Test_InitiateConnection() {
@@ -706,3 +1267,97 @@ func createInvalidCertificate(organizationalUnit, organization, country, commonN
 
 	return tlsCertificate, nil
 }
+
+// create a self signed peer certificate, the template can be changed by the update function
+func createPeerCertificate(s *HubSuite, privateKey *ecdsa.PrivateKey, update func(*x509.Certificate)) []byte {
+	publicKey, err := privateKey.PublicKey.ECDH()
+	assert.Nil(s.T(), err)
+	// #nosec G401
+	ski := sha1.Sum(publicKey.Bytes())
+
+	template := x509.Certificate{
+		SignatureAlgorithm: x509.ECDSAWithSHA256,
+		SerialNumber:       big.NewInt(1),
+		Subject:            pkix.Name{CommonName: "peer"},
+		NotBefore:          time.Now().Add(-time.Hour),
+		NotAfter:           time.Now().Add(time.Hour),
+		SubjectKeyId:       ski[:],
+		KeyUsage:           x509.KeyUsageDigitalSignature,
+	}
+	if update != nil {
+		update(&template)
+	}
+
+	certBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, &privateKey.PublicKey, privateKey)
+	assert.Nil(s.T(), err)
+
+	return certBytes
+}
+
+// create a self signed certificate which expired an hour ago
+func createExpiredCertificate(s *HubSuite) []byte {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(s.T(), err)
+
+	return createPeerCertificate(s, privateKey, func(t *x509.Certificate) {
+		t.NotBefore = time.Now().Add(-2 * time.Hour)
+		t.NotAfter = time.Now().Add(-time.Hour)
+	})
+}
+
+func (s *HubSuite) Test_NetworkChanged() {
+	s.hubReader.EXPECT().VisibleRemoteServicesUpdated(gomock.Any()).AnyTimes()
+
+	// without known entries only the connection attempts are reset
+	s.sut.increaseConnectionAttemptCounter(s.remoteSki)
+	s.sut.increaseConnectionAttemptCounter(s.remoteSki)
+	s.sut.setConnectionAttemptRunning(s.remoteSki, true)
+	s.sut.NetworkChanged()
+
+	_, exists := s.sut.getCurrentConnectionAttemptCounter(s.remoteSki)
+	assert.False(s.T(), exists)
+	assert.False(s.T(), s.sut.isConnectionAttemptRunning(s.remoteSki))
+
+	// a known entry of a service which is not paired is not connected to
+	entry := &api.MdnsEntry{
+		Ski:       s.remoteSki,
+		Addresses: []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	s.sut.muxMdns.Lock()
+	s.sut.knownMdnsEntries = []*api.MdnsEntry{entry}
+	s.sut.muxMdns.Unlock()
+
+	s.sut.NetworkChanged()
+	assert.False(s.T(), s.sut.isConnectionAttemptRunning(s.remoteSki))
+
+	// a paired service is connected to again with the shortest delay
+	s.sut.ServiceForSKI(s.remoteSki).SetTrusted(true)
+	s.sut.increaseConnectionAttemptCounter(s.remoteSki)
+	s.sut.increaseConnectionAttemptCounter(s.remoteSki)
+
+	s.sut.NetworkChanged()
+	assert.True(s.T(), s.sut.isConnectionAttemptRunning(s.remoteSki))
+	counter, exists := s.sut.getCurrentConnectionAttemptCounter(s.remoteSki)
+	assert.True(s.T(), exists)
+	assert.Equal(s.T(), 0, counter)
+}
+
+func (s *HubSuite) Test_ConnectionAttemptGeneration() {
+	generation, ok := s.sut.startConnectionAttempt(s.remoteSki)
+	assert.True(s.T(), ok)
+	_, ok = s.sut.startConnectionAttempt(s.remoteSki)
+	assert.False(s.T(), ok)
+
+	// a network change invalidates the pending attempt and allows a new one
+	s.sut.resetConnectionAttempts()
+	newGeneration, ok := s.sut.startConnectionAttempt(s.remoteSki)
+	assert.True(s.T(), ok)
+	assert.NotEqual(s.T(), generation, newGeneration)
+
+	// the invalidated attempt does not reset the running state of the new one
+	assert.False(s.T(), s.sut.finishConnectionAttempt(s.remoteSki, generation))
+	assert.True(s.T(), s.sut.isConnectionAttemptRunning(s.remoteSki))
+
+	assert.True(s.T(), s.sut.finishConnectionAttempt(s.remoteSki, newGeneration))
+	assert.False(s.T(), s.sut.isConnectionAttemptRunning(s.remoteSki))
+}
@@ -2,7 +2,9 @@ package mdns
 
 import (
 	"fmt"
+	"maps"
 	"net"
+	"slices"
 	"sync"
 	"time"
 
@@ -11,6 +13,9 @@ import (
 	"github.com/enbility/ship-go/logging"
 )
 
+// avahi does not report the TTL of the records, so the TTL required by SHIP 7 is used
+const avahiTTL = 120 * time.Second
+
 type mdnsServiceData struct {
 	// the service name
 	Name string
@@ -34,30 +39,48 @@ type AvahiProvider struct {
 
 	mdnsServiceData *mdnsServiceData
 
-	resolveCB api.MdnsResolveCB
+	resolveCB api.MdnsResolveTTLCB
+
+	nameConflictCB api.MdnsNameConflictCB
+
+	// closed when the entry group is freed, to stop watching its state
+	entryGroupStop chan struct{}
 
 	// Used to store the service elements for each service, so that we can recall them when a service is removed
 	serviceElements map[string]map[string]string
 
+	// Used to store the resolved address of each service, so that only this address is removed
+	serviceAddresses map[string]net.IP
+
+	// Used to store the resolved services, so that they can be reported again before they expire
+	resolvedServices map[string]avahi.Service
+
 	shutdownChan                      chan struct{}
 	addServiceChan, removeServiceChan chan avahi.Service
 
 	mux   sync.Mutex
-	muxEl sync.RWMutex // used for serviceElements
+	muxEl sync.RWMutex // used for serviceElements, serviceAddresses, resolvedServices and changes of ifaceIndexes
 }
 
 func NewAvahiProvider(ifaceIndexes []int32) *AvahiProvider {
 	return &AvahiProvider{
-		avServer:        avahi.ServerNew(),
-		setupSuccessful: false,
-		ifaceIndexes:    ifaceIndexes,
-		serviceElements: make(map[string]map[string]string),
+		avServer:         avahi.ServerNew(),
+		setupSuccessful:  false,
+		ifaceIndexes:     ifaceIndexes,
+		serviceElements:  make(map[string]map[string]string),
+		serviceAddresses: make(map[string]net.IP),
+		resolvedServices: make(map[string]avahi.Service),
 	}
 }
 
 var _ api.MdnsProviderInterface = (*AvahiProvider)(nil)
+var _ api.MdnsProviderTTLInterface = (*AvahiProvider)(nil)
 
 func (a *AvahiProvider) Start(autoReconnect bool, cb api.MdnsResolveCB) bool {
+	return a.StartWithTTL(autoReconnect, withoutTTL(cb))
+}
+
+func (a *AvahiProvider) StartWithTTL(autoReconnect bool, cb api.MdnsResolveTTLCB) bool {
 	a.mux.Lock()
 	defer a.mux.Unlock()
 
@@ -156,10 +179,19 @@ func (a *AvahiProvider) Shutdown() {
 	a.avEntryGroup = nil
 }
 
+func (a *AvahiProvider) SetNameConflictCB(cb api.MdnsNameConflictCB) {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+
+	a.nameConflictCB = cb
+}
+
 func (a *AvahiProvider) Announce(serviceName string, port int, txt []string) error {
 	a.mux.Lock()
 	defer a.mux.Unlock()
 
+	previous := a.mdnsServiceData
+
 	// store the data for reconnection
 	a.mdnsServiceData = &mdnsServiceData{
 		Name: serviceName,
@@ -174,6 +206,22 @@ func (a *AvahiProvider) Announce(serviceName string, port int, txt []string) err
 		btxt = append(btxt, []byte(t))
 	}
 
+	if a.avEntryGroup != nil {
+		// only the TXT records changed, update them in the existing entry group
+		if previous != nil && previous.Name == serviceName && previous.Port == port {
+			for _, iface := range a.ifaceIndexes {
+				err := a.avEntryGroup.UpdateServiceTxt(iface, avahi.ProtoUnspec, 0, serviceName, shipZeroConfServiceType, shipZeroConfDomain, btxt)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}
+
+		a.freeEntryGroup()
+	}
+
 	entryGroup, err := a.avServer.EntryGroupNew()
 	if err != nil {
 		return err
@@ -194,6 +242,12 @@ func (a *AvahiProvider) Announce(serviceName string, port int, txt []string) err
 
 	a.avEntryGroup = entryGroup
 
+	// the avahi daemon probes the service name and reports conflicts via the state of the entry group
+	if group, ok := entryGroup.(*avahi.EntryGroup); ok {
+		a.entryGroupStop = make(chan struct{})
+		go a.entryGroupListener(group.StateChangeChannel, a.entryGroupStop, serviceName)
+	}
+
 	return nil
 }
 
@@ -204,6 +258,16 @@ func (a *AvahiProvider) Unannounce() {
 	// clean up the reconnection data
 	a.mdnsServiceData = nil
 
+	a.freeEntryGroup()
+}
+
+// free the entry group and stop watching its state, a.mux has to be locked
+func (a *AvahiProvider) freeEntryGroup() {
+	if a.entryGroupStop != nil {
+		close(a.entryGroupStop)
+		a.entryGroupStop = nil
+	}
+
 	if a.avEntryGroup == nil {
 		return
 	}
@@ -212,6 +276,73 @@ func (a *AvahiProvider) Unannounce() {
 	a.avEntryGroup = nil
 }
 
+// report a name conflict of the announced service, until stop is closed
+//
+// On a conflict avahi withdraws the service, so it has to be announced with another name
+func (a *AvahiProvider) entryGroupListener(states <-chan avahi.EntryGroupState, stop <-chan struct{}, serviceName string) {
+	for {
+		select {
+		case <-stop:
+			return
+		case state := <-states:
+			switch state.State {
+			case avahi.EntryGroupCollision:
+				logging.Log().Debug("mdns: avahi - name conflict for service:", serviceName)
+
+				a.mux.Lock()
+				cb := a.nameConflictCB
+				a.mux.Unlock()
+
+				if cb != nil {
+					cb(serviceName)
+				}
+
+				return
+			case avahi.EntryGroupFailure:
+				logging.Log().Debug("mdns: avahi - announcing service failed:", state.Error)
+			}
+		}
+	}
+}
+
+// announce the service on the current interfaces
+//
+// The avahi daemon follows the changes of the interfaces itself, only configured
+// interfaces have to be updated as their indexes may have changed
+func (a *AvahiProvider) NetworkChanged(ifaces []net.Interface) {
+	ifaceIndexes := []int32{avahi.InterfaceUnspec}
+	if len(ifaces) > 0 {
+		ifaceIndexes = make([]int32, len(ifaces))
+		for i, iface := range ifaces {
+			// conversion is safe, as the index is always positive and not higher than int32
+			ifaceIndexes[i] = int32(iface.Index) // #nosec G115
+		}
+	}
+
+	a.mux.Lock()
+	if slices.Equal(a.ifaceIndexes, ifaceIndexes) {
+		a.mux.Unlock()
+		return
+	}
+
+	a.muxEl.Lock()
+	a.ifaceIndexes = ifaceIndexes
+	a.muxEl.Unlock()
+
+	serviceData := a.mdnsServiceData
+	a.mux.Unlock()
+
+	if serviceData == nil {
+		return
+	}
+
+	// the service name and port did not change, so the entry group has to be recreated
+	a.Unannounce()
+	if err := a.Announce(serviceData.Name, serviceData.Port, serviceData.Txt); err != nil {
+		logging.Log().Debug("mdns: avahi - error re-announcing service:", err)
+	}
+}
+
 func (a *AvahiProvider) avahiCallback(event avahi.Event) {
 	a.mux.Lock()
 	// if there is a manual shutdown, we do not want to reconnect
@@ -222,6 +353,13 @@ func (a *AvahiProvider) avahiCallback(event avahi.Event) {
 
 	logging.Log().Debug("mdns: avahi - disconnected")
 
+	// the entry group is gone together with the connection
+	a.avEntryGroup = nil
+	if a.entryGroupStop != nil {
+		close(a.entryGroupStop)
+		a.entryGroupStop = nil
+	}
+
 	// the server was shutdown, set it to nil so we don't try to call free functions
 	// on shutting down a currently running resolve
 	cb := a.resolveCB
@@ -231,12 +369,17 @@ func (a *AvahiProvider) avahiCallback(event avahi.Event) {
 	}
 	a.mux.Unlock()
 
+	// the browser is gone as well, so the services expire unless they are found again
+	a.muxEl.Lock()
+	clear(a.resolvedServices)
+	a.muxEl.Unlock()
+
 	// try to reconnect until successull
 	go a.attemptReconnect(cb, serviceData)
 }
 
 // attempt to reconnect to the avahi daemon endlessly
-func (a *AvahiProvider) attemptReconnect(cb api.MdnsResolveCB, serviceData *mdnsServiceData) {
+func (a *AvahiProvider) attemptReconnect(cb api.MdnsResolveTTLCB, serviceData *mdnsServiceData) {
 	for {
 		a.mux.Lock()
 		isManualShutdown := a.manualShutdown
@@ -247,7 +390,7 @@ func (a *AvahiProvider) attemptReconnect(cb api.MdnsResolveCB, serviceData *mdns
 
 		<-time.After(time.Second)
 
-		if !a.Start(true, cb) {
+		if !a.StartWithTTL(true, cb) {
 			continue
 		}
 
@@ -264,11 +407,16 @@ func (a *AvahiProvider) attemptReconnect(cb api.MdnsResolveCB, serviceData *mdns
 }
 
 // listen to service changes and shutdown
-func (a *AvahiProvider) chanListener(cb api.MdnsResolveCB) {
+func (a *AvahiProvider) chanListener(cb api.MdnsResolveTTLCB) {
+	ticker := time.NewTicker(providerRefreshInterval)
+	defer ticker.Stop()
+
 	for {
 		select {
 		case <-a.shutdownChan:
 			return
+		case <-ticker.C:
+			a.refreshServices(cb)
 		case service := <-a.addServiceChan:
 			if err := a.processService(service, false, cb); err != nil {
 				logging.Log().Debug("mdns: avahi -", err)
@@ -283,13 +431,17 @@ func (a *AvahiProvider) chanListener(cb api.MdnsResolveCB) {
 
 // process an avahi mDNS service
 // as avahi returns a service per interface, we need to combine them
-func (a *AvahiProvider) processService(service avahi.Service, remove bool, cb api.MdnsResolveCB) error {
+func (a *AvahiProvider) processService(service avahi.Service, remove bool, cb api.MdnsResolveTTLCB) error {
+	a.muxEl.RLock()
+	ifaceIndexes := a.ifaceIndexes
+	a.muxEl.RUnlock()
+
 	// check if the service is within the allowed list
 	allow := false
-	if len(a.ifaceIndexes) == 1 && a.ifaceIndexes[0] == avahi.InterfaceUnspec {
+	if len(ifaceIndexes) == 1 && ifaceIndexes[0] == avahi.InterfaceUnspec {
 		allow = true
 	} else {
-		for _, iface := range a.ifaceIndexes {
+		for _, iface := range ifaceIndexes {
 			if service.Interface == iface {
 				allow = true
 				break
@@ -314,20 +466,29 @@ func (a *AvahiProvider) processService(service avahi.Service, remove bool, cb ap
 	return a.processAddedService(resolved, cb)
 }
 
-func (a *AvahiProvider) processRemovedService(service avahi.Service, cb api.MdnsResolveCB) error {
+func (a *AvahiProvider) processRemovedService(service avahi.Service, cb api.MdnsResolveTTLCB) error {
 	logging.Log().Tracef("mdns: avahi - process remove service: %v", service)
 
-	// get the elements for the service
-	a.muxEl.RLock()
-	elements := a.serviceElements[getServiceUniqueKey(service)]
-	a.muxEl.RUnlock()
+	// get the elements and the address for the service
+	// avahi reports a service for each interface and protocol, so only its address is removed
+	key := getServiceUniqueKey(service)
+	a.muxEl.Lock()
+	elements := a.serviceElements[key]
+	var addresses []net.IP
+	if address, ok := a.serviceAddresses[key]; ok {
+		addresses = []net.IP{address}
+	}
+	delete(a.serviceAddresses, key)
+	delete(a.resolvedServices, key)
+	a.muxEl.Unlock()
 
-	cb(elements, service.Name, service.Host, nil, -1, true)
+	// avahi expires the records itself and reports the removal
+	cb(elements, service.Name, service.Host, addresses, -1, 0, true)
 
 	return nil
 }
 
-func (a *AvahiProvider) processAddedService(service avahi.Service, cb api.MdnsResolveCB) error {
+func (a *AvahiProvider) processAddedService(service avahi.Service, cb api.MdnsResolveTTLCB) error {
 	// convert [][]byte to []string manually
 	var txt []string
 	for _, element := range service.Txt {
@@ -343,16 +504,32 @@ func (a *AvahiProvider) processAddedService(service avahi.Service, cb api.MdnsRe
 		return fmt.Errorf("service provides unusable address: %s", service.Name)
 	}
 
-	// add the elements to the map
+	// add the elements and the address to the maps
 	a.muxEl.Lock()
 	a.serviceElements[getServiceUniqueKey(service)] = elements
+	a.serviceAddresses[getServiceUniqueKey(service)] = address
+	a.resolvedServices[getServiceUniqueKey(service)] = service
 	a.muxEl.Unlock()
 
-	cb(elements, service.Name, service.Host, []net.IP{address}, int(service.Port), false)
+	// avahi refreshes known services without reporting them, they are reported again by refreshServices
+	cb(elements, service.Name, service.Host, []net.IP{address}, int(service.Port), avahiTTL, false)
 
 	return nil
 }
 
+// report the resolved services again, as avahi only reports changes
+func (a *AvahiProvider) refreshServices(cb api.MdnsResolveTTLCB) {
+	a.muxEl.RLock()
+	services := maps.Clone(a.resolvedServices)
+	a.muxEl.RUnlock()
+
+	for _, service := range services {
+		if err := a.processAddedService(service, cb); err != nil {
+			logging.Log().Debug("mdns: avahi -", err)
+		}
+	}
+}
+
 // Create a unique key for a ship service
 func getServiceUniqueKey(service avahi.Service) string {
 	return fmt.Sprintf("%s-%s-%s-%d-%d", service.Name, service.Type, service.Domain, service.Protocol, service.Interface)
@@ -42,7 +42,7 @@ func (a *AvahiSuite) BeforeTest(suiteName, testName string) {
 func (a *AvahiSuite) AfterTest(suiteName, testName string) {
 }
 
-func processMdnsEntry(elements map[string]string, name, host string, addresses []net.IP, port int, remove bool) {
+func processMdnsEntry(elements map[string]string, name, host string, addresses []net.IP, port int, ttl time.Duration, remove bool) {
 }
 
 func (a *AvahiSuite) Test_Avahi() {
@@ -212,7 +212,7 @@ func (a *AvahiSuite) Test_Announce() {
 func (a *AvahiSuite) Test_Avahi_Reconnect() {
 	// As we do not have an Avahi server running for automated testing
 	// these tests are very limited
-	cb := func(elements map[string]string, name, host string, addresses []net.IP, port int, remove bool) {
+	cb := func(elements map[string]string, name, host string, addresses []net.IP, port int, ttl time.Duration, remove bool) {
 		assert.NotEqual(a.T(), "", name)
 	}
 
@@ -225,7 +225,7 @@ func (a *AvahiSuite) Test_Avahi_Reconnect() {
 		int32(-1), int32(-1),
 		shipZeroConfServiceType, shipZeroConfDomain,
 		uint32(0)).Return(a.serviceBrowserMock, nil).Once()
-	available := a.sut.Start(true, cb)
+	available := a.sut.StartWithTTL(true, cb)
 	assert.True(a.T(), available)
 
 	a.avahiMock.EXPECT().EntryGroupNew().Return(a.entryGroupMock, nil).Once()
@@ -271,7 +271,7 @@ func (a *AvahiSuite) Test_Avahi_Reconnect() {
 		int32(-1), int32(-1),
 		shipZeroConfServiceType, shipZeroConfDomain,
 		uint32(0)).Return(a.serviceBrowserMock, nil).Once()
-	available = a.sut.Start(true, cb)
+	available = a.sut.StartWithTTL(true, cb)
 	assert.True(a.T(), available)
 	a.sut.mux.Lock()
 	assert.NotNil(a.T(), a.sut.avServer)
@@ -288,7 +288,7 @@ func (a *AvahiSuite) Test_Avahi_Reconnect() {
 func (a *AvahiSuite) Test_chanListener() {
 	// As we do not have an Avahi server running for automated testing
 	// these tests are very limited
-	cb := func(elements map[string]string, name, host string, addresses []net.IP, port int, remove bool) {
+	cb := func(elements map[string]string, name, host string, addresses []net.IP, port int, ttl time.Duration, remove bool) {
 		assert.NotEqual(a.T(), "", name)
 	}
 
@@ -301,7 +301,7 @@ func (a *AvahiSuite) Test_chanListener() {
 		int32(-1), int32(-1),
 		shipZeroConfServiceType, shipZeroConfDomain,
 		uint32(0)).Return(a.serviceBrowserMock, nil).Once()
-	available := a.sut.Start(true, cb)
+	available := a.sut.StartWithTTL(true, cb)
 	assert.True(a.T(), available)
 
 	time.Sleep(time.Second * 1)
@@ -334,3 +334,149 @@ func (a *AvahiSuite) Test_chanListener() {
 	a.avahiMock.EXPECT().ServiceBrowserFree(a.serviceBrowserMock).Return().Once()
 	a.sut.Shutdown()
 }
+
+func (a *AvahiSuite) Test_ProcessRemovedService_Address() {
+	var reported []net.IP
+	var removed bool
+	cb := func(elements map[string]string, name, host string, addresses []net.IP, port int, ttl time.Duration, remove bool) {
+		reported = addresses
+		removed = remove
+	}
+
+	testService := avahi.Service{
+		Interface: 1,
+		Protocol:  0,
+		Name:      "TestService",
+		Type:      "_ship._tcp",
+		Domain:    "local",
+		Address:   "192.168.1.10",
+		Txt:       [][]byte{[]byte("ski=133742247331")},
+	}
+
+	err := a.sut.processAddedService(testService, cb)
+	assert.Nil(a.T(), err)
+	assert.False(a.T(), removed)
+
+	// only the address resolved for the interface and protocol is removed
+	err = a.sut.processRemovedService(testService, cb)
+	assert.Nil(a.T(), err)
+	assert.True(a.T(), removed)
+	assert.Equal(a.T(), []net.IP{net.ParseIP("192.168.1.10")}, reported)
+
+	// the whole service is removed if the address is unknown
+	err = a.sut.processRemovedService(testService, cb)
+	assert.Nil(a.T(), err)
+	assert.Nil(a.T(), reported)
+}
+
+func (a *AvahiSuite) Test_RefreshServices() {
+	var reports int
+	var ttl time.Duration
+	cb := func(elements map[string]string, name, host string, addresses []net.IP, port int, reportedTTL time.Duration, remove bool) {
+		if !remove {
+			reports++
+			ttl = reportedTTL
+		}
+	}
+
+	testService := avahi.Service{
+		Interface: 1,
+		Protocol:  0,
+		Name:      "TestService",
+		Type:      "_ship._tcp",
+		Domain:    "local",
+		Address:   "192.168.1.10",
+		Txt:       [][]byte{[]byte("ski=133742247331")},
+	}
+
+	err := a.sut.processAddedService(testService, cb)
+	assert.Nil(a.T(), err)
+	assert.Equal(a.T(), 1, reports)
+	assert.Equal(a.T(), avahiTTL, ttl)
+
+	// avahi does not report known services again
+	a.sut.refreshServices(cb)
+	assert.Equal(a.T(), 2, reports)
+
+	err = a.sut.processRemovedService(testService, cb)
+	assert.Nil(a.T(), err)
+	a.sut.refreshServices(cb)
+	assert.Equal(a.T(), 2, reports)
+}
+
+func (a *AvahiSuite) Test_Announce_Update() {
+	a.avahiMock.EXPECT().EntryGroupNew().Return(a.entryGroupMock, nil).Once()
+	a.entryGroupMock.EXPECT().AddService(mock.Anything, mock.Anything, mock.Anything, "dummytest", shipZeroConfServiceType, shipZeroConfDomain, "", uint16(4289), [][]byte{[]byte("more=more")}).Return(nil).Once()
+	a.entryGroupMock.EXPECT().Commit().Return(nil).Once()
+	err := a.sut.Announce("dummytest", 4289, []string{"more=more"})
+	assert.Nil(a.T(), err)
+
+	// only the TXT records changed
+	a.entryGroupMock.EXPECT().UpdateServiceTxt(int32(1), int32(avahi.ProtoUnspec), uint32(0), "dummytest", shipZeroConfServiceType, shipZeroConfDomain, [][]byte{[]byte("more=less")}).Return(nil).Once()
+	err = a.sut.Announce("dummytest", 4289, []string{"more=less"})
+	assert.Nil(a.T(), err)
+
+	someError := errors.New("some error")
+	a.entryGroupMock.EXPECT().UpdateServiceTxt(int32(1), int32(avahi.ProtoUnspec), uint32(0), "dummytest", shipZeroConfServiceType, shipZeroConfDomain, mock.Anything).Return(someError).Once()
+	err = a.sut.Announce("dummytest", 4289, []string{"more=more"})
+	assert.NotNil(a.T(), err)
+
+	// the name changed, the service is announced with a new entry group
+	a.avahiMock.EXPECT().EntryGroupFree(a.entryGroupMock).Return().Once()
+	a.avahiMock.EXPECT().EntryGroupNew().Return(a.entryGroupMock, nil).Once()
+	a.entryGroupMock.EXPECT().AddService(mock.Anything, mock.Anything, mock.Anything, "othertest", shipZeroConfServiceType, shipZeroConfDomain, "", uint16(4289), mock.Anything).Return(nil).Once()
+	a.entryGroupMock.EXPECT().Commit().Return(nil).Once()
+	err = a.sut.Announce("othertest", 4289, []string{"more=more"})
+	assert.Nil(a.T(), err)
+
+	a.avahiMock.EXPECT().EntryGroupFree(a.entryGroupMock).Return().Once()
+	a.sut.Unannounce()
+}
+
+func (a *AvahiSuite) Test_NetworkChanged() {
+	// the same interfaces
+	a.sut.NetworkChanged([]net.Interface{{Index: 1}})
+
+	// not announced
+	a.sut.NetworkChanged([]net.Interface{{Index: 2}})
+	assert.Equal(a.T(), []int32{2}, a.sut.ifaceIndexes)
+
+	a.avahiMock.EXPECT().EntryGroupNew().Return(a.entryGroupMock, nil).Once()
+	a.entryGroupMock.EXPECT().AddService(int32(2), mock.Anything, mock.Anything, "dummytest", shipZeroConfServiceType, shipZeroConfDomain, "", uint16(4289), mock.Anything).Return(nil).Once()
+	a.entryGroupMock.EXPECT().Commit().Return(nil).Once()
+	err := a.sut.Announce("dummytest", 4289, []string{"more=more"})
+	assert.Nil(a.T(), err)
+
+	// the service is announced on the changed interfaces
+	a.avahiMock.EXPECT().EntryGroupFree(a.entryGroupMock).Return().Once()
+	a.avahiMock.EXPECT().EntryGroupNew().Return(a.entryGroupMock, nil).Once()
+	a.entryGroupMock.EXPECT().AddService(int32(avahi.InterfaceUnspec), mock.Anything, mock.Anything, "dummytest", shipZeroConfServiceType, shipZeroConfDomain, "", uint16(4289), mock.Anything).Return(nil).Once()
+	a.entryGroupMock.EXPECT().Commit().Return(nil).Once()
+	a.sut.NetworkChanged(nil)
+	assert.Equal(a.T(), []int32{avahi.InterfaceUnspec}, a.sut.ifaceIndexes)
+	assert.NotNil(a.T(), a.sut.mdnsServiceData)
+
+	a.avahiMock.EXPECT().EntryGroupFree(a.entryGroupMock).Return().Once()
+	a.sut.Unannounce()
+}
+
+func (a *AvahiSuite) Test_EntryGroupListener() {
+	var conflicts []string
+	a.sut.SetNameConflictCB(func(serviceName string) {
+		conflicts = append(conflicts, serviceName)
+	})
+
+	// the listener stops with a conflict
+	states := make(chan avahi.EntryGroupState, 3)
+	states <- avahi.EntryGroupState{State: avahi.EntryGroupRegistering}
+	states <- avahi.EntryGroupState{State: avahi.EntryGroupFailure, Error: "failure"}
+	states <- avahi.EntryGroupState{State: avahi.EntryGroupCollision}
+	a.sut.entryGroupListener(states, make(chan struct{}), "dummytest")
+	assert.Equal(a.T(), []string{"dummytest"}, conflicts)
+
+	// the listener stops when the entry group is freed
+	stop := make(chan struct{})
+	close(stop)
+	a.sut.entryGroupListener(states, stop, "dummytest")
+	assert.Equal(a.T(), 1, len(conflicts))
+}
@@ -0,0 +1,208 @@
+package mdns
+
+import (
+	"net"
+	"time"
+
+	"github.com/enbility/ship-go/logging"
+)
+
+// the interval for checking if mDNS entries or their addresses expired
+var expiryCheckInterval = 5 * time.Second
+
+// the interval in which providers report their known services again
+//
+// zeroconf and avahi only report changes of services, so they have to report them
+// again before the reported TTL ends to keep them from expiring
+var providerRefreshInterval = time.Minute
+
+// the last seen time and the expiry of an mDNS entry or an address
+type lifetime struct {
+	lastSeen time.Time
+
+	// zero if the provider reports the removal itself
+	expiry time.Time
+}
+
+func newLifetime(now time.Time, ttl time.Duration) lifetime {
+	l := lifetime{lastSeen: now}
+	if ttl > 0 {
+		l.expiry = now.Add(ttl)
+	}
+
+	return l
+}
+
+func (l lifetime) expired(now time.Time) bool {
+	return !l.expiry.IsZero() && now.After(l.expiry)
+}
+
+// the lifetime of an mDNS entry and of each of its addresses
+type mdnsEntryLifetime struct {
+	lifetime
+
+	// the lifetimes of the addresses with the string representation of the address as the key
+	addresses map[string]lifetime
+}
+
+// add new addresses to an mDNS entry and refresh the lifetime of the entry and the reported addresses
+//
+// returns true if addresses were added
+func (m *MdnsManager) refreshMdnsEntry(ski string, addresses []net.IP, ttl time.Duration, now time.Time) bool {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	entry, ok := m.entries[ski]
+	if !ok {
+		return false
+	}
+
+	entryLifetime, ok := m.lifetimes[ski]
+	if !ok {
+		entryLifetime = &mdnsEntryLifetime{addresses: make(map[string]lifetime)}
+		m.lifetimes[ski] = entryLifetime
+	}
+	entryLifetime.lifetime = newLifetime(now, ttl)
+
+	updated := false
+	for _, address := range addresses {
+		// only add if it is not added yet
+		isNewElement := true
+
+		for _, item := range entry.Addresses {
+			if item.Equal(address) {
+				isNewElement = false
+				break
+			}
+		}
+
+		if isNewElement {
+			entry.Addresses = append(entry.Addresses, address)
+			updated = true
+		}
+
+		entryLifetime.addresses[address.String()] = newLifetime(now, ttl)
+	}
+
+	return updated
+}
+
+// remove addresses from an mDNS entry, the entry is removed together with its last address
+//
+// returns true if addresses were removed
+func (m *MdnsManager) removeMdnsEntryAddresses(ski string, addresses []net.IP) bool {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	entry, ok := m.entries[ski]
+	if !ok {
+		return false
+	}
+
+	var remaining []net.IP
+	for _, item := range entry.Addresses {
+		removed := false
+		for _, address := range addresses {
+			if item.Equal(address) {
+				removed = true
+				break
+			}
+		}
+
+		if removed {
+			if entryLifetime, ok := m.lifetimes[ski]; ok {
+				delete(entryLifetime.addresses, item.String())
+			}
+			continue
+		}
+
+		remaining = append(remaining, item)
+	}
+
+	if len(remaining) == len(entry.Addresses) {
+		return false
+	}
+
+	if len(remaining) == 0 {
+		delete(m.entries, ski)
+		delete(m.lifetimes, ski)
+		return true
+	}
+
+	entry.Addresses = remaining
+
+	return true
+}
+
+// remove the expired mDNS entries and addresses
+//
+// returns true if entries or addresses were removed
+func (m *MdnsManager) expireMdnsEntries(now time.Time) bool {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	updated := false
+
+	for ski, entryLifetime := range m.lifetimes {
+		entry, ok := m.entries[ski]
+		if !ok {
+			delete(m.lifetimes, ski)
+			continue
+		}
+
+		if entryLifetime.expired(now) {
+			logging.Log().Debug("mdns: expired - ski:", ski, "name:", entry.Name, "last seen:", entryLifetime.lastSeen)
+
+			delete(m.entries, ski)
+			delete(m.lifetimes, ski)
+			updated = true
+			continue
+		}
+
+		var remaining []net.IP
+		for _, address := range entry.Addresses {
+			if addressLifetime, ok := entryLifetime.addresses[address.String()]; ok && addressLifetime.expired(now) {
+				logging.Log().Debug("mdns: expired address - ski:", ski, "address:", address, "last seen:", addressLifetime.lastSeen)
+
+				delete(entryLifetime.addresses, address.String())
+				continue
+			}
+
+			remaining = append(remaining, address)
+		}
+
+		if len(remaining) == len(entry.Addresses) {
+			continue
+		}
+
+		updated = true
+
+		// an entry without any address can not be connected to
+		if len(remaining) == 0 {
+			delete(m.entries, ski)
+			delete(m.lifetimes, ski)
+			continue
+		}
+
+		entry.Addresses = remaining
+	}
+
+	return updated
+}
+
+// periodically remove the expired mDNS entries and addresses until stop is closed
+func (m *MdnsManager) runExpiry(stop <-chan struct{}) {
+	ticker := time.NewTicker(expiryCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			if m.expireMdnsEntries(now) {
+				m.reportMdnsEntries(true)
+			}
+		}
+	}
+}
@@ -1,15 +1,29 @@
 package mdns
 
 import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/enbility/ship-go/api"
 )
 
+// the maximum length of a service instance name in bytes: RFC 6763 4.1.1
+const maxServiceNameLength = 63
+
+// the number appended to a service name after a name conflict, e.g. "Name (2)"
+var serviceNameNumber = regexp.MustCompile(` \(([0-9]+)\)$`)
+
 // parse mDNS text fields
 func parseTxt(txt []string) map[string]string {
 	result := make(map[string]string)
 
 	for _, item := range txt {
-		s := strings.Split(item, "=")
+		// the value may contain "=": RFC 6763 6.4
+		s := strings.SplitN(item, "=", 2)
 		if len(s) != 2 {
 			continue
 		}
@@ -18,3 +32,32 @@ func parseTxt(txt []string) map[string]string {
 
 	return result
 }
+
+// returns the next service name to use after a name conflict: RFC 6763 Appendix D
+//
+// "Name" becomes "Name (2)", "Name (2)" becomes "Name (3)" and so on.
+// The name is shortened to keep the maximum length of a service instance name
+func alternativeServiceName(name string) string {
+	number := 2
+	if match := serviceNameNumber.FindStringSubmatchIndex(name); match != nil {
+		if value, err := strconv.Atoi(name[match[2]:match[3]]); err == nil && value >= 2 {
+			number = value + 1
+			name = name[:match[0]]
+		}
+	}
+
+	suffix := fmt.Sprintf(" (%d)", number)
+
+	return shortenString(name, maxServiceNameLength-len(suffix)) + suffix
+}
+
+// adapt a callback without ttl to the callback of providers reporting a ttl
+func withoutTTL(cb api.MdnsResolveCB) api.MdnsResolveTTLCB {
+	if cb == nil {
+		return nil
+	}
+
+	return func(elements map[string]string, name, host string, addresses []net.IP, port int, ttl time.Duration, remove bool) {
+		cb(elements, name, host, addresses, port, remove)
+	}
+}
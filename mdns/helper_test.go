@@ -1,6 +1,7 @@
 package mdns
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -20,3 +21,19 @@ func TestParseTXT(t *testing.T) {
 	result = parseTxt(txt)
 	assert.Equal(t, 1, len(result))
 }
+
+func TestAlternativeServiceName(t *testing.T) {
+	assert.Equal(t, "Name (2)", alternativeServiceName("Name"))
+	assert.Equal(t, "Name (3)", alternativeServiceName("Name (2)"))
+	assert.Equal(t, "Name (10)", alternativeServiceName("Name (9)"))
+	assert.Equal(t, "Name (1) (2)", alternativeServiceName("Name (1)"))
+	assert.Equal(t, "Name(2) (2)", alternativeServiceName("Name(2)"))
+
+	// the name is shortened to the maximum length
+	result := alternativeServiceName(strings.Repeat("a", 62) + "ä")
+	assert.Equal(t, strings.Repeat("a", 59)+" (2)", result)
+	assert.Equal(t, maxServiceNameLength, len(result))
+
+	result = alternativeServiceName(strings.Repeat("ä", 40))
+	assert.Equal(t, strings.Repeat("ä", 29)+" (2)", result)
+}
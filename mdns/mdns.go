@@ -3,13 +3,16 @@ package mdns
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"net"
 	"os"
 	"os/signal"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/enbility/go-avahi"
 	"github.com/enbility/ship-go/api"
@@ -19,6 +22,10 @@ import (
 
 const shipWebsocketPath = "/ship/"
 
+// ErrClonedIdentity reports that another service on the network announces the SKI
+// of the local certificate, e.g. a device using a copy of the certificate
+var ErrClonedIdentity = errors.New("another service announces the local SKI")
+
 type MdnsProviderSelection uint
 
 const (
@@ -49,9 +56,15 @@ type MdnsManager struct {
 	// the identifier to be used for mDNS and SHIP ID
 	identifier string
 
+	// additional vendor specific TXT records
+	vendorRecords map[string]string
+
 	// the name to be used as the mDNS service name
 	serviceName string
 
+	// the service name announced on the network, differs from serviceName after a name conflict
+	announcedName string
+
 	// Network interface to use for the service
 	// Optional, if not set all detected interfaces will be used
 	ifaces []string
@@ -67,34 +80,45 @@ type MdnsManager struct {
 	// the currently available mDNS entries with the SKI as the key in the map
 	entries map[string]*api.MdnsEntry
 
+	// the last seen time and the expiry of the mDNS entries and their addresses with the SKI as the key in the map
+	lifetimes map[string]*mdnsEntryLifetime
+
+	// the services announcing the local SKI which were reported, with the name and host as the key in the map
+	clones map[string]struct{}
+
+	// closed on shutdown to stop expiring mDNS entries and watching the network
+	stopChan chan struct{}
+
 	// the registered callback, only connectionsHub is using this
 	report api.MdnsReportInterface
 
+	// the callback of the application for changes of the announcement
+	announcementReport api.MdnsAnnouncementReportInterface
+
 	mdnsProvider api.MdnsProviderInterface
 
+	// the provider used instead of the provider selection, if set
+	customProvider api.MdnsProviderInterface
+
 	shutdownOnce sync.Once
 
 	providerSelection MdnsProviderSelection
 
 	mux,
 	muxAnnounced sync.Mutex
-}
 
-func shortenString(s string, maxLen int) string {
-	if len(s) <= maxLen {
-		return s
-	}
-	return s[:maxLen]
+	// used for the announced details and to serialize announcements
+	muxTxt sync.Mutex
 }
 
 // Create a new mDNS manager
 //
 // Parameters:
 //   - ski: the SKI of certificate
-//   - deviceBrand: the brand of the device (max 32 byte of UTF8)
-//   - deviceModel: the model of the device (max 32 byte of UTF8)
-//   - deviceType: the type of the device (max 32 byte of UTF8)
-//   - deviceSerial: the serial number of the device (max 32 byte of UTF8)
+//   - deviceBrand: the brand of the device (max 32 byte of UTF8, longer values are shortened)
+//   - deviceModel: the model of the device (max 32 byte of UTF8, longer values are shortened)
+//   - deviceType: the type of the device (max 32 byte of UTF8, longer values are shortened)
+//   - deviceSerial: the serial number of the device (max 32 byte of UTF8, longer values are shortened)
 //   - deviceCategories: the categories of the device
 //   - shipIdentifier: the identifier to be used for SHIP ID
 //   - serviceName: the name to be used as the mDNS service name
@@ -108,12 +132,25 @@ func NewMDNS(
 	port int,
 	ifaces []string,
 	providerSelection MdnsProviderSelection) *MdnsManager {
+	values := map[string]*string{
+		"brand":  &deviceBrand,
+		"model":  &deviceModel,
+		"type":   &deviceType,
+		"serial": &deviceSerial,
+	}
+	for key, value := range values {
+		if len(*value) > maxTxtValueLength {
+			*value = shortenString(*value, maxTxtValueLength)
+			logging.Log().Debug("mdns: shortened", key, "to", maxTxtValueLength, "bytes:", *value)
+		}
+	}
+
 	m := &MdnsManager{
 		ski:               ski,
-		deviceBrand:       shortenString(deviceBrand, 32),
-		deviceModel:       shortenString(deviceModel, 32),
-		deviceType:        shortenString(deviceType, 32),
-		deviceSerial:      shortenString(deviceSerial, 32),
+		deviceBrand:       deviceBrand,
+		deviceModel:       deviceModel,
+		deviceType:        deviceType,
+		deviceSerial:      deviceSerial,
 		deviceCategories:  deviceCategories,
 		identifier:        shipIdentifier,
 		serviceName:       serviceName,
@@ -121,6 +158,8 @@ func NewMDNS(
 		ifaces:            ifaces,
 		providerSelection: providerSelection,
 		entries:           make(map[string]*api.MdnsEntry),
+		lifetimes:         make(map[string]*mdnsEntryLifetime),
+		clones:            make(map[string]struct{}),
 	}
 
 	return m
@@ -156,44 +195,113 @@ func (m *MdnsManager) interfaces() ([]net.Interface, []int32, error) {
 var _ api.MdnsInterface = (*MdnsManager)(nil)
 
 func (m *MdnsManager) Start(cb api.MdnsReportInterface) error {
+	if err := m.startProvider(); err != nil {
+		return err
+	}
+
+	// on startup always start mDNS announcement
+	if err := m.AnnounceMdnsEntry(); err != nil {
+		return err
+	}
+
+	m.report = cb
+
+	m.shutdownOnSignal()
+
+	return nil
+}
+
+// Start browsing for SHIP services without announcing the local service
+//
+// Used by tools which only discover services, e.g. cmd/ship-browse.
+// The ski and identifier passed to NewMDNS may be empty
+func (m *MdnsManager) StartBrowsing(cb api.MdnsReportInterface) error {
+	// set before starting the provider, so no entry is missed
+	m.report = cb
+
+	if err := m.startProvider(); err != nil {
+		return err
+	}
+
+	m.shutdownOnSignal()
+
+	return nil
+}
+
+// start the selected mDNS provider
+func (m *MdnsManager) startProvider() error {
 	ifaces, ifaceIndexes, err := m.interfaces()
 	if err != nil {
 		return err
 	}
 
+	m.mux.Lock()
+	if m.stopChan == nil {
+		m.stopChan = make(chan struct{})
+		go m.runExpiry(m.stopChan)
+		go watchNetwork(m.stopChan, m.processNetworkChange)
+	}
+	m.mux.Unlock()
+
+	if m.customProvider != nil {
+		m.mdnsProvider = m.customProvider
+		m.mdnsProvider.SetNameConflictCB(m.processNameConflict)
+		if !m.runProvider(m.mdnsProvider, true) {
+			return errors.New("mDNS provider not available")
+		}
+
+		return nil
+	}
+
 	switch m.providerSelection {
 	case MdnsProviderSelectionAll:
 		// First try avahi, if not available use zerconf
 		provider := NewAvahiProvider(ifaceIndexes)
-		if provider.Start(false, m.processMdnsEntry) {
+		provider.SetNameConflictCB(m.processNameConflict)
+		if m.runProvider(provider, false) {
 			m.mdnsProvider = provider
 		} else {
 			provider.Shutdown()
 
 			// Avahi is not availble, use Zeroconf
 			m.mdnsProvider = NewZeroconfProvider(ifaces)
-			if !m.mdnsProvider.Start(false, m.processMdnsEntry) {
+			m.mdnsProvider.SetNameConflictCB(m.processNameConflict)
+			if !m.runProvider(m.mdnsProvider, false) {
 				return errors.New("No mDNS provider available")
 			}
 		}
 	case MdnsProviderSelectionAvahiOnly:
 		// Only use Avahi
 		m.mdnsProvider = NewAvahiProvider(ifaceIndexes)
-		_ = m.mdnsProvider.Start(true, m.processMdnsEntry)
+		m.mdnsProvider.SetNameConflictCB(m.processNameConflict)
+		_ = m.runProvider(m.mdnsProvider, true)
 	case MdnsProviderSelectionGoZeroConfOnly:
 		// Only use Zeroconf
 		m.mdnsProvider = NewZeroconfProvider(ifaces)
-		_ = m.mdnsProvider.Start(true, m.processMdnsEntry)
+		m.mdnsProvider.SetNameConflictCB(m.processNameConflict)
+		_ = m.runProvider(m.mdnsProvider, true)
 	}
 
-	// on startup always start mDNS announcement
-	if err := m.AnnounceMdnsEntry(); err != nil {
-		return err
-	}
+	return nil
+}
 
-	m.report = cb
+// Use a provider instead of the provider selection, e.g. a UnicastProvider
+//
+// Has to be invoked before Start or StartBrowsing
+func (m *MdnsManager) SetMdnsProvider(provider api.MdnsProviderInterface) {
+	m.customProvider = provider
+}
+
+// Set the callback of the application for changes of the announcement,
+// e.g. a new service name after a name conflict or another service announcing the local SKI
+//
+// Has to be invoked before Start
+func (m *MdnsManager) SetAnnouncementReport(report api.MdnsAnnouncementReportInterface) {
+	m.announcementReport = report
+}
 
-	// catch signals
+// shutdown when the process receives an interrupt or terminate signal
+func (m *MdnsManager) shutdownOnSignal() {
 	go func() {
 		signalC := make(chan os.Signal, 1)
 		signal.Notify(signalC, os.Interrupt, syscall.SIGTERM)
@@ -202,8 +310,6 @@ func (m *MdnsManager) Start(cb api.MdnsReportInterface) error {
 
 		m.Shutdown()
 	}()
-
-	return nil
 }
 
 // Shutdown all of mDNS
@@ -211,55 +317,87 @@ func (m *MdnsManager) Shutdown() {
 	m.shutdownOnce.Do(func() {
 		m.UnannounceMdnsEntry()
 
-		if m.mdnsProvider == nil {
-			return
+		m.mux.Lock()
+		if m.stopChan != nil {
+			close(m.stopChan)
 		}
+		m.mux.Unlock()
 
-		m.mdnsProvider.Shutdown()
+		m.muxTxt.Lock()
+		provider := m.mdnsProvider
 		m.mdnsProvider = nil
+		m.muxTxt.Unlock()
+
+		if provider == nil {
+			return
+		}
+
+		provider.Shutdown()
 	})
 }
 
+// announce and browse on the current interfaces and retry connections after the network changed
+func (m *MdnsManager) processNetworkChange() {
+	logging.Log().Debug("mdns: network interfaces changed")
+
+	ifaces, _, err := m.interfaces()
+	if err != nil {
+		// a configured interface may be missing while it is down, the next change is processed again
+		logging.Log().Debug("mdns: network interfaces not available", err)
+		return
+	}
+
+	m.muxTxt.Lock()
+	if m.mdnsProvider != nil {
+		m.mdnsProvider.NetworkChanged(ifaces)
+	}
+	m.muxTxt.Unlock()
+
+	reportNetworkChange(m.report)
+}
+
 // Announces the service to the network via mDNS
 // A CEM service should always invoke this on startup
 // Any other service should only invoke this whenever it is not connected to a CEM service
 func (m *MdnsManager) AnnounceMdnsEntry() error {
-	if m.mdnsProvider == nil {
-		return nil
-	}
+	m.muxTxt.Lock()
+	defer m.muxTxt.Unlock()
 
-	serviceIdentifier := m.identifier
+	return m.announceMdnsEntry()
+}
 
-	txt := []string{ // SHIP 7.3.2
-		"txtvers=1",
-		"path=" + shipWebsocketPath,
-		"id=" + serviceIdentifier,
-		"ski=" + m.ski,
-		"brand=" + m.deviceBrand,
-		"model=" + m.deviceModel,
-		"type=" + m.deviceType,
-		"register=" + fmt.Sprintf("%v", m.autoaccept),
-	}
+// announce the service with the current details, muxTxt has to be locked
+func (m *MdnsManager) announceMdnsEntry() error {
+	return m.announceMdnsEntryWithName(m.announcedServiceName())
+}
 
-	// SHIP Requirements for Installation Process V1.0.0
-	if len(m.deviceSerial) > 0 {
-		txt = append(txt, "serial="+m.deviceSerial)
+// announce the service with the current details using the service name,
+// or an alternative if a known service uses the name, muxTxt has to be locked
+func (m *MdnsManager) announceMdnsEntryWithName(serviceName string) error {
+	if m.mdnsProvider == nil {
+		return nil
 	}
 
-	categories := m.deviceCategoriesString(m.deviceCategories)
-	if len(categories) > 0 {
-		txt = append(txt, "cat="+categories)
+	txt := m.txtRecords(m.announcementDetails(), m.autoaccept)
+	if err := validateTxtRecords(txt); err != nil {
+		logging.Log().Debug("mdns: failure announcing service", err)
+		return err
 	}
 
 	logging.Log().Debug("mdns: announce")
 
-	serviceName := m.serviceName
+	// do not announce a conflicting name, as some providers do not probe for conflicts
+	for m.isServiceNameUsed(serviceName) {
+		serviceName = alternativeServiceName(serviceName)
+	}
 
 	if err := m.mdnsProvider.Announce(serviceName, m.port, txt); err != nil {
 		logging.Log().Debug("mdns: failure announcing service", err)
 		return err
 	}
 
+	m.setAnnouncedServiceName(serviceName)
+
 	m.mux.Lock()
 	defer m.mux.Unlock()
 
@@ -268,8 +406,78 @@ func (m *MdnsManager) AnnounceMdnsEntry() error {
 	return nil
 }
 
+// announce the service with an alternative name if the announced name is used by another service
+func (m *MdnsManager) processNameConflict(serviceName string) {
+	m.muxTxt.Lock()
+	defer m.muxTxt.Unlock()
+
+	// the conflict may be reported for a previous announcement
+	if !m.isServiceAnnounced() || serviceName != m.announcedServiceName() {
+		return
+	}
+
+	logging.Log().Debug("mdns: service name is used by another service:", serviceName)
+
+	if err := m.announceMdnsEntryWithName(alternativeServiceName(serviceName)); err != nil {
+		logging.Log().Debug("mdns: failure announcing service with another name", err)
+	}
+}
+
+// Returns the service name announced on the network
+//
+// Differs from the service name passed to NewMDNS if another service on the network
+// used the name, e.g. "Name (2)"
+func (m *MdnsManager) ServiceName() string {
+	m.muxTxt.Lock()
+	defer m.muxTxt.Unlock()
+
+	return m.announcedServiceName()
+}
+
+// returns the name used to announce the service, muxTxt has to be locked
+func (m *MdnsManager) announcedServiceName() string {
+	if len(m.announcedName) > 0 {
+		return m.announcedName
+	}
+
+	return m.serviceName
+}
+
+// set the name used to announce the service and report it if it changed, muxTxt has to be locked
+func (m *MdnsManager) setAnnouncedServiceName(serviceName string) {
+	if serviceName == m.announcedServiceName() {
+		return
+	}
+
+	logging.Log().Debug("mdns: announcing service as", serviceName)
+
+	m.announcedName = serviceName
+
+	if m.announcementReport != nil {
+		go m.announcementReport.ServiceNameChanged(serviceName)
+	}
+}
+
+// returns if a known service of another device uses the service name,
+// the names are compared case insensitive like DNS names
+func (m *MdnsManager) isServiceNameUsed(serviceName string) bool {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	for _, entry := range m.entries {
+		if strings.EqualFold(entry.Name, serviceName) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // Stop the mDNS announcement on the network
 func (m *MdnsManager) UnannounceMdnsEntry() {
+	m.muxTxt.Lock()
+	defer m.muxTxt.Unlock()
+
 	if !m.isServiceAnnounced() || m.mdnsProvider == nil {
 		return
 	}
@@ -295,6 +503,9 @@ func (m *MdnsManager) setIsServiceAnnounce(value bool) {
 }
 
 func (m *MdnsManager) SetAutoAccept(accept bool) {
+	m.muxTxt.Lock()
+	defer m.muxTxt.Unlock()
+
 	m.autoaccept = accept
 
 	// if announcement is off, don't enforce a new announcement
@@ -303,11 +514,147 @@ func (m *MdnsManager) SetAutoAccept(accept bool) {
 	}
 
 	// Update the announcement as autoaccept changed
-	if err := m.AnnounceMdnsEntry(); err != nil {
+	if err := m.announceMdnsEntry(); err != nil {
 		logging.Log().Debug("mdns: changing mdns entry failed", err)
 	}
 }
 
+// Returns the details of the local service announced in the TXT records
+func (m *MdnsManager) AnnouncementDetails() AnnouncementDetails {
+	m.muxTxt.Lock()
+	defer m.muxTxt.Unlock()
+
+	return m.announcementDetails()
+}
+
+// Set the details of the local service announced in the TXT records
+//
+// If the service is announced, it is re-announced with the new details.
+// Returns an error wrapping ErrInvalidTxtRecord if a value is too long or a vendor record is invalid,
+// the details are only changed if the announcement succeeds
+func (m *MdnsManager) SetAnnouncementDetails(details AnnouncementDetails) error {
+	return m.updateAnnouncementDetails(func(current *AnnouncementDetails) {
+		*current = details
+	})
+}
+
+// Set the brand of the device (max 32 byte of UTF8), see SetAnnouncementDetails
+func (m *MdnsManager) SetDeviceBrand(brand string) error {
+	return m.updateAnnouncementDetails(func(details *AnnouncementDetails) {
+		details.Brand = brand
+	})
+}
+
+// Set the model of the device (max 32 byte of UTF8), see SetAnnouncementDetails
+func (m *MdnsManager) SetDeviceModel(model string) error {
+	return m.updateAnnouncementDetails(func(details *AnnouncementDetails) {
+		details.Model = model
+	})
+}
+
+// Set the type of the device (max 32 byte of UTF8), see SetAnnouncementDetails
+func (m *MdnsManager) SetDeviceType(deviceType string) error {
+	return m.updateAnnouncementDetails(func(details *AnnouncementDetails) {
+		details.Type = deviceType
+	})
+}
+
+// Set the serial number of the device (max 32 byte of UTF8), see SetAnnouncementDetails
+func (m *MdnsManager) SetDeviceSerial(serial string) error {
+	return m.updateAnnouncementDetails(func(details *AnnouncementDetails) {
+		details.Serial = serial
+	})
+}
+
+// Set the categories of the device, see SetAnnouncementDetails
+func (m *MdnsManager) SetDeviceCategories(categories []api.DeviceCategoryType) error {
+	return m.updateAnnouncementDetails(func(details *AnnouncementDetails) {
+		details.Categories = categories
+	})
+}
+
+// Set the identifier to be used for SHIP ID, see SetAnnouncementDetails
+func (m *MdnsManager) SetIdentifier(identifier string) error {
+	return m.updateAnnouncementDetails(func(details *AnnouncementDetails) {
+		details.Identifier = identifier
+	})
+}
+
+// Set additional vendor specific TXT records, see SetAnnouncementDetails
+func (m *MdnsManager) SetVendorRecords(records map[string]string) error {
+	return m.updateAnnouncementDetails(func(details *AnnouncementDetails) {
+		details.VendorRecords = records
+	})
+}
+
+// update the announced details and re-announce the service if it is announced
+func (m *MdnsManager) updateAnnouncementDetails(update func(details *AnnouncementDetails)) error {
+	m.muxTxt.Lock()
+	defer m.muxTxt.Unlock()
+
+	previous := m.announcementDetails()
+
+	details := m.announcementDetails()
+	update(&details)
+
+	if err := validateAnnouncementDetails(details); err != nil {
+		return err
+	}
+
+	if err := validateTxtRecords(m.txtRecords(details, m.autoaccept)); err != nil {
+		return err
+	}
+
+	m.setAnnouncementDetails(details)
+
+	// if announcement is off, the details are used with the next announcement
+	if !m.isServiceAnnounced() {
+		return nil
+	}
+
+	if err := m.announceMdnsEntry(); err != nil {
+		m.setAnnouncementDetails(previous)
+
+		// the provider may have stopped the previous announcement
+		if err := m.announceMdnsEntry(); err != nil {
+			logging.Log().Debug("mdns: restoring the previous announcement failed", err)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// returns a copy of the announced details, muxTxt has to be locked
+func (m *MdnsManager) announcementDetails() AnnouncementDetails {
+	details := AnnouncementDetails{
+		Brand:      m.deviceBrand,
+		Model:      m.deviceModel,
+		Type:       m.deviceType,
+		Serial:     m.deviceSerial,
+		Categories: slices.Clone(m.deviceCategories),
+		Identifier: m.identifier,
+	}
+
+	if m.vendorRecords != nil {
+		details.VendorRecords = maps.Clone(m.vendorRecords)
+	}
+
+	return details
+}
+
+// set a copy of the announced details, muxTxt has to be locked
+func (m *MdnsManager) setAnnouncementDetails(details AnnouncementDetails) {
+	m.deviceBrand = details.Brand
+	m.deviceModel = details.Model
+	m.deviceType = details.Type
+	m.deviceSerial = details.Serial
+	m.deviceCategories = slices.Clone(details.Categories)
+	m.identifier = details.Identifier
+	m.vendorRecords = maps.Clone(details.VendorRecords)
+}
+
 // Returns a safe to use key value pair for the QR code text in the proper format
 // according to SHIP Requirements for Installation Process V1.0.0
 func (m *MdnsManager) safeQRCodeKeyValue(key, value string) string {
@@ -338,6 +685,9 @@ func (m *MdnsManager) deviceCategoriesString(categories []api.DeviceCategoryType
 // Returns the QR code text for the service
 // as defined in SHIP Requirements for Installation Process V1.0.0
 func (m *MdnsManager) QRCodeText() string {
+	m.muxTxt.Lock()
+	defer m.muxTxt.Unlock()
+
 	var optionals string
 
 	if len(m.deviceBrand) > 0 {
@@ -406,10 +756,25 @@ func (m *MdnsManager) removeMdnsEntry(ski string) {
 	defer m.mux.Unlock()
 
 	delete(m.entries, ski)
+	delete(m.lifetimes, ski)
+}
+
+// start a provider, with the ttl callback if the provider reports how long addresses are valid
+func (m *MdnsManager) runProvider(provider api.MdnsProviderInterface, autoReconnect bool) bool {
+	if ttlProvider, ok := provider.(api.MdnsProviderTTLInterface); ok {
+		return ttlProvider.StartWithTTL(autoReconnect, m.processMdnsEntry)
+	}
+
+	return provider.Start(autoReconnect, func(elements map[string]string, name, host string, addresses []net.IP, port int, remove bool) {
+		m.processMdnsEntry(elements, name, host, addresses, port, 0, remove)
+	})
 }
 
 // process an mDNS entry and manage mDNS entries map
-func (m *MdnsManager) processMdnsEntry(elements map[string]string, name, host string, addresses []net.IP, port int, remove bool) {
+//
+// Addresses reported with a ttl expire if they are not reported again in time,
+// a removal with addresses only removes these addresses
+func (m *MdnsManager) processMdnsEntry(elements map[string]string, name, host string, addresses []net.IP, port int, ttl time.Duration, remove bool) {
 	// check for mandatory text elements
 	mapItems := []string{"txtvers", "id", "path", "ski", "register"}
 	for _, item := range mapItems {
@@ -430,8 +795,9 @@ func (m *MdnsManager) processMdnsEntry(elements map[string]string, name, host st
 	path := elements["path"]
 	ski := elements["ski"]
 
-	// ignore own service
+	// ignore own service, but detect other services using the same SKI
 	if ski == m.ski {
+		m.processOwnMdnsEntry(name, host, addresses, remove)
 		return
 	}
 
@@ -442,6 +808,9 @@ func (m *MdnsManager) processMdnsEntry(elements map[string]string, name, host st
 		return
 	}
 
+	// a removal without addresses removes the whole entry
+	removeAll := len(addresses) == 0
+
 	// remove IPv6 local link addresses
 	var newAddresses []net.IP
 	for _, address := range addresses {
@@ -484,39 +853,26 @@ func (m *MdnsManager) processMdnsEntry(elements map[string]string, name, host st
 
 	updated := false
 
-	entry, exists := m.mdnsEntry(ski)
+	_, exists := m.mdnsEntry(ski)
 
 	if remove && exists {
-		updated = true
-		// remove
-		// there will be a remove for each address with avahi, but we'll delete it right away
-		m.removeMdnsEntry(ski)
+		if removeAll {
+			// there will be a remove for each address with avahi, but we'll delete it right away
+			updated = true
+			m.removeMdnsEntry(ski)
+		} else {
+			// the entry is removed together with its last address
+			updated = m.removeMdnsEntryAddresses(ski, addresses)
+		}
 
-		logging.Log().Debug("mdns: remove - ski:", ski, "name:", name, "brand:", brand, "model:", model, "typ:", deviceType, "serial:", serial, "categories:", categoriesStr, "identifier:", identifier, "register:", register, "host:", host, "port:", port, "addresses:", addresses)
+		if updated {
+			logging.Log().Debug("mdns: remove - ski:", ski, "name:", name, "brand:", brand, "model:", model, "typ:", deviceType, "serial:", serial, "categories:", categoriesStr, "identifier:", identifier, "register:", register, "host:", host, "port:", port, "addresses:", addresses)
+		}
 	} else if exists {
 		// avahi sends an item for each network address, merge them
-
-		// we assume only network addresses are added
-		for _, address := range addresses {
-			// only add if it is not added yet
-			isNewElement := true
-
-			for _, item := range entry.Addresses {
-				if item.String() == address.String() {
-					isNewElement = false
-					break
-				}
-			}
-
-			if isNewElement {
-				entry.Addresses = append(entry.Addresses, address)
-				updated = true
-			}
-		}
+		updated = m.refreshMdnsEntry(ski, addresses, ttl, time.Now())
 
 		if updated {
-			m.setMdnsEntry(ski, entry)
-
 			logging.Log().Debug("mdns: update - ski:", ski, "name:", name, "brand:", brand, "model:", model, "typ:", deviceType, "serial:", serial, "categories:", categoriesStr, "identifier:", identifier, "register:", register, "host:", host, "port:", port, "addresses:", addresses)
 		}
 	} else if !exists && !remove {
@@ -535,26 +891,82 @@ func (m *MdnsManager) processMdnsEntry(elements map[string]string, name, host st
 			Categories: categories,
 			Host:       host,
 			Port:       port,
-			Addresses:  addresses,
 		}
 		m.setMdnsEntry(ski, newEntry)
+		m.refreshMdnsEntry(ski, addresses, ttl, time.Now())
 
 		logging.Log().Debug("mdns: new - ski:", ski, "name:", name, "brand:", brand, "model:", model, "typ:", deviceType, "serial:", serial, "categories:", categoriesStr, "identifier:", identifier, "register:", register, "host:", host, "port:", port, "addresses:", addresses)
 	}
 
-	if m.report == nil || !updated {
+	if updated {
+		m.reportMdnsEntries(true)
+	}
+}
+
+// report services announcing the local SKI with addresses of other hosts, e.g. a device
+// using a copy of the certificate
+//
+// Each service is reported once until it is removed
+func (m *MdnsManager) processOwnMdnsEntry(name, host string, addresses []net.IP, remove bool) {
+	// browsing without a local service
+	if len(m.ski) == 0 {
 		return
 	}
 
-	entries := m.copyMdnsEntries()
-	go m.report.ReportMdnsEntries(entries, true)
+	key := name + "-" + host
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if remove {
+		delete(m.clones, key)
+		return
+	}
+
+	if _, ok := m.clones[key]; ok || !slices.ContainsFunc(addresses, isRemoteAddress) {
+		return
+	}
+
+	m.clones[key] = struct{}{}
+
+	err := fmt.Errorf("%w: service %q on host %s %v", ErrClonedIdentity, name, host, addresses)
+	logging.Log().Error("mdns:", err)
+
+	if m.announcementReport != nil {
+		go m.announcementReport.AnnouncementError(err)
+	}
 }
 
-func (m *MdnsManager) RequestMdnsEntries() {
+// returns if the address is not assigned to a local interface
+func isRemoteAddress(address net.IP) bool {
+	if address.IsLoopback() {
+		return false
+	}
+
+	localAddresses, err := net.InterfaceAddrs()
+	if err != nil {
+		return false
+	}
+
+	for _, localAddress := range localAddresses {
+		if ipNet, ok := localAddress.(*net.IPNet); ok && ipNet.IP.Equal(address) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// report a copy of the current mDNS entries
+func (m *MdnsManager) reportMdnsEntries(newEntries bool) {
 	if m.report == nil {
 		return
 	}
 
 	entries := m.copyMdnsEntries()
-	go m.report.ReportMdnsEntries(entries, false)
+	go m.report.ReportMdnsEntries(entries, newEntries)
+}
+
+func (m *MdnsManager) RequestMdnsEntries() {
+	m.reportMdnsEntries(false)
 }
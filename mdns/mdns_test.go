@@ -1,13 +1,17 @@
 package mdns
 
 import (
+	"errors"
 	"net"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/enbility/ship-go/api"
 	"github.com/enbility/ship-go/mocks"
 	"github.com/enbility/ship-go/util"
+	"github.com/enbility/zeroconf/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
@@ -36,6 +40,7 @@ func (s *MdnsSuite) BeforeTest(suiteName, testName string) {
 	s.mdnsProvider = mocks.NewMdnsProviderInterface(s.T())
 	s.mdnsProvider.On("ResolveEntries", mock.Anything, mock.Anything).Maybe().Return()
 	s.mdnsProvider.On("Shutdown").Maybe().Return()
+	s.mdnsProvider.On("SetNameConflictCB", mock.Anything).Maybe().Return()
 
 	s.sut = NewMDNS("test", "brand", "model", "EnergyManagementSystem",
 		"12345",
@@ -140,6 +145,21 @@ func (s *MdnsSuite) Test_Start() {
 	assert.Equal(s.T(), false, s.sut.isAnnounced)
 }
 
+func (s *MdnsSuite) Test_StartBrowsing() {
+	s.sut.Shutdown()
+
+	s.sut = NewMDNS("", "", "", "", "", nil, "", "", 0, nil, MdnsProviderSelectionGoZeroConfOnly)
+
+	err := s.sut.StartBrowsing(s.mdnsSearch)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), false, s.sut.isAnnounced)
+	assert.NotNil(s.T(), s.sut.report)
+
+	s.sut.ifaces = []string{"noifacename"}
+	err = s.sut.StartBrowsing(s.mdnsSearch)
+	assert.NotNil(s.T(), err)
+}
+
 func (s *MdnsSuite) Test_Start_IFaces() {
 	// we don't have access to iface names on CI
 	if util.IsRunningOnCI() {
@@ -241,7 +261,7 @@ func (s *MdnsSuite) Test_ProcessMdnsEntry() {
 	ips := []net.IP{}
 	port := 4567
 
-	s.sut.processMdnsEntry(elements, name, host, ips, port, false)
+	s.sut.processMdnsEntry(elements, name, host, ips, port, 0, false)
 	assert.Equal(s.T(), 0, len(s.sut.mdnsEntries()))
 
 	elements["txtvers"] = "2"
@@ -251,23 +271,23 @@ func (s *MdnsSuite) Test_ProcessMdnsEntry() {
 	elements["register"] = "falsee"
 	elements["cat"] = "text"
 
-	s.sut.processMdnsEntry(elements, name, host, ips, port, false)
+	s.sut.processMdnsEntry(elements, name, host, ips, port, 0, false)
 	assert.Equal(s.T(), 0, len(s.sut.mdnsEntries()))
 
 	elements["txtvers"] = "1"
-	s.sut.processMdnsEntry(elements, name, host, ips, port, false)
+	s.sut.processMdnsEntry(elements, name, host, ips, port, 0, false)
 	assert.Equal(s.T(), 0, len(s.sut.mdnsEntries()))
 
 	elements["ski"] = s.sut.ski
-	s.sut.processMdnsEntry(elements, name, host, ips, port, false)
+	s.sut.processMdnsEntry(elements, name, host, ips, port, 0, false)
 	assert.Equal(s.T(), 0, len(s.sut.mdnsEntries()))
 
 	elements["ski"] = "testski"
-	s.sut.processMdnsEntry(elements, name, host, ips, port, false)
+	s.sut.processMdnsEntry(elements, name, host, ips, port, 0, false)
 	assert.Equal(s.T(), 0, len(s.sut.mdnsEntries()))
 
 	elements["register"] = "false"
-	s.sut.processMdnsEntry(elements, name, host, ips, port, false)
+	s.sut.processMdnsEntry(elements, name, host, ips, port, 0, false)
 	assert.Equal(s.T(), 1, len(s.sut.mdnsEntries()))
 
 	elements["brand"] = "brand"
@@ -275,16 +295,390 @@ func (s *MdnsSuite) Test_ProcessMdnsEntry() {
 	elements["model"] = "model"
 	elements["serial"] = "serial"
 	elements["cat"] = "2,3"
-	s.sut.processMdnsEntry(elements, name, host, ips, port, false)
+	s.sut.processMdnsEntry(elements, name, host, ips, port, 0, false)
 	assert.Equal(s.T(), 1, len(s.sut.mdnsEntries()))
 
 	ips = []net.IP{[]byte("127.0.0.1"), []byte{0xfe, 0x80, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}}
-	s.sut.processMdnsEntry(elements, name, host, ips, port, false)
+	s.sut.processMdnsEntry(elements, name, host, ips, port, 0, false)
 	assert.Equal(s.T(), 1, len(s.sut.mdnsEntries()))
 
-	s.sut.processMdnsEntry(elements, name, host, ips, port, false)
+	s.sut.processMdnsEntry(elements, name, host, ips, port, 0, false)
 	assert.Equal(s.T(), 1, len(s.sut.mdnsEntries()))
 
-	s.sut.processMdnsEntry(elements, name, host, ips, port, true)
+	s.sut.processMdnsEntry(elements, name, host, ips, port, 0, true)
 	assert.Equal(s.T(), 0, len(s.sut.mdnsEntries()))
 }
+
+func (s *MdnsSuite) Test_ProcessMdnsEntry_Addresses() {
+	elements := map[string]string{
+		"txtvers":  "1",
+		"id":       "id",
+		"path":     "/ship",
+		"ski":      "testski",
+		"register": "false",
+	}
+	address1 := net.ParseIP("192.168.1.10")
+	address2 := net.ParseIP("192.168.1.11")
+	linkLocal := net.ParseIP("fe80::1")
+
+	s.sut.processMdnsEntry(elements, "name", "host", []net.IP{address1}, 4567, 0, false)
+	s.sut.processMdnsEntry(elements, "name", "host", []net.IP{address2}, 4567, 0, false)
+	entry, ok := s.sut.mdnsEntry("testski")
+	if assert.True(s.T(), ok) {
+		assert.Equal(s.T(), []net.IP{address1, address2}, entry.Addresses)
+	}
+
+	// only the reported address is removed
+	s.sut.processMdnsEntry(elements, "name", "host", []net.IP{address1}, 4567, 0, true)
+	entry, ok = s.sut.mdnsEntry("testski")
+	if assert.True(s.T(), ok) {
+		assert.Equal(s.T(), []net.IP{address2}, entry.Addresses)
+	}
+
+	// ignored link local addresses do not remove the entry
+	s.sut.processMdnsEntry(elements, "name", "host", []net.IP{linkLocal}, 4567, 0, true)
+	_, ok = s.sut.mdnsEntry("testski")
+	assert.True(s.T(), ok)
+
+	// the entry is removed with its last address
+	s.sut.processMdnsEntry(elements, "name", "host", []net.IP{address2}, 4567, 0, true)
+	_, ok = s.sut.mdnsEntry("testski")
+	assert.False(s.T(), ok)
+	assert.Empty(s.T(), s.sut.lifetimes)
+
+	// a removal without addresses removes the whole entry
+	s.sut.processMdnsEntry(elements, "name", "host", []net.IP{address1, address2}, 4567, 0, false)
+	s.sut.processMdnsEntry(elements, "name", "host", nil, -1, 0, true)
+	_, ok = s.sut.mdnsEntry("testski")
+	assert.False(s.T(), ok)
+}
+
+func (s *MdnsSuite) Test_ExpireMdnsEntries() {
+	elements := map[string]string{
+		"txtvers":  "1",
+		"id":       "id",
+		"path":     "/ship",
+		"ski":      "testski",
+		"register": "false",
+	}
+	address1 := net.ParseIP("192.168.1.10")
+	address2 := net.ParseIP("192.168.1.11")
+
+	s.sut.processMdnsEntry(elements, "name", "host", []net.IP{address1, address2}, 4567, time.Minute, false)
+	now := time.Now()
+	assert.False(s.T(), s.sut.expireMdnsEntries(now))
+
+	// address2 is no longer reported, e.g. after a DHCP change
+	s.sut.processMdnsEntry(elements, "name", "host", []net.IP{address1}, 4567, time.Minute, false)
+	lifetime := s.sut.lifetimes["testski"]
+	lifetime.addresses[address2.String()] = newLifetime(now.Add(-2*time.Minute), time.Minute)
+
+	assert.True(s.T(), s.sut.expireMdnsEntries(now))
+	entry, ok := s.sut.mdnsEntry("testski")
+	if assert.True(s.T(), ok) {
+		assert.Equal(s.T(), []net.IP{address1}, entry.Addresses)
+	}
+	assert.False(s.T(), s.sut.expireMdnsEntries(now))
+
+	// the entry expires if it is not reported again
+	assert.True(s.T(), s.sut.expireMdnsEntries(now.Add(2*time.Minute)))
+	_, ok = s.sut.mdnsEntry("testski")
+	assert.False(s.T(), ok)
+	assert.Empty(s.T(), s.sut.lifetimes)
+
+	// entries of providers reporting the removal themselves do not expire
+	s.sut.processMdnsEntry(elements, "name", "host", []net.IP{address1}, 4567, 0, false)
+	assert.False(s.T(), s.sut.expireMdnsEntries(now.Add(time.Hour)))
+	_, ok = s.sut.mdnsEntry("testski")
+	assert.True(s.T(), ok)
+}
+
+func (s *MdnsSuite) Test_ExpireZeroconfEntry() {
+	provider := NewZeroconfProvider(nil)
+	service := &zeroconf.ServiceEntry{
+		ServiceRecord: zeroconf.ServiceRecord{Instance: "name"},
+		HostName:      "host",
+		Port:          4567,
+		Text:          []string{"txtvers=1", "id=id", "path=/ship", "ski=testski", "register=false"},
+		AddrIPv4:      []net.IP{net.ParseIP("192.168.1.10")},
+	}
+
+	provider.processAddedEntry(service, s.sut.processMdnsEntry)
+	_, ok := s.sut.mdnsEntry("testski")
+	assert.True(s.T(), ok)
+
+	// the entry is kept while the provider reports it again
+	now := time.Now()
+	provider.refreshServices(s.sut.processMdnsEntry)
+	assert.False(s.T(), s.sut.expireMdnsEntries(now.Add(zeroconfTTL*time.Second-providerRefreshInterval)))
+
+	// the entry expires if the provider stops reporting it
+	assert.True(s.T(), s.sut.expireMdnsEntries(time.Now().Add(zeroconfTTL*time.Second+time.Second)))
+	_, ok = s.sut.mdnsEntry("testski")
+	assert.False(s.T(), ok)
+
+	// a removed entry is not reported again
+	provider.processRemovedEntry(service, s.sut.processMdnsEntry)
+	provider.refreshServices(s.sut.processMdnsEntry)
+	_, ok = s.sut.mdnsEntry("testski")
+	assert.False(s.T(), ok)
+}
+
+func (s *MdnsSuite) Test_SetAnnouncementDetails() {
+	s.mdnsProvider.EXPECT().Unannounce().Maybe()
+
+	// not announced yet, the details are used with the next announcement
+	err := s.sut.SetDeviceBrand("other brand")
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), "other brand", s.sut.AnnouncementDetails().Brand)
+
+	s.mdnsProvider.EXPECT().Announce("serviceName", 4729, mock.MatchedBy(func(txt []string) bool {
+		return slices.Contains(txt, "brand=other brand")
+	})).Return(nil).Once()
+	err = s.sut.AnnounceMdnsEntry()
+	assert.Nil(s.T(), err)
+
+	// the vendor records are announced after the SHIP records
+	s.mdnsProvider.EXPECT().Announce("serviceName", 4729, []string{
+		"txtvers=1", "path=/ship/", "id=otherid", "ski=test",
+		"brand=other brand", "model=other model", "type=EnergyManagementSystem", "register=false",
+		"serial=12345", "cat=2,3",
+		"vendor.fw=1.2.3", "vendor.url=http://device.local/?a=b",
+	}).Return(nil).Once()
+	err = s.sut.SetAnnouncementDetails(AnnouncementDetails{
+		Brand:      "other brand",
+		Model:      "other model",
+		Type:       "EnergyManagementSystem",
+		Serial:     "12345",
+		Categories: []api.DeviceCategoryType{api.DeviceCategoryTypeEnergyManagementSystem, api.DeviceCategoryTypeEMobility},
+		Identifier: "otherid",
+		VendorRecords: map[string]string{
+			"vendor.url": "http://device.local/?a=b",
+			"vendor.fw":  "1.2.3",
+		},
+	})
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), "SHIP;SKI:test;ID:otherid;BRAND:other brand;TYPE:EnergyManagementSystem;MODEL:other model;SERIAL:12345;CAT:2,3;ENDSHIP;", s.sut.QRCodeText())
+
+	// invalid details are not announced
+	err = s.sut.SetDeviceModel("modelmodelmodelmodelmodelmodelmodel")
+	assert.ErrorIs(s.T(), err, ErrInvalidTxtRecord)
+	err = s.sut.SetVendorRecords(map[string]string{"SKI": "other"})
+	assert.ErrorIs(s.T(), err, ErrInvalidTxtRecord)
+	err = s.sut.SetVendorRecords(map[string]string{"key": strings.Repeat("x", 252)})
+	assert.ErrorIs(s.T(), err, ErrInvalidTxtRecord)
+	assert.Equal(s.T(), "other model", s.sut.AnnouncementDetails().Model)
+
+	// the previous details are restored if the announcement fails
+	someError := errors.New("some error")
+	s.mdnsProvider.EXPECT().Announce("serviceName", 4729, mock.MatchedBy(func(txt []string) bool {
+		return slices.Contains(txt, "serial=54321")
+	})).Return(someError).Once()
+	s.mdnsProvider.EXPECT().Announce("serviceName", 4729, mock.MatchedBy(func(txt []string) bool {
+		return slices.Contains(txt, "serial=12345")
+	})).Return(nil).Once()
+	err = s.sut.SetDeviceSerial("54321")
+	assert.ErrorIs(s.T(), err, someError)
+	assert.Equal(s.T(), "12345", s.sut.AnnouncementDetails().Serial)
+
+	s.mdnsProvider.EXPECT().Announce("serviceName", 4729, mock.MatchedBy(func(txt []string) bool {
+		return slices.Contains(txt, "type=Inverter") && slices.Contains(txt, "id=otherid")
+	})).Return(nil).Once()
+	assert.Nil(s.T(), s.sut.SetDeviceType("Inverter"))
+
+	s.mdnsProvider.EXPECT().Announce("serviceName", 4729, mock.MatchedBy(func(txt []string) bool {
+		return slices.Contains(txt, "id=newid") && slices.Contains(txt, "cat=2,3")
+	})).Return(nil).Once()
+	assert.Nil(s.T(), s.sut.SetIdentifier("newid"))
+
+	s.mdnsProvider.EXPECT().Announce("serviceName", 4729, mock.MatchedBy(func(txt []string) bool {
+		return slices.Contains(txt, "id=newid") && !slices.Contains(txt, "cat=2,3")
+	})).Return(nil).Once()
+	assert.Nil(s.T(), s.sut.SetDeviceCategories(nil))
+
+	// the returned details are a copy
+	details := s.sut.AnnouncementDetails()
+	details.VendorRecords["vendor.fw"] = "changed"
+	assert.Equal(s.T(), "1.2.3", s.sut.AnnouncementDetails().VendorRecords["vendor.fw"])
+}
+
+func (s *MdnsSuite) Test_SetMdnsProvider() {
+	s.sut.SetMdnsProvider(s.mdnsProvider)
+
+	s.mdnsProvider.EXPECT().Start(true, mock.Anything).Return(true).Once()
+	s.mdnsProvider.EXPECT().Announce("serviceName", 4729, mock.Anything).Return(nil).Once()
+	s.mdnsProvider.EXPECT().Unannounce().Return().Once()
+	err := s.sut.Start(s.mdnsSearch)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), s.mdnsProvider, s.sut.mdnsProvider)
+
+	s.sut.Shutdown()
+
+	s.sut = NewMDNS("", "", "", "", "", nil, "", "", 0, nil, MdnsProviderSelectionAll)
+	s.sut.SetMdnsProvider(s.mdnsProvider)
+
+	s.mdnsProvider.EXPECT().Start(true, mock.Anything).Return(false).Once()
+	err = s.sut.StartBrowsing(s.mdnsSearch)
+	assert.NotNil(s.T(), err)
+}
+
+// a provider reporting how long addresses are valid
+type ttlMdnsProvider struct {
+	*mocks.MdnsProviderInterface
+	*mocks.MdnsProviderTTLInterface
+}
+
+func (s *MdnsSuite) Test_RunProvider() {
+	elements := map[string]string{
+		"txtvers":  "1",
+		"id":       "id",
+		"path":     "/ship",
+		"ski":      "testski",
+		"register": "false",
+	}
+	address := net.ParseIP("192.168.1.10")
+
+	// providers without ttl report the removal themselves
+	s.mdnsProvider.EXPECT().Start(true, mock.Anything).RunAndReturn(func(autoReconnect bool, cb api.MdnsResolveCB) bool {
+		cb(elements, "name", "host", []net.IP{address}, 4567, false)
+		return true
+	}).Once()
+	assert.True(s.T(), s.sut.runProvider(s.mdnsProvider, true))
+	assert.False(s.T(), s.sut.expireMdnsEntries(time.Now().Add(time.Hour)))
+	_, ok := s.sut.mdnsEntry("testski")
+	assert.True(s.T(), ok)
+
+	s.sut.removeMdnsEntry("testski")
+
+	provider := ttlMdnsProvider{
+		MdnsProviderInterface:    mocks.NewMdnsProviderInterface(s.T()),
+		MdnsProviderTTLInterface: mocks.NewMdnsProviderTTLInterface(s.T()),
+	}
+	provider.MdnsProviderTTLInterface.EXPECT().StartWithTTL(true, mock.Anything).RunAndReturn(func(autoReconnect bool, cb api.MdnsResolveTTLCB) bool {
+		cb(elements, "name", "host", []net.IP{address}, 4567, time.Minute, false)
+		return true
+	}).Once()
+	assert.True(s.T(), s.sut.runProvider(provider, true))
+	assert.True(s.T(), s.sut.expireMdnsEntries(time.Now().Add(time.Hour)))
+	_, ok = s.sut.mdnsEntry("testski")
+	assert.False(s.T(), ok)
+}
+
+// a report callback which is notified about network changes
+type networkChangeReport struct {
+	*mocks.MdnsReportInterface
+	*mocks.MdnsNetworkChangeReportInterface
+}
+
+func (s *MdnsSuite) Test_ProcessNetworkChange() {
+	// not started yet
+	s.sut.mdnsProvider = nil
+	s.sut.processNetworkChange()
+
+	s.sut.mdnsProvider = s.mdnsProvider
+
+	// the report callback is only notified if it implements api.MdnsNetworkChangeReportInterface
+	s.sut.report = s.mdnsSearch
+	s.mdnsProvider.EXPECT().NetworkChanged([]net.Interface(nil)).Return().Once()
+	s.sut.processNetworkChange()
+
+	changeReport := mocks.NewMdnsNetworkChangeReportInterface(s.T())
+	s.sut.report = networkChangeReport{
+		MdnsReportInterface:              s.mdnsSearch,
+		MdnsNetworkChangeReportInterface: changeReport,
+	}
+
+	reported := make(chan struct{})
+	s.mdnsProvider.EXPECT().NetworkChanged([]net.Interface(nil)).Return().Once()
+	changeReport.EXPECT().NetworkChanged().Run(func() { close(reported) }).Return().Once()
+	s.sut.processNetworkChange()
+
+	select {
+	case <-reported:
+	case <-time.After(time.Second):
+		s.T().Error("the network change was not reported")
+	}
+
+	// a configured interface is not available
+	s.sut.ifaces = []string{"noifacename"}
+	s.sut.processNetworkChange()
+}
+
+func (s *MdnsSuite) Test_NameConflict() {
+	report := mocks.NewMdnsAnnouncementReportInterface(s.T())
+	s.sut.SetAnnouncementReport(report)
+
+	elements := map[string]string{
+		"txtvers":  "1",
+		"id":       "id",
+		"path":     "/ship",
+		"ski":      "testski",
+		"register": "false",
+	}
+	s.sut.processMdnsEntry(elements, "SERVICENAME", "host", []net.IP{net.ParseIP("192.168.1.10")}, 4729, 0, false)
+
+	// a known service uses the name
+	reported := make(chan string, 2)
+	report.EXPECT().ServiceNameChanged(mock.Anything).Run(func(serviceName string) { reported <- serviceName }).Return().Twice()
+	s.mdnsProvider.EXPECT().Announce("serviceName (2)", 4729, mock.Anything).Return(nil).Once()
+	err := s.sut.AnnounceMdnsEntry()
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), "serviceName (2)", <-reported)
+	assert.Equal(s.T(), "serviceName (2)", s.sut.ServiceName())
+
+	// the name is kept with the next announcement
+	s.mdnsProvider.EXPECT().Announce("serviceName (2)", 4729, mock.Anything).Return(nil).Once()
+	s.sut.SetAutoAccept(true)
+
+	// a conflict of a previous announcement is ignored
+	s.sut.processNameConflict("serviceName")
+
+	// a provider detected a conflict
+	s.mdnsProvider.EXPECT().Announce("serviceName (3)", 4729, mock.Anything).Return(nil).Once()
+	s.sut.processNameConflict("serviceName (2)")
+	assert.Equal(s.T(), "serviceName (3)", <-reported)
+	assert.Equal(s.T(), "serviceName (3)", s.sut.ServiceName())
+
+	// announcing with another name failed
+	s.mdnsProvider.EXPECT().Announce("serviceName (4)", 4729, mock.Anything).Return(errors.New("test")).Once()
+	s.sut.processNameConflict("serviceName (3)")
+	assert.Equal(s.T(), "serviceName (3)", s.sut.ServiceName())
+
+	s.mdnsProvider.EXPECT().Unannounce().Return().Once()
+	s.sut.UnannounceMdnsEntry()
+	s.sut.processNameConflict("serviceName (3)")
+}
+
+func (s *MdnsSuite) Test_ClonedIdentity() {
+	report := mocks.NewMdnsAnnouncementReportInterface(s.T())
+	s.sut.SetAnnouncementReport(report)
+
+	elements := map[string]string{
+		"txtvers":  "1",
+		"id":       "shipid",
+		"path":     "/ship",
+		"ski":      s.sut.ski,
+		"register": "false",
+	}
+
+	// the own service
+	s.sut.processMdnsEntry(elements, "serviceName", "host", []net.IP{net.ParseIP("127.0.0.1")}, 4729, 0, false)
+
+	// another service uses the SKI, reported once
+	reported := make(chan error, 2)
+	report.EXPECT().AnnouncementError(mock.Anything).Run(func(err error) { reported <- err }).Return().Twice()
+	s.sut.processMdnsEntry(elements, "serviceName", "clone", []net.IP{net.ParseIP("192.0.2.1")}, 4729, 0, false)
+	s.sut.processMdnsEntry(elements, "serviceName", "clone", []net.IP{net.ParseIP("192.0.2.1")}, 4729, 0, false)
+	assert.ErrorIs(s.T(), <-reported, ErrClonedIdentity)
+	assert.Equal(s.T(), 0, len(s.sut.mdnsEntries()))
+
+	// reported again after it was removed
+	s.sut.processMdnsEntry(elements, "serviceName", "clone", nil, 4729, 0, true)
+	s.sut.processMdnsEntry(elements, "serviceName", "clone", []net.IP{net.ParseIP("192.0.2.1")}, 4729, 0, false)
+	assert.ErrorIs(s.T(), <-reported, ErrClonedIdentity)
+
+	// browsing without a local service
+	s.sut.ski = ""
+	elements["ski"] = ""
+	s.sut.processMdnsEntry(elements, "serviceName", "other", []net.IP{net.ParseIP("192.0.2.2")}, 4729, 0, false)
+}
@@ -0,0 +1,138 @@
+package mdns
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/enbility/ship-go/api"
+	"github.com/enbility/ship-go/logging"
+)
+
+// the time without further events before a network change is processed, as changes cause bursts of events
+var networkChangeDelay = 2 * time.Second
+
+// the interval for checking the network interfaces if the system does not report changes
+var networkPollInterval = 10 * time.Second
+
+var errNetworkEventsNotSupported = errors.New("network events are not supported on this system")
+
+// returns a description of the running interfaces and their addresses, used to detect changes
+func networkSnapshot() string {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return ""
+	}
+
+	var items []string
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagRunning == 0 {
+			continue
+		}
+
+		addresses, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+
+		var values []string
+		for _, address := range addresses {
+			values = append(values, address.String())
+		}
+		slices.Sort(values)
+
+		items = append(items, fmt.Sprintf("%d:%s:%s", iface.Index, iface.Name, strings.Join(values, ",")))
+	}
+	slices.Sort(items)
+
+	return strings.Join(items, ";")
+}
+
+// invoke changed whenever interfaces came up, went down or changed their addresses, until stop is closed
+//
+// Uses the events of the system if available (netlink on Linux), otherwise the interfaces are polled
+func watchNetwork(stop <-chan struct{}, changed func()) {
+	events := make(chan struct{}, 1)
+
+	go func() {
+		err := networkEvents(stop, events)
+		if err == nil {
+			return
+		}
+
+		logging.Log().Debug("mdns: polling network interfaces:", err)
+		pollNetwork(stop, events)
+	}()
+
+	processNetworkEvents(stop, events, networkSnapshot, changed)
+}
+
+// invoke changed if the snapshot differs after a burst of events ended, until stop is closed
+func processNetworkEvents(stop <-chan struct{}, events <-chan struct{}, snapshot func() string, changed func()) {
+	previous := snapshot()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-events:
+		}
+
+		// wait until the burst of events ended
+		timer := time.NewTimer(networkChangeDelay)
+	debounce:
+		for {
+			select {
+			case <-stop:
+				timer.Stop()
+				return
+			case <-events:
+				timer.Reset(networkChangeDelay)
+			case <-timer.C:
+				break debounce
+			}
+		}
+
+		// events are also reported for changes which are not relevant, e.g. statistics
+		current := snapshot()
+		if current == previous {
+			continue
+		}
+		previous = current
+
+		changed()
+	}
+}
+
+// notify the report callback about a network change, if it implements api.MdnsNetworkChangeReportInterface
+func reportNetworkChange(report api.MdnsReportInterface) {
+	if changeReport, ok := report.(api.MdnsNetworkChangeReportInterface); ok {
+		go changeReport.NetworkChanged()
+	}
+}
+
+// notify events periodically, so the interfaces are compared, until stop is closed
+func pollNetwork(stop <-chan struct{}, events chan<- struct{}) {
+	ticker := time.NewTicker(networkPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			notifyNetworkEvent(events)
+		}
+	}
+}
+
+// notify an event without blocking, a pending event is sufficient
+func notifyNetworkEvent(events chan<- struct{}) {
+	select {
+	case events <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,58 @@
+package mdns
+
+import (
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// notify events for changes of links and addresses reported via netlink, until stop is closed
+func networkEvents(stop <-chan struct{}, events chan<- struct{}) error {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW|unix.SOCK_CLOEXEC|unix.SOCK_NONBLOCK, unix.NETLINK_ROUTE)
+	if err != nil {
+		return err
+	}
+
+	address := &unix.SockaddrNetlink{
+		Family: unix.AF_NETLINK,
+		Groups: unix.RTMGRP_LINK | unix.RTMGRP_IPV4_IFADDR | unix.RTMGRP_IPV6_IFADDR,
+	}
+	if err := unix.Bind(fd, address); err != nil {
+		_ = unix.Close(fd)
+		return err
+	}
+
+	// the file uses the runtime poller, so closing it stops a blocking read
+	file := os.NewFile(uintptr(fd), "netlink")
+
+	go func() {
+		<-stop
+		_ = file.Close()
+	}()
+
+	buffer := make([]byte, os.Getpagesize())
+	for {
+		n, err := file.Read(buffer)
+		if err != nil {
+			select {
+			case <-stop:
+				return nil
+			default:
+				return err
+			}
+		}
+
+		messages, err := syscall.ParseNetlinkMessage(buffer[:n])
+		if err != nil {
+			continue
+		}
+
+		for _, message := range messages {
+			switch message.Header.Type {
+			case unix.RTM_NEWLINK, unix.RTM_DELLINK, unix.RTM_NEWADDR, unix.RTM_DELADDR:
+				notifyNetworkEvent(events)
+			}
+		}
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package mdns
+
+// the interfaces are polled on systems other than Linux
+func networkEvents(stop <-chan struct{}, events chan<- struct{}) error {
+	return errNetworkEventsNotSupported
+}
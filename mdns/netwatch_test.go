@@ -0,0 +1,76 @@
+package mdns
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessNetworkEvents(t *testing.T) {
+	networkChangeDelay = 50 * time.Millisecond
+	defer func() { networkChangeDelay = 2 * time.Second }()
+
+	var mux sync.Mutex
+	current := "eth0:192.168.1.2/24"
+	snapshot := func() string {
+		mux.Lock()
+		defer mux.Unlock()
+		return current
+	}
+
+	changes := make(chan struct{}, 10)
+	changed := func() {
+		changes <- struct{}{}
+	}
+
+	stop := make(chan struct{})
+	events := make(chan struct{}, 1)
+	done := make(chan struct{})
+	go func() {
+		processNetworkEvents(stop, events, snapshot, changed)
+		close(done)
+	}()
+
+	// an event without a change of the interfaces
+	notifyNetworkEvent(events)
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, 0, len(changes))
+
+	// a burst of events is processed once
+	mux.Lock()
+	current = "eth0:192.168.1.3/24"
+	mux.Unlock()
+	for i := 0; i < 5; i++ {
+		notifyNetworkEvent(events)
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, 1, len(changes))
+
+	close(stop)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("processing network events did not stop")
+	}
+}
+
+func TestWatchNetwork(t *testing.T) {
+	assert.NotEqual(t, "", networkSnapshot())
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		watchNetwork(stop, func() {})
+		close(done)
+	}()
+
+	close(stop)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("watching the network did not stop")
+	}
+}
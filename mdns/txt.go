@@ -0,0 +1,175 @@
+package mdns
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/enbility/ship-go/api"
+)
+
+const (
+	// the maximum length of the brand, model, type and serial values: SHIP 7.3.2
+	maxTxtValueLength = 32
+
+	// the maximum length of a single TXT record string: RFC 6763 6.1
+	maxTxtStringLength = 255
+
+	// the maximum size of all TXT record strings including their length bytes: RFC 6763 6.2
+	maxTxtRecordSize = 1300
+)
+
+// the TXT keys defined by SHIP 7.3.2 and SHIP Requirements for Installation Process V1.0.0
+var shipTxtKeys = []string{"txtvers", "path", "id", "ski", "brand", "model", "type", "register", "serial", "cat"}
+
+// ErrInvalidTxtRecord is returned if announcement details do not fit into the TXT records of the announcement
+var ErrInvalidTxtRecord = errors.New("invalid TXT record")
+
+// The details of the local service announced in the TXT records
+type AnnouncementDetails struct {
+	// The brand of the device (max 32 byte of UTF8)
+	Brand string
+
+	// The model of the device (max 32 byte of UTF8)
+	Model string
+
+	// The type of the device (max 32 byte of UTF8)
+	Type string
+
+	// The serial number of the device (max 32 byte of UTF8)
+	Serial string
+
+	// The categories of the device
+	Categories []api.DeviceCategoryType
+
+	// The identifier to be used for SHIP ID
+	Identifier string
+
+	// Additional vendor specific TXT records with the key as the map key
+	//
+	// Keys are case insensitive, have to consist of printable US-ASCII characters
+	// except "=" and must not use one of the keys defined by SHIP
+	VendorRecords map[string]string
+}
+
+// shorten a string to a maximum number of bytes without splitting an UTF8 character
+func shortenString(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+
+	s = s[:maxLen]
+	for len(s) > 0 && !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+
+	return s
+}
+
+// validate a vendor TXT record key: RFC 6763 6.4
+func validateTxtKey(key string) error {
+	if len(key) == 0 {
+		return fmt.Errorf("%w: empty key", ErrInvalidTxtRecord)
+	}
+
+	for _, char := range key {
+		if char < 0x20 || char > 0x7e || char == '=' {
+			return fmt.Errorf("%w: key %q contains invalid characters", ErrInvalidTxtRecord, key)
+		}
+	}
+
+	if slices.Contains(shipTxtKeys, strings.ToLower(key)) {
+		return fmt.Errorf("%w: key %q is defined by SHIP", ErrInvalidTxtRecord, key)
+	}
+
+	return nil
+}
+
+// validate the announcement details which are not covered by the limits of the TXT records
+func validateAnnouncementDetails(details AnnouncementDetails) error {
+	values := map[string]string{
+		"brand":  details.Brand,
+		"model":  details.Model,
+		"type":   details.Type,
+		"serial": details.Serial,
+	}
+	for key, value := range values {
+		if len(value) > maxTxtValueLength {
+			return fmt.Errorf("%w: %s is longer than %d bytes", ErrInvalidTxtRecord, key, maxTxtValueLength)
+		}
+	}
+
+	keys := make(map[string]string)
+	for key := range details.VendorRecords {
+		if err := validateTxtKey(key); err != nil {
+			return err
+		}
+
+		// keys are case insensitive: RFC 6763 6.4
+		if other, ok := keys[strings.ToLower(key)]; ok {
+			return fmt.Errorf("%w: keys %q and %q are equal", ErrInvalidTxtRecord, key, other)
+		}
+		keys[strings.ToLower(key)] = key
+	}
+
+	return nil
+}
+
+// validate the size of the TXT records of an announcement
+func validateTxtRecords(txt []string) error {
+	size := 0
+	for _, item := range txt {
+		if len(item) > maxTxtStringLength {
+			return fmt.Errorf("%w: %q is longer than %d bytes", ErrInvalidTxtRecord, item, maxTxtStringLength)
+		}
+
+		// each string is prefixed by a length byte
+		size += len(item) + 1
+	}
+
+	if size > maxTxtRecordSize {
+		return fmt.Errorf("%w: the TXT records have %d bytes, more than %d bytes", ErrInvalidTxtRecord, size, maxTxtRecordSize)
+	}
+
+	return nil
+}
+
+// Returns the TXT records announcing the service with the provided details
+func (m *MdnsManager) txtRecords(details AnnouncementDetails, autoaccept bool) []string {
+	txt := []string{ // SHIP 7.3.2
+		"txtvers=1",
+		"path=" + shipWebsocketPath,
+		"id=" + details.Identifier,
+		"ski=" + m.ski,
+		"brand=" + details.Brand,
+		"model=" + details.Model,
+		"type=" + details.Type,
+		"register=" + fmt.Sprintf("%v", autoaccept),
+	}
+
+	// SHIP Requirements for Installation Process V1.0.0
+	if len(details.Serial) > 0 {
+		txt = append(txt, "serial="+details.Serial)
+	}
+
+	categories := m.deviceCategoriesString(details.Categories)
+	if len(categories) > 0 {
+		txt = append(txt, "cat="+categories)
+	}
+
+	// sort the vendor records, so the announcement does not change without changes
+	keys := make([]string, 0, len(details.VendorRecords))
+	for key := range details.VendorRecords {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		txt = append(txt, key+"="+details.VendorRecords[key])
+	}
+
+	return txt
+}
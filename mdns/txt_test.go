@@ -0,0 +1,61 @@
+package mdns
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShortenString(t *testing.T) {
+	assert.Equal(t, "brand", shortenString("brand", 32))
+	assert.Equal(t, "brandbrandb", shortenString("brandbrandbrand", 11))
+
+	// an UTF8 character is not split
+	assert.Equal(t, "brand", shortenString("brandä", 6))
+	assert.Equal(t, "brandä", shortenString("brandä", 7))
+}
+
+func TestValidateTxtKey(t *testing.T) {
+	assert.Nil(t, validateTxtKey("vendor"))
+	assert.Nil(t, validateTxtKey("vendor.fw version"))
+
+	assert.ErrorIs(t, validateTxtKey(""), ErrInvalidTxtRecord)
+	assert.ErrorIs(t, validateTxtKey("a=b"), ErrInvalidTxtRecord)
+	assert.ErrorIs(t, validateTxtKey("vendorä"), ErrInvalidTxtRecord)
+	assert.ErrorIs(t, validateTxtKey("vendor\n"), ErrInvalidTxtRecord)
+	assert.ErrorIs(t, validateTxtKey("id"), ErrInvalidTxtRecord)
+	assert.ErrorIs(t, validateTxtKey("Register"), ErrInvalidTxtRecord)
+}
+
+func TestValidateAnnouncementDetails(t *testing.T) {
+	details := AnnouncementDetails{
+		Brand:         strings.Repeat("b", 32),
+		Model:         strings.Repeat("m", 32),
+		Type:          strings.Repeat("t", 32),
+		Serial:        strings.Repeat("s", 32),
+		VendorRecords: map[string]string{"vendor": "value"},
+	}
+	assert.Nil(t, validateAnnouncementDetails(details))
+
+	details.Serial = strings.Repeat("s", 33)
+	assert.ErrorIs(t, validateAnnouncementDetails(details), ErrInvalidTxtRecord)
+	details.Serial = ""
+
+	details.VendorRecords["Vendor"] = "value"
+	assert.ErrorIs(t, validateAnnouncementDetails(details), ErrInvalidTxtRecord)
+}
+
+func TestValidateTxtRecords(t *testing.T) {
+	assert.Nil(t, validateTxtRecords(nil))
+	assert.Nil(t, validateTxtRecords([]string{strings.Repeat("x", 255)}))
+	assert.ErrorIs(t, validateTxtRecords([]string{strings.Repeat("x", 256)}), ErrInvalidTxtRecord)
+
+	// 5 strings with 255 bytes and the length byte each, 1280 bytes
+	txt := []string{}
+	for i := 0; i < 5; i++ {
+		txt = append(txt, strings.Repeat("x", 255))
+	}
+	assert.Nil(t, validateTxtRecords(append(txt, strings.Repeat("x", 19))))
+	assert.ErrorIs(t, validateTxtRecords(append(txt, strings.Repeat("x", 20))), ErrInvalidTxtRecord)
+}
@@ -0,0 +1,450 @@
+package mdns
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/enbility/ship-go/api"
+	"github.com/enbility/ship-go/logging"
+	"github.com/miekg/dns"
+)
+
+// the interval for reading the static file and querying the DNS server again
+var unicastRefreshInterval = time.Minute
+
+// the timeout for a single DNS query
+var unicastQueryTimeout = 5 * time.Second
+
+// the default DNS-SD domain used for unicast queries
+const unicastDefaultDomain = "local."
+
+// an entry of the static file
+type staticEntry struct {
+	// the instance name of the service
+	Name string `json:"name"`
+
+	// the host name of the service, used to look up the addresses if none are provided
+	Host string `json:"host"`
+
+	// the IP addresses of the service
+	Addresses []string `json:"addresses,omitempty"`
+
+	// the port of the websocket server
+	Port int `json:"port"`
+
+	// the TXT records in the format "key=value", SHIP 7.3.2
+	Txt []string `json:"txt"`
+}
+
+// a service resolved by the unicast provider
+type unicastEntry struct {
+	elements  map[string]string
+	name      string
+	host      string
+	addresses []net.IP
+	port      int
+	ttl       time.Duration
+}
+
+// Resolves SHIP services without multicast, from a static file and via unicast DNS-SD queries
+//
+// Used in networks which block multicast traffic. The services are reported like services
+// found via mDNS, but the local service can not be announced by this provider.
+type UnicastProvider struct {
+	// the path of the static file, empty if not used
+	staticFile string
+
+	// the address of the DNS server, empty if not used
+	dnsServer string
+
+	// the DNS-SD domain to browse
+	domain string
+
+	client *dns.Client
+
+	cb api.MdnsResolveTTLCB
+
+	// the services reported with the last refresh of each source, with the instance name as the key
+	staticEntries, dnsEntries map[string]unicastEntry
+
+	stopChan chan struct{}
+
+	mux        sync.Mutex
+	muxRefresh sync.Mutex // used for staticEntries and dnsEntries
+}
+
+// Create a provider resolving SHIP services from a static file and a DNS server
+//
+// Parameters:
+//   - staticFile: the path of a JSON file containing an array of services, or empty
+//     e.g. [{"name": "EVSE", "host": "evse.example.com", "addresses": ["192.168.1.2"], "port": 4712, "txt": ["txtvers=1", "id=...", "path=/ship/", "ski=...", "register=false"]}]
+//     The addresses of the host are looked up if none are provided
+//   - dnsServer: the address of the DNS server used for DNS-SD queries (RFC 6763), or empty; the port defaults to 53
+//   - domain: the DNS-SD domain containing the SHIP services, defaults to "local."
+//
+// The file is read and the DNS server queried on start and every minute.
+func NewUnicastProvider(staticFile, dnsServer, domain string) *UnicastProvider {
+	if dnsServer != "" {
+		if _, _, err := net.SplitHostPort(dnsServer); err != nil {
+			dnsServer = net.JoinHostPort(dnsServer, "53")
+		}
+	}
+
+	if domain == "" {
+		domain = unicastDefaultDomain
+	}
+
+	return &UnicastProvider{
+		staticFile:    staticFile,
+		dnsServer:     dnsServer,
+		domain:        dns.Fqdn(domain),
+		client:        &dns.Client{Timeout: unicastQueryTimeout},
+		staticEntries: make(map[string]unicastEntry),
+		dnsEntries:    make(map[string]unicastEntry),
+	}
+}
+
+var _ api.MdnsProviderInterface = (*UnicastProvider)(nil)
+var _ api.MdnsProviderTTLInterface = (*UnicastProvider)(nil)
+
+func (u *UnicastProvider) Start(autoReconnect bool, cb api.MdnsResolveCB) bool {
+	return u.StartWithTTL(autoReconnect, withoutTTL(cb))
+}
+
+func (u *UnicastProvider) StartWithTTL(autoReconnect bool, cb api.MdnsResolveTTLCB) bool {
+	u.mux.Lock()
+	defer u.mux.Unlock()
+
+	if u.staticFile == "" && u.dnsServer == "" {
+		return false
+	}
+
+	u.cb = cb
+
+	if u.stopChan == nil {
+		u.stopChan = make(chan struct{})
+		go u.run(u.stopChan)
+	}
+
+	return true
+}
+
+func (u *UnicastProvider) Shutdown() {
+	u.mux.Lock()
+	defer u.mux.Unlock()
+
+	if u.stopChan != nil {
+		close(u.stopChan)
+		u.stopChan = nil
+	}
+}
+
+// Announcing via unicast DNS is not supported, the service has to be configured in the DNS server
+func (u *UnicastProvider) Announce(serviceName string, port int, txt []string) error {
+	logging.Log().Debug("mdns: unicast - announcing the service is not supported")
+
+	return nil
+}
+
+func (u *UnicastProvider) Unannounce() {}
+
+// The service is not announced, so there are no name conflicts
+func (u *UnicastProvider) SetNameConflictCB(cb api.MdnsNameConflictCB) {}
+
+// refresh the services right away, as the DNS server may only be reachable via a new interface
+func (u *UnicastProvider) NetworkChanged(ifaces []net.Interface) {
+	go u.refresh()
+}
+
+// refresh the services periodically until stop is closed
+func (u *UnicastProvider) run(stop <-chan struct{}) {
+	ticker := time.NewTicker(unicastRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		u.refresh()
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// read the static file and query the DNS server, report the services and the removed services
+func (u *UnicastProvider) refresh() {
+	u.mux.Lock()
+	cb := u.cb
+	u.mux.Unlock()
+
+	if cb == nil {
+		return
+	}
+
+	u.muxRefresh.Lock()
+	defer u.muxRefresh.Unlock()
+
+	if u.staticFile != "" {
+		entries, err := readStaticEntries(u.staticFile)
+		if err != nil {
+			logging.Log().Debug("mdns: unicast - error reading static file:", err)
+		} else {
+			u.report(cb, u.staticEntries, entries)
+			u.staticEntries = entries
+		}
+	}
+
+	if u.dnsServer != "" {
+		entries, err := u.browse()
+		if err != nil {
+			// the reported services expire if the server is not reachable for too long
+			logging.Log().Debug("mdns: unicast - error querying DNS server:", err)
+		} else {
+			u.report(cb, u.dnsEntries, entries)
+			u.dnsEntries = entries
+		}
+	}
+}
+
+// report the current services of a source and the services and addresses no longer provided
+func (u *UnicastProvider) report(cb api.MdnsResolveTTLCB, previous, current map[string]unicastEntry) {
+	for name, entry := range previous {
+		currentEntry, ok := current[name]
+		if !ok {
+			cb(entry.elements, entry.name, entry.host, nil, entry.port, 0, true)
+			continue
+		}
+
+		var removed []net.IP
+		for _, address := range entry.addresses {
+			if !slices.ContainsFunc(currentEntry.addresses, address.Equal) {
+				removed = append(removed, address)
+			}
+		}
+		if len(removed) > 0 {
+			cb(entry.elements, entry.name, entry.host, removed, entry.port, 0, true)
+		}
+	}
+
+	for _, entry := range current {
+		cb(entry.elements, entry.name, entry.host, entry.addresses, entry.port, entry.ttl, false)
+	}
+}
+
+// read the services of a static file
+func readStaticEntries(path string) (map[string]unicastEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []staticEntry
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]unicastEntry)
+	for _, item := range items {
+		var addresses []net.IP
+		for _, value := range item.Addresses {
+			address := net.ParseIP(value)
+			if address == nil {
+				return nil, fmt.Errorf("invalid address of %s: %s", item.Name, value)
+			}
+			addresses = append(addresses, address)
+		}
+
+		if len(addresses) == 0 && item.Host != "" {
+			if addresses, err = net.LookupIP(item.Host); err != nil {
+				logging.Log().Debug("mdns: unicast - error looking up host", item.Host, err)
+				continue
+			}
+		}
+
+		if item.Name == "" || len(addresses) == 0 {
+			logging.Log().Debug("mdns: unicast - ignoring static entry without name or addresses:", item.Name)
+			continue
+		}
+
+		// static entries do not expire, they are removed from the file
+		entries[item.Name] = unicastEntry{
+			elements:  parseTxt(item.Txt),
+			name:      item.Name,
+			host:      item.Host,
+			addresses: addresses,
+			port:      item.Port,
+		}
+	}
+
+	return entries, nil
+}
+
+// browse the SHIP services of the domain: RFC 6763 4
+func (u *UnicastProvider) browse() (map[string]unicastEntry, error) {
+	serviceType := shipZeroConfServiceType + "." + u.domain
+
+	response, err := u.query(serviceType, dns.TypePTR)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]unicastEntry)
+	for _, answer := range response.Answer {
+		ptr, ok := answer.(*dns.PTR)
+		if !ok {
+			continue
+		}
+
+		entry, err := u.resolve(ptr.Ptr, serviceType, time.Duration(ptr.Hdr.Ttl)*time.Second)
+		if err != nil {
+			logging.Log().Debug("mdns: unicast - error resolving", ptr.Ptr, err)
+			continue
+		}
+
+		entries[entry.name] = entry
+	}
+
+	return entries, nil
+}
+
+// resolve the SRV, TXT and address records of a service instance: RFC 6763 5
+func (u *UnicastProvider) resolve(instance, serviceType string, ttl time.Duration) (unicastEntry, error) {
+	entry := unicastEntry{
+		name: instanceName(instance, serviceType),
+		ttl:  ttl,
+	}
+
+	response, err := u.query(instance, dns.TypeSRV)
+	if err != nil {
+		return entry, err
+	}
+
+	var target string
+	for _, answer := range response.Answer {
+		if srv, ok := answer.(*dns.SRV); ok {
+			target = srv.Target
+			entry.host = strings.TrimSuffix(srv.Target, ".")
+			entry.port = int(srv.Port)
+			entry.ttl = min(entry.ttl, time.Duration(srv.Hdr.Ttl)*time.Second)
+			break
+		}
+	}
+	if target == "" {
+		return entry, errors.New("no SRV record found")
+	}
+
+	response, err = u.query(instance, dns.TypeTXT)
+	if err != nil {
+		return entry, err
+	}
+
+	var txt []string
+	for _, answer := range response.Answer {
+		if record, ok := answer.(*dns.TXT); ok {
+			for _, item := range record.Txt {
+				txt = append(txt, unescapeDNSString(item))
+			}
+			entry.ttl = min(entry.ttl, time.Duration(record.Hdr.Ttl)*time.Second)
+		}
+	}
+	entry.elements = parseTxt(txt)
+
+	for _, recordType := range []uint16{dns.TypeA, dns.TypeAAAA} {
+		response, err = u.query(target, recordType)
+		if err != nil {
+			return entry, err
+		}
+
+		for _, answer := range response.Answer {
+			switch record := answer.(type) {
+			case *dns.A:
+				entry.addresses = append(entry.addresses, record.A)
+				entry.ttl = min(entry.ttl, time.Duration(record.Hdr.Ttl)*time.Second)
+			case *dns.AAAA:
+				entry.addresses = append(entry.addresses, record.AAAA)
+				entry.ttl = min(entry.ttl, time.Duration(record.Hdr.Ttl)*time.Second)
+			}
+		}
+	}
+	if len(entry.addresses) == 0 {
+		return entry, fmt.Errorf("no addresses found for %s", target)
+	}
+
+	// the services are only queried again with the next refresh
+	entry.ttl = max(entry.ttl, 2*unicastRefreshInterval)
+
+	return entry, nil
+}
+
+// query the DNS server, a missing name is no error
+func (u *UnicastProvider) query(name string, recordType uint16) (*dns.Msg, error) {
+	request := new(dns.Msg)
+	request.SetQuestion(dns.Fqdn(name), recordType)
+	// TXT records may not fit into a plain UDP response
+	request.SetEdns0(4096, false)
+
+	response, _, err := u.client.Exchange(request, u.dnsServer)
+	if err == nil && response.Truncated {
+		tcpClient := &dns.Client{Net: "tcp", Timeout: u.client.Timeout}
+		response, _, err = tcpClient.Exchange(request, u.dnsServer)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if response.Rcode != dns.RcodeSuccess && response.Rcode != dns.RcodeNameError {
+		return nil, fmt.Errorf("query %s %s failed: %s", dns.TypeToString[recordType], name, dns.RcodeToString[response.Rcode])
+	}
+
+	return response, nil
+}
+
+// returns the unescaped instance name of a service instance domain name
+func instanceName(instance, serviceType string) string {
+	name := strings.TrimSuffix(dns.Fqdn(instance), "."+dns.Fqdn(serviceType))
+	if len(name) == len(dns.Fqdn(instance)) {
+		if labels := dns.SplitDomainName(instance); len(labels) > 0 {
+			name = labels[0]
+		}
+	}
+
+	return unescapeDNSString(name)
+}
+
+// unescape a string in the presentation format: RFC 1035 5.1
+func unescapeDNSString(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+
+	var result []byte
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 >= len(s) {
+			result = append(result, s[i])
+			continue
+		}
+
+		// \DDD is the decimal value of a byte
+		if i+3 < len(s) {
+			if value, err := strconv.ParseUint(s[i+1:i+4], 10, 8); err == nil {
+				result = append(result, byte(value))
+				i += 3
+				continue
+			}
+		}
+
+		result = append(result, s[i+1])
+		i++
+	}
+
+	return string(result)
+}
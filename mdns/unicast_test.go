@@ -0,0 +1,260 @@
+package mdns
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestUnicastSuite(t *testing.T) {
+	suite.Run(t, new(UnicastSuite))
+}
+
+type unicastReport struct {
+	elements  map[string]string
+	name      string
+	host      string
+	addresses []net.IP
+	port      int
+	ttl       time.Duration
+	remove    bool
+}
+
+type UnicastSuite struct {
+	suite.Suite
+
+	// the records of the DNS stand-in
+	records []dns.RR
+	server  *dns.Server
+
+	reports []unicastReport
+
+	mux sync.Mutex
+}
+
+func (u *UnicastSuite) BeforeTest(suiteName, testName string) {
+	u.records = []dns.RR{
+		u.record("_ship._tcp.example.com. 300 IN PTR EVSE\\ 1._ship._tcp.example.com."),
+		u.record("EVSE\\ 1._ship._tcp.example.com. 120 IN SRV 0 0 4712 evse.example.com."),
+		u.record(`EVSE\ 1._ship._tcp.example.com. 300 IN TXT "txtvers=1" "id=EVSE-1" "path=/ship/" "ski=1234" "register=false" "brand=M\195\188ller"`),
+		u.record("evse.example.com. 300 IN A 192.168.1.2"),
+		u.record("evse.example.com. 300 IN AAAA fd00::2"),
+	}
+
+	u.reports = nil
+
+	packetConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		u.T().Fatal(err)
+	}
+
+	started := make(chan struct{})
+	u.server = &dns.Server{
+		PacketConn:        packetConn,
+		Handler:           dns.HandlerFunc(u.handleQuery),
+		NotifyStartedFunc: func() { close(started) },
+	}
+	go func() {
+		_ = u.server.ActivateAndServe()
+	}()
+	<-started
+}
+
+func (u *UnicastSuite) AfterTest(suiteName, testName string) {
+	_ = u.server.Shutdown()
+}
+
+func (u *UnicastSuite) record(s string) dns.RR {
+	rr, err := dns.NewRR(s)
+	if err != nil {
+		u.T().Fatal(err)
+	}
+
+	return rr
+}
+
+// answer a query with the matching records of the stand-in
+func (u *UnicastSuite) handleQuery(w dns.ResponseWriter, request *dns.Msg) {
+	response := new(dns.Msg)
+	response.SetReply(request)
+
+	u.mux.Lock()
+	for _, rr := range u.records {
+		question := request.Question[0]
+		if dns.CanonicalName(rr.Header().Name) == dns.CanonicalName(question.Name) && rr.Header().Rrtype == question.Qtype {
+			response.Answer = append(response.Answer, rr)
+		}
+	}
+	u.mux.Unlock()
+
+	_ = w.WriteMsg(response)
+}
+
+func (u *UnicastSuite) cb(elements map[string]string, name, host string, addresses []net.IP, port int, ttl time.Duration, remove bool) {
+	u.mux.Lock()
+	defer u.mux.Unlock()
+
+	u.reports = append(u.reports, unicastReport{elements, name, host, addresses, port, ttl, remove})
+}
+
+func (u *UnicastSuite) Test_Unicast() {
+	sut := NewUnicastProvider("", u.server.PacketConn.LocalAddr().String(), "example.com")
+
+	entries, err := sut.browse()
+	assert.Nil(u.T(), err)
+	if assert.Equal(u.T(), 1, len(entries)) {
+		entry := entries["EVSE 1"]
+		assert.Equal(u.T(), "EVSE 1", entry.name)
+		assert.Equal(u.T(), "evse.example.com", entry.host)
+		assert.Equal(u.T(), 4712, entry.port)
+		assert.Equal(u.T(), "1234", entry.elements["ski"])
+		assert.Equal(u.T(), "Müller", entry.elements["brand"])
+		assert.Equal(u.T(), 2, len(entry.addresses))
+		assert.True(u.T(), entry.addresses[0].Equal(net.ParseIP("192.168.1.2")))
+		assert.True(u.T(), entry.addresses[1].Equal(net.ParseIP("fd00::2")))
+		// the lowest TTL, but at least until the next refresh
+		assert.Equal(u.T(), 2*unicastRefreshInterval, entry.ttl)
+	}
+
+	// the provider reports the services like the mDNS providers
+	assert.True(u.T(), sut.StartWithTTL(true, u.cb))
+	assert.Eventually(u.T(), func() bool {
+		u.mux.Lock()
+		defer u.mux.Unlock()
+		return len(u.reports) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// the address and the service are removed
+	u.mux.Lock()
+	u.records = u.records[:len(u.records)-1]
+	u.reports = nil
+	u.mux.Unlock()
+	sut.refresh()
+
+	u.mux.Lock()
+	if assert.Equal(u.T(), 2, len(u.reports)) {
+		assert.True(u.T(), u.reports[0].remove)
+		assert.Equal(u.T(), 1, len(u.reports[0].addresses))
+		assert.True(u.T(), u.reports[0].addresses[0].Equal(net.ParseIP("fd00::2")))
+		assert.False(u.T(), u.reports[1].remove)
+	}
+	u.records = nil
+	u.reports = nil
+	u.mux.Unlock()
+	sut.refresh()
+
+	u.mux.Lock()
+	if assert.Equal(u.T(), 1, len(u.reports)) {
+		assert.True(u.T(), u.reports[0].remove)
+		assert.Nil(u.T(), u.reports[0].addresses)
+		assert.Equal(u.T(), "EVSE 1", u.reports[0].name)
+	}
+	u.mux.Unlock()
+
+	sut.Shutdown()
+	sut.Shutdown()
+}
+
+func (u *UnicastSuite) Test_Unicast_ServerError() {
+	packetConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(u.T(), err)
+	address := packetConn.LocalAddr().String()
+	_ = packetConn.Close()
+
+	unicastQueryTimeout = 100 * time.Millisecond
+	defer func() { unicastQueryTimeout = 5 * time.Second }()
+
+	sut := NewUnicastProvider("", address, "")
+	assert.Equal(u.T(), "local.", sut.domain)
+
+	_, err = sut.browse()
+	assert.NotNil(u.T(), err)
+
+	// the known services are not removed if the server is not reachable
+	sut.cb = u.cb
+	sut.dnsEntries["test"] = unicastEntry{name: "test"}
+	sut.refresh()
+	assert.Equal(u.T(), 0, len(u.reports))
+
+	sut = NewUnicastProvider("", "127.0.0.1", "")
+	assert.Equal(u.T(), "127.0.0.1:53", sut.dnsServer)
+
+	sut = NewUnicastProvider("", "", "")
+	assert.False(u.T(), sut.StartWithTTL(true, u.cb))
+}
+
+func (u *UnicastSuite) Test_Static() {
+	path := filepath.Join(u.T().TempDir(), "services.json")
+	err := os.WriteFile(path, []byte(`[
+		{"name": "EVSE", "host": "evse.example.com", "addresses": ["192.168.1.2"], "port": 4712,
+		 "txt": ["txtvers=1", "id=EVSE", "path=/ship/", "ski=1234", "register=false"]},
+		{"name": "", "addresses": ["192.168.1.3"]},
+		{"name": "no address"}
+	]`), 0600)
+	assert.Nil(u.T(), err)
+
+	sut := NewUnicastProvider(path, "", "")
+	sut.cb = u.cb
+	sut.refresh()
+
+	if assert.Equal(u.T(), 1, len(u.reports)) {
+		assert.Equal(u.T(), "EVSE", u.reports[0].name)
+		assert.Equal(u.T(), "1234", u.reports[0].elements["ski"])
+		assert.Equal(u.T(), 4712, u.reports[0].port)
+		assert.Equal(u.T(), time.Duration(0), u.reports[0].ttl)
+		assert.False(u.T(), u.reports[0].remove)
+	}
+
+	// the entry is removed from the file
+	err = os.WriteFile(path, []byte(`[]`), 0600)
+	assert.Nil(u.T(), err)
+	u.reports = nil
+	sut.refresh()
+
+	if assert.Equal(u.T(), 1, len(u.reports)) {
+		assert.Equal(u.T(), "EVSE", u.reports[0].name)
+		assert.True(u.T(), u.reports[0].remove)
+	}
+
+	// an invalid file keeps the known entries
+	err = os.WriteFile(path, []byte(`[{"name": "EVSE", "addresses": ["invalid"]}]`), 0600)
+	assert.Nil(u.T(), err)
+	_, err = readStaticEntries(path)
+	assert.NotNil(u.T(), err)
+
+	_, err = readStaticEntries(filepath.Join(u.T().TempDir(), "missing.json"))
+	assert.NotNil(u.T(), err)
+}
+
+func (u *UnicastSuite) Test_InstanceName() {
+	assert.Equal(u.T(), "EVSE 1", instanceName("EVSE\\ 1._ship._tcp.example.com.", "_ship._tcp.example.com."))
+	assert.Equal(u.T(), "EVSE.1", instanceName("EVSE\\.1._ship._tcp.example.com", "_ship._tcp.example.com."))
+	assert.Equal(u.T(), "other", instanceName("other.example.org.", "_ship._tcp.example.com."))
+
+	assert.Equal(u.T(), "Müller \"1\"", unescapeDNSString("M\\195\\188ller \\\"1\\\""))
+	assert.Equal(u.T(), "end\\", unescapeDNSString("end\\"))
+}
+
+func (u *UnicastSuite) Test_NetworkChanged() {
+	path := filepath.Join(u.T().TempDir(), "services.json")
+	err := os.WriteFile(path, []byte(`[{"name": "EVSE", "addresses": ["192.168.1.2"], "port": 4712}]`), 0600)
+	assert.Nil(u.T(), err)
+
+	sut := NewUnicastProvider(path, "", "")
+	sut.cb = u.cb
+
+	// the services are refreshed right away
+	sut.NetworkChanged(nil)
+	assert.Eventually(u.T(), func() bool {
+		u.mux.Lock()
+		defer u.mux.Unlock()
+		return len(u.reports) == 1
+	}, time.Second, 10*time.Millisecond)
+}
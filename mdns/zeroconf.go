@@ -2,35 +2,71 @@ package mdns
 
 import (
 	"context"
+	"maps"
 	"net"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/enbility/ship-go/api"
 	"github.com/enbility/ship-go/logging"
 	"github.com/enbility/zeroconf/v2"
 )
 
+// the TTL of the announced records in seconds: SHIP 7
+const zeroconfTTL = 120
+
 type ZeroconfProvider struct {
 	ifaces []net.Interface
 
 	zc *zeroconf.Server
 
-	ctx    context.Context
+	// the announced service, used to register it again if the network changed
+	announced   bool
+	serviceName string
+	port        int
+	txt         []string
+
+	cb api.MdnsResolveTTLCB
+
+	nameConflictCB api.MdnsNameConflictCB
+
 	cancel context.CancelFunc
 
+	// the addresses last reported for each service instance
+	addresses map[string][]net.IP
+
+	// the service last reported for each service instance
+	services map[string]*zeroconf.ServiceEntry
+
 	mux sync.Mutex
 }
 
 func NewZeroconfProvider(ifaces []net.Interface) *ZeroconfProvider {
 	return &ZeroconfProvider{
-		ifaces: ifaces,
+		ifaces:    ifaces,
+		addresses: make(map[string][]net.IP),
+		services:  make(map[string]*zeroconf.ServiceEntry),
 	}
 }
 
 var _ api.MdnsProviderInterface = (*ZeroconfProvider)(nil)
+var _ api.MdnsProviderTTLInterface = (*ZeroconfProvider)(nil)
 
 func (z *ZeroconfProvider) Start(autoReconnect bool, cb api.MdnsResolveCB) bool {
-	go z.chanListener(cb)
+	return z.StartWithTTL(autoReconnect, withoutTTL(cb))
+}
+
+func (z *ZeroconfProvider) StartWithTTL(autoReconnect bool, cb api.MdnsResolveTTLCB) bool {
+	z.mux.Lock()
+	z.cb = cb
+	// for Zeroconf we need a context
+	ctx, cancel := context.WithCancel(context.Background())
+	z.cancel = cancel
+	z.mux.Unlock()
+
+	go z.chanListener(ctx, cb)
 
 	return true
 }
@@ -43,22 +79,53 @@ func (z *ZeroconfProvider) Shutdown() {
 
 	if z.cancel != nil {
 		z.cancel()
+		z.cancel = nil
 	}
 }
 
+func (z *ZeroconfProvider) SetNameConflictCB(cb api.MdnsNameConflictCB) {
+	z.mux.Lock()
+	defer z.mux.Unlock()
+
+	z.nameConflictCB = cb
+}
+
 func (z *ZeroconfProvider) Announce(serviceName string, port int, txt []string) error {
 	logging.Log().Debug("mdns: using zeroconf")
 
+	z.mux.Lock()
+	defer z.mux.Unlock()
+
+	// the server is registered again, as changing the TXT records of a
+	// running server races with the server answering queries
+	if z.zc != nil {
+		z.zc.Shutdown()
+		z.zc = nil
+	}
+
+	z.announced = false
+
+	if err := z.register(serviceName, port, txt); err != nil {
+		return err
+	}
+
+	z.announced = true
+	z.serviceName = serviceName
+	z.port = port
+	z.txt = txt
+
+	return nil
+}
+
+// register the service on the interfaces, z.mux has to be locked
+func (z *ZeroconfProvider) register(serviceName string, port int, txt []string) error {
 	// use Zeroconf library if avahi is not available
 	// Set TTL to 2 minutes as defined in SHIP chapter 7
-	mDNSServer, err := zeroconf.Register(serviceName, shipZeroConfServiceType, shipZeroConfDomain, port, txt, z.ifaces, zeroconf.TTL(120))
+	mDNSServer, err := zeroconf.Register(serviceName, shipZeroConfServiceType, shipZeroConfDomain, port, txt, z.ifaces, zeroconf.TTL(zeroconfTTL))
 	if err != nil {
 		return err
 	}
 
-	z.mux.Lock()
-	defer z.mux.Unlock()
-
 	z.zc = mDNSServer
 
 	return nil
@@ -68,6 +135,8 @@ func (z *ZeroconfProvider) Unannounce() {
 	z.mux.Lock()
 	defer z.mux.Unlock()
 
+	z.announced = false
+
 	if z.zc == nil {
 		return
 	}
@@ -76,45 +145,180 @@ func (z *ZeroconfProvider) Unannounce() {
 	z.zc = nil
 }
 
-func (z *ZeroconfProvider) chanListener(cb api.MdnsResolveCB) {
-	zcEntries := make(chan *zeroconf.ServiceEntry)
-	zcRemoved := make(chan *zeroconf.ServiceEntry)
+// register the service and browse again on the current interfaces,
+// as the server and the browser only use the interfaces available when they started
+func (z *ZeroconfProvider) NetworkChanged(ifaces []net.Interface) {
+	z.mux.Lock()
+
+	z.ifaces = ifaces
+
+	if z.announced {
+		if z.zc != nil {
+			z.zc.Shutdown()
+			z.zc = nil
+		}
+
+		// without any usable interface the service is registered with the next change
+		if err := z.register(z.serviceName, z.port, z.txt); err != nil {
+			logging.Log().Debug("mdns: zeroconf - error registering service:", err)
+		}
+	}
+
+	// the browser is not running
+	cb := z.cb
+	if z.cancel == nil || cb == nil {
+		z.mux.Unlock()
+		return
+	}
+
+	z.cancel()
+	ctx, cancel := context.WithCancel(context.Background())
+	z.cancel = cancel
+
+	// the services expire unless they are found again by the new browser
+	services := z.services
+	z.services = make(map[string]*zeroconf.ServiceEntry)
+	z.mux.Unlock()
+
+	reportServices(services, cb)
+
+	go z.chanListener(ctx, cb)
+}
 
+// report the known services again, as zeroconf only reports changes
+func (z *ZeroconfProvider) refreshServices(cb api.MdnsResolveTTLCB) {
 	z.mux.Lock()
-	// for Zeroconf we need a context
-	z.ctx, z.cancel = context.WithCancel(context.Background())
+	services := maps.Clone(z.services)
 	z.mux.Unlock()
 
+	reportServices(services, cb)
+}
+
+// report services using the TTL of the announced records
+func reportServices(services map[string]*zeroconf.ServiceEntry, cb api.MdnsResolveTTLCB) {
+	for _, service := range services {
+		addresses := append(slices.Clone(service.AddrIPv4), service.AddrIPv6...)
+		cb(parseTxt(service.Text), service.Instance, service.HostName, addresses, service.Port, zeroconfTTL*time.Second, false)
+	}
+}
+
+func (z *ZeroconfProvider) chanListener(ctx context.Context, cb api.MdnsResolveTTLCB) {
+	zcEntries := make(chan *zeroconf.ServiceEntry)
+	zcRemoved := make(chan *zeroconf.ServiceEntry)
+
 	go func() {
-		_ = zeroconf.Browse(z.ctx, shipZeroConfServiceType, shipZeroConfDomain, zcEntries, zcRemoved)
+		_ = zeroconf.Browse(ctx, shipZeroConfServiceType, shipZeroConfDomain, zcEntries, zcRemoved)
 	}()
 
+	ticker := time.NewTicker(providerRefreshInterval)
+	defer ticker.Stop()
+
 	for {
 		select {
-		case <-z.ctx.Done():
+		case <-ctx.Done():
 			return
+		case <-ticker.C:
+			z.refreshServices(cb)
 		case service := <-zcRemoved:
-			// Zeroconf has issues with merging mDNS data and sometimes reports incomplete records
-			if service == nil || len(service.Text) == 0 {
-				continue
-			}
+			z.processRemovedEntry(service, cb)
+		case service := <-zcEntries:
+			z.processAddedEntry(service, cb)
+		}
+	}
+}
 
-			elements := parseTxt(service.Text)
+// process a service which expired or was removed
+func (z *ZeroconfProvider) processRemovedEntry(service *zeroconf.ServiceEntry, cb api.MdnsResolveTTLCB) {
+	// Zeroconf has issues with merging mDNS data and sometimes reports incomplete records
+	if service == nil || len(service.Text) == 0 {
+		return
+	}
 
-			addresses := service.AddrIPv4
-			cb(elements, service.Instance, service.HostName, addresses, service.Port, true)
+	elements := parseTxt(service.Text)
 
-		case service := <-zcEntries:
-			// Zeroconf has issues with merging mDNS data and sometimes reports incomplete records
-			if service == nil || len(service.Text) == 0 {
-				continue
-			}
+	// the service expired or was removed, so all of its addresses are gone
+	z.mux.Lock()
+	delete(z.addresses, service.Instance)
+	delete(z.services, service.Instance)
+	z.mux.Unlock()
 
-			elements := parseTxt(service.Text)
+	cb(elements, service.Instance, service.HostName, nil, service.Port, 0, true)
+}
 
-			addresses := service.AddrIPv4
-			addresses = append(addresses, service.AddrIPv6...)
-			cb(elements, service.Instance, service.HostName, addresses, service.Port, false)
+// process a new service or a service with changed records
+func (z *ZeroconfProvider) processAddedEntry(service *zeroconf.ServiceEntry, cb api.MdnsResolveTTLCB) {
+	// Zeroconf has issues with merging mDNS data and sometimes reports incomplete records
+	if service == nil || len(service.Text) == 0 {
+		return
+	}
+
+	elements := parseTxt(service.Text)
+
+	addresses := service.AddrIPv4
+	addresses = append(addresses, service.AddrIPv6...)
+
+	z.mux.Lock()
+	z.services[service.Instance] = service
+	z.mux.Unlock()
+
+	// zeroconf reports an entry again with all current addresses if they changed
+	if removed := z.updateAddresses(service.Instance, addresses); len(removed) > 0 {
+		cb(elements, service.Instance, service.HostName, removed, service.Port, 0, true)
+	}
+
+	// zeroconf refreshes known entries without reporting them, they are reported again by
+	// refreshServices. The expiry of the entry is updated by zeroconf concurrently, so the
+	// TTL of the announced records is used instead
+	cb(elements, service.Instance, service.HostName, addresses, service.Port, zeroconfTTL*time.Second, false)
+
+	z.processNameConflict(service.Instance, elements)
+}
+
+// report a name conflict if another service uses the announced service name
+//
+// Zeroconf does not probe the name before announcing it, so both services detect the conflict.
+// Like the tie-break of simultaneous probes, the service with the lexicographically later SKI
+// keeps the name: RFC 6762 8.2
+func (z *ZeroconfProvider) processNameConflict(instance string, elements map[string]string) {
+	z.mux.Lock()
+	if !z.announced || !strings.EqualFold(instance, z.serviceName) {
+		z.mux.Unlock()
+		return
+	}
+
+	// the own service, a service using the same SKI or a service losing the tie-break
+	ski := parseTxt(z.txt)["ski"]
+	if elements["ski"] <= ski {
+		z.mux.Unlock()
+		return
+	}
+
+	serviceName := z.serviceName
+	cb := z.nameConflictCB
+	z.mux.Unlock()
+
+	logging.Log().Debug("mdns: zeroconf - name conflict for service:", serviceName)
+
+	if cb != nil {
+		cb(serviceName)
+	}
+}
+
+// store the current addresses of a service instance
+//
+// returns the previously reported addresses which are no longer provided
+func (z *ZeroconfProvider) updateAddresses(instance string, addresses []net.IP) []net.IP {
+	z.mux.Lock()
+	defer z.mux.Unlock()
+
+	var removed []net.IP
+	for _, previous := range z.addresses[instance] {
+		if !slices.ContainsFunc(addresses, previous.Equal) {
+			removed = append(removed, previous)
 		}
 	}
+
+	z.addresses[instance] = addresses
+
+	return removed
 }
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/enbility/zeroconf/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 )
@@ -120,3 +121,119 @@ func (z *ZeroconfSuite) Test_ZeroConf() {
 
 	z.sut.Unannounce()
 }
+
+func (z *ZeroconfSuite) Test_UpdateAddresses() {
+	address1 := net.ParseIP("192.168.1.10")
+	address2 := net.ParseIP("192.168.1.11")
+
+	removed := z.sut.updateAddresses("test", []net.IP{address1, address2})
+	assert.Empty(z.T(), removed)
+
+	removed = z.sut.updateAddresses("test", []net.IP{address2})
+	assert.Equal(z.T(), []net.IP{address1}, removed)
+
+	removed = z.sut.updateAddresses("other", []net.IP{address1})
+	assert.Empty(z.T(), removed)
+}
+
+func (z *ZeroconfSuite) Test_Announce_Update() {
+	err := z.sut.Announce("dummytest", 4289, []string{"more=more"})
+	assert.Nil(z.T(), err)
+	server := z.sut.zc
+
+	// the previous server is replaced
+	err = z.sut.Announce("dummytest", 4289, []string{"more=less"})
+	assert.Nil(z.T(), err)
+	assert.NotSame(z.T(), server, z.sut.zc)
+	assert.Equal(z.T(), []string{"more=less"}, z.sut.txt)
+
+	err = z.sut.Announce("othertest", 4289, []string{"more=less"})
+	assert.Nil(z.T(), err)
+	assert.Equal(z.T(), "othertest", z.sut.serviceName)
+
+	// the failed announcement is not registered again on network changes
+	err = z.sut.Announce("", 4289, []string{"more=less"})
+	assert.NotNil(z.T(), err)
+	assert.False(z.T(), z.sut.announced)
+	assert.Nil(z.T(), z.sut.zc)
+
+	z.sut.Unannounce()
+}
+
+func (z *ZeroconfSuite) Test_NetworkChanged() {
+	// neither announced nor browsing
+	z.sut.NetworkChanged(nil)
+	assert.Nil(z.T(), z.sut.zc)
+
+	var reports []mDNSEntry
+	var ttls []time.Duration
+	cb := func(elements map[string]string, name, host string, addresses []net.IP, port int, ttl time.Duration, remove bool) {
+		z.mux.Lock()
+		defer z.mux.Unlock()
+
+		if name == "known" {
+			reports = append(reports, mDNSEntry{elements: elements, name: name, host: host, addresses: addresses, port: port})
+			ttls = append(ttls, ttl)
+		}
+	}
+
+	assert.True(z.T(), z.sut.StartWithTTL(false, cb))
+	err := z.sut.Announce("dummytest", 4289, []string{"more=more"})
+	assert.Nil(z.T(), err)
+	server := z.sut.zc
+
+	z.sut.mux.Lock()
+	z.sut.services["known"] = &zeroconf.ServiceEntry{
+		ServiceRecord: zeroconf.ServiceRecord{Instance: "known"},
+		HostName:      "known.local.",
+		Port:          4711,
+		Text:          []string{"ski=1234"},
+		AddrIPv4:      []net.IP{net.ParseIP("192.168.1.2")},
+	}
+	z.sut.mux.Unlock()
+
+	// the service is registered again and the known services expire unless found again
+	z.sut.NetworkChanged(nil)
+	assert.NotSame(z.T(), server, z.sut.zc)
+	assert.NotNil(z.T(), z.sut.zc)
+	assert.Equal(z.T(), []string{"more=more"}, z.sut.txt)
+
+	z.mux.Lock()
+	if assert.Equal(z.T(), 1, len(reports)) {
+		assert.Equal(z.T(), "1234", reports[0].elements["ski"])
+		assert.Equal(z.T(), 4711, reports[0].port)
+		assert.Equal(z.T(), zeroconfTTL*time.Second, ttls[0])
+	}
+	z.mux.Unlock()
+
+	z.sut.Unannounce()
+	z.sut.NetworkChanged(nil)
+	assert.Nil(z.T(), z.sut.zc)
+}
+
+func (z *ZeroconfSuite) Test_NameConflict() {
+	var conflicts []string
+	z.sut.SetNameConflictCB(func(serviceName string) {
+		conflicts = append(conflicts, serviceName)
+	})
+
+	// not announced
+	z.sut.processNameConflict("dummytest", map[string]string{"ski": "5678"})
+	assert.Equal(z.T(), 0, len(conflicts))
+
+	z.sut.announced = true
+	z.sut.serviceName = "dummytest"
+	z.sut.txt = []string{"ski=1234"}
+
+	// another name, the own service and a service losing the tie-break
+	z.sut.processNameConflict("othertest", map[string]string{"ski": "5678"})
+	z.sut.processNameConflict("dummytest", map[string]string{"ski": "1234"})
+	z.sut.processNameConflict("dummytest", map[string]string{"ski": "0123"})
+	assert.Equal(z.T(), 0, len(conflicts))
+
+	// the names are compared case insensitive
+	z.sut.processNameConflict("DummyTest", map[string]string{"ski": "5678"})
+	assert.Equal(z.T(), []string{"dummytest"}, conflicts)
+
+	z.sut.announced = false
+}
@@ -281,6 +281,62 @@ func (_c *HubInterface_Shutdown_Call) RunAndReturn(run func()) *HubInterface_Shu
 	return _c
 }
 
+// SpineBufferStatisticForSKI provides a mock function with given fields: ski
+func (_m *HubInterface) SpineBufferStatisticForSKI(ski string) (api.SpineBufferStatistic, error) {
+	ret := _m.Called(ski)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SpineBufferStatisticForSKI")
+	}
+
+	var r0 api.SpineBufferStatistic
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (api.SpineBufferStatistic, error)); ok {
+		return rf(ski)
+	}
+	if rf, ok := ret.Get(0).(func(string) api.SpineBufferStatistic); ok {
+		r0 = rf(ski)
+	} else {
+		r0 = ret.Get(0).(api.SpineBufferStatistic)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(ski)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// HubInterface_SpineBufferStatisticForSKI_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SpineBufferStatisticForSKI'
+type HubInterface_SpineBufferStatisticForSKI_Call struct {
+	*mock.Call
+}
+
+// SpineBufferStatisticForSKI is a helper method to define mock.On call
+//   - ski string
+func (_e *HubInterface_Expecter) SpineBufferStatisticForSKI(ski interface{}) *HubInterface_SpineBufferStatisticForSKI_Call {
+	return &HubInterface_SpineBufferStatisticForSKI_Call{Call: _e.mock.On("SpineBufferStatisticForSKI", ski)}
+}
+
+func (_c *HubInterface_SpineBufferStatisticForSKI_Call) Run(run func(ski string)) *HubInterface_SpineBufferStatisticForSKI_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *HubInterface_SpineBufferStatisticForSKI_Call) Return(_a0 api.SpineBufferStatistic, _a1 error) *HubInterface_SpineBufferStatisticForSKI_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *HubInterface_SpineBufferStatisticForSKI_Call) RunAndReturn(run func(string) (api.SpineBufferStatistic, error)) *HubInterface_SpineBufferStatisticForSKI_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Start provides a mock function with given fields:
 func (_m *HubInterface) Start() {
 	_m.Called()
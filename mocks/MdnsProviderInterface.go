@@ -5,6 +5,8 @@ package mocks
 import (
 	api "github.com/enbility/ship-go/api"
 	mock "github.com/stretchr/testify/mock"
+
+	net "net"
 )
 
 // MdnsProviderInterface is an autogenerated mock type for the MdnsProviderInterface type
@@ -68,6 +70,72 @@ func (_c *MdnsProviderInterface_Announce_Call) RunAndReturn(run func(string, int
 	return _c
 }
 
+// NetworkChanged provides a mock function with given fields: ifaces
+func (_m *MdnsProviderInterface) NetworkChanged(ifaces []net.Interface) {
+	_m.Called(ifaces)
+}
+
+// MdnsProviderInterface_NetworkChanged_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'NetworkChanged'
+type MdnsProviderInterface_NetworkChanged_Call struct {
+	*mock.Call
+}
+
+// NetworkChanged is a helper method to define mock.On call
+//   - ifaces []net.Interface
+func (_e *MdnsProviderInterface_Expecter) NetworkChanged(ifaces interface{}) *MdnsProviderInterface_NetworkChanged_Call {
+	return &MdnsProviderInterface_NetworkChanged_Call{Call: _e.mock.On("NetworkChanged", ifaces)}
+}
+
+func (_c *MdnsProviderInterface_NetworkChanged_Call) Run(run func(ifaces []net.Interface)) *MdnsProviderInterface_NetworkChanged_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]net.Interface))
+	})
+	return _c
+}
+
+func (_c *MdnsProviderInterface_NetworkChanged_Call) Return() *MdnsProviderInterface_NetworkChanged_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MdnsProviderInterface_NetworkChanged_Call) RunAndReturn(run func([]net.Interface)) *MdnsProviderInterface_NetworkChanged_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetNameConflictCB provides a mock function with given fields: cb
+func (_m *MdnsProviderInterface) SetNameConflictCB(cb api.MdnsNameConflictCB) {
+	_m.Called(cb)
+}
+
+// MdnsProviderInterface_SetNameConflictCB_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetNameConflictCB'
+type MdnsProviderInterface_SetNameConflictCB_Call struct {
+	*mock.Call
+}
+
+// SetNameConflictCB is a helper method to define mock.On call
+//   - cb api.MdnsNameConflictCB
+func (_e *MdnsProviderInterface_Expecter) SetNameConflictCB(cb interface{}) *MdnsProviderInterface_SetNameConflictCB_Call {
+	return &MdnsProviderInterface_SetNameConflictCB_Call{Call: _e.mock.On("SetNameConflictCB", cb)}
+}
+
+func (_c *MdnsProviderInterface_SetNameConflictCB_Call) Run(run func(cb api.MdnsNameConflictCB)) *MdnsProviderInterface_SetNameConflictCB_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(api.MdnsNameConflictCB))
+	})
+	return _c
+}
+
+func (_c *MdnsProviderInterface_SetNameConflictCB_Call) Return() *MdnsProviderInterface_SetNameConflictCB_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MdnsProviderInterface_SetNameConflictCB_Call) RunAndReturn(run func(api.MdnsNameConflictCB)) *MdnsProviderInterface_SetNameConflictCB_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Shutdown provides a mock function with given fields:
 func (_m *MdnsProviderInterface) Shutdown() {
 	_m.Called()
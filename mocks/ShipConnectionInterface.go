@@ -268,6 +268,51 @@ func (_c *ShipConnectionInterface_ShipHandshakeState_Call) RunAndReturn(run func
 	return _c
 }
 
+// SpineBufferStatistic provides a mock function with given fields:
+func (_m *ShipConnectionInterface) SpineBufferStatistic() api.SpineBufferStatistic {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for SpineBufferStatistic")
+	}
+
+	var r0 api.SpineBufferStatistic
+	if rf, ok := ret.Get(0).(func() api.SpineBufferStatistic); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(api.SpineBufferStatistic)
+	}
+
+	return r0
+}
+
+// ShipConnectionInterface_SpineBufferStatistic_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SpineBufferStatistic'
+type ShipConnectionInterface_SpineBufferStatistic_Call struct {
+	*mock.Call
+}
+
+// SpineBufferStatistic is a helper method to define mock.On call
+func (_e *ShipConnectionInterface_Expecter) SpineBufferStatistic() *ShipConnectionInterface_SpineBufferStatistic_Call {
+	return &ShipConnectionInterface_SpineBufferStatistic_Call{Call: _e.mock.On("SpineBufferStatistic")}
+}
+
+func (_c *ShipConnectionInterface_SpineBufferStatistic_Call) Run(run func()) *ShipConnectionInterface_SpineBufferStatistic_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *ShipConnectionInterface_SpineBufferStatistic_Call) Return(_a0 api.SpineBufferStatistic) *ShipConnectionInterface_SpineBufferStatistic_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *ShipConnectionInterface_SpineBufferStatistic_Call) RunAndReturn(run func() api.SpineBufferStatistic) *ShipConnectionInterface_SpineBufferStatistic_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewShipConnectionInterface creates a new instance of ShipConnectionInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewShipConnectionInterface(t interface {
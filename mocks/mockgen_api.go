@@ -1,9 +1,9 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/enbility/ship-go/api (interfaces: MdnsInterface,HubReaderInterface)
+// Source: github.com/enbility/ship-go/api (interfaces: MdnsInterface,HubReaderInterface,HubPublicKeyPinReaderInterface)
 //
 // Generated by this command:
 //
-//	mockgen -destination=../mocks/mockgen_api.go -package=mocks github.com/enbility/ship-go/api MdnsInterface,HubReaderInterface
+//	mockgen -destination=../mocks/mockgen_api.go -package=mocks github.com/enbility/ship-go/api MdnsInterface,HubReaderInterface,HubPublicKeyPinReaderInterface
 //
 
 // Package mocks is a generated GoMock package.
@@ -239,3 +239,38 @@ func (mr *MockHubReaderInterfaceMockRecorder) VisibleRemoteServicesUpdated(arg0
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VisibleRemoteServicesUpdated", reflect.TypeOf((*MockHubReaderInterface)(nil).VisibleRemoteServicesUpdated), arg0)
 }
+
+// MockHubPublicKeyPinReaderInterface is a mock of HubPublicKeyPinReaderInterface interface.
+type MockHubPublicKeyPinReaderInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockHubPublicKeyPinReaderInterfaceMockRecorder
+}
+
+// MockHubPublicKeyPinReaderInterfaceMockRecorder is the mock recorder for MockHubPublicKeyPinReaderInterface.
+type MockHubPublicKeyPinReaderInterfaceMockRecorder struct {
+	mock *MockHubPublicKeyPinReaderInterface
+}
+
+// NewMockHubPublicKeyPinReaderInterface creates a new mock instance.
+func NewMockHubPublicKeyPinReaderInterface(ctrl *gomock.Controller) *MockHubPublicKeyPinReaderInterface {
+	mock := &MockHubPublicKeyPinReaderInterface{ctrl: ctrl}
+	mock.recorder = &MockHubPublicKeyPinReaderInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockHubPublicKeyPinReaderInterface) EXPECT() *MockHubPublicKeyPinReaderInterfaceMockRecorder {
+	return m.recorder
+}
+
+// ServicePublicKeyPinUpdate mocks base method.
+func (m *MockHubPublicKeyPinReaderInterface) ServicePublicKeyPinUpdate(arg0, arg1 string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ServicePublicKeyPinUpdate", arg0, arg1)
+}
+
+// ServicePublicKeyPinUpdate indicates an expected call of ServicePublicKeyPinUpdate.
+func (mr *MockHubPublicKeyPinReaderInterfaceMockRecorder) ServicePublicKeyPinUpdate(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ServicePublicKeyPinUpdate", reflect.TypeOf((*MockHubPublicKeyPinReaderInterface)(nil).ServicePublicKeyPinUpdate), arg0, arg1)
+}
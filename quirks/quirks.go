@@ -0,0 +1,182 @@
+// Package quirks provides a registry of known deviations of remote devices
+// from the SHIP specification and the workarounds applied for them.
+//
+// A quirk is only applied to a connection if a profile of the registry
+// matches the remote device, well-behaved devices are handled strictly.
+package quirks
+
+import (
+	"crypto/x509"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/enbility/ship-go/util"
+)
+
+// A known deviation of a remote device from the SHIP specification
+type Quirk string
+
+const (
+	// The device adds a `0x00` byte at the end of many messages, which is removed before parsing.
+	// Seen on PMCP devices.
+	TrailingZeroBytes Quirk = "trailing-zero-bytes"
+
+	// The device rejects a connection by sending `{"connectionHello":[{"phase":"pending"},{"waiting":60000}]}`
+	// and then closing the websocket connection with `4452: Node rejected by application.`
+	// A closed connection in the hello ready listen state is then handled as a rejection instead of an error.
+	PendingCloseRejection Quirk = "pending-close-rejection"
+)
+
+// A set of quirks applied to a connection
+type Set []Quirk
+
+// Returns true if the quirk is part of the set
+func (s Set) Has(quirk Quirk) bool {
+	return slices.Contains(s, quirk)
+}
+
+// The set of quirks which were applied to every remote device before quirks were configurable
+func Legacy() Set {
+	return Set{TrailingZeroBytes, PendingCloseRejection}
+}
+
+// The properties of a remote device used for matching profiles
+type Peer struct {
+	SKI   string // the SKI of the remote device
+	Brand string // the mDNS brand of the remote device, if known
+	Model string // the mDNS model of the remote device, if known
+
+	Certificate *x509.Certificate // the certificate of the remote device, if known
+}
+
+// Defines which remote devices a profile applies to
+//
+// All non empty fields have to match, an empty match applies to all devices.
+// Strings are compared case insensitive
+type Match struct {
+	SKI   string // the SKI, compared after normalization
+	Brand string // the mDNS brand
+	Model string // the mDNS model
+
+	CertificateOrganization       string // an O of the certificate subject
+	CertificateOrganizationalUnit string // an OU of the certificate subject
+	CertificateCommonName         string // the CN of the certificate subject
+}
+
+// Returns true if the peer matches all non empty fields
+func (m Match) Matches(peer Peer) bool {
+	if m.SKI != "" && util.NormalizeSKI(m.SKI) != util.NormalizeSKI(peer.SKI) {
+		return false
+	}
+
+	if m.Brand != "" && !strings.EqualFold(m.Brand, peer.Brand) {
+		return false
+	}
+
+	if m.Model != "" && !strings.EqualFold(m.Model, peer.Model) {
+		return false
+	}
+
+	if m.CertificateOrganization == "" &&
+		m.CertificateOrganizationalUnit == "" &&
+		m.CertificateCommonName == "" {
+		return true
+	}
+
+	if peer.Certificate == nil {
+		return false
+	}
+
+	subject := peer.Certificate.Subject
+
+	if m.CertificateOrganization != "" && !containsFold(subject.Organization, m.CertificateOrganization) {
+		return false
+	}
+
+	if m.CertificateOrganizationalUnit != "" && !containsFold(subject.OrganizationalUnit, m.CertificateOrganizationalUnit) {
+		return false
+	}
+
+	if m.CertificateCommonName != "" && !strings.EqualFold(subject.CommonName, m.CertificateCommonName) {
+		return false
+	}
+
+	return true
+}
+
+func containsFold(values []string, value string) bool {
+	return slices.ContainsFunc(values, func(item string) bool {
+		return strings.EqualFold(item, value)
+	})
+}
+
+// A documented set of quirks for matching remote devices
+type Profile struct {
+	Name        string // a short name of the profile
+	Description string // describes the devices and their deviations
+	Match       Match
+	Quirks      Set
+}
+
+// A registry of profiles used to find the quirks of a remote device
+type Registry struct {
+	profiles []Profile
+
+	mux sync.Mutex
+}
+
+// Create a registry with the given profiles
+func NewRegistry(profiles ...Profile) *Registry {
+	return &Registry{
+		profiles: slices.Clone(profiles),
+	}
+}
+
+// Create a registry which applies the legacy quirks to every remote device
+//
+// This is the default used by the hub to keep the behaviour of previous versions
+func NewLegacyRegistry() *Registry {
+	return NewRegistry(Profile{
+		Name:        "legacy",
+		Description: "workarounds applied to all devices by previous versions",
+		Quirks:      Legacy(),
+	})
+}
+
+// Add a profile to the registry
+func (r *Registry) Register(profile Profile) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	r.profiles = append(r.profiles, profile)
+}
+
+// Returns all profiles of the registry
+func (r *Registry) Profiles() []Profile {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	return slices.Clone(r.profiles)
+}
+
+// Returns the quirks of all profiles matching the peer
+func (r *Registry) Lookup(peer Peer) Set {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	var result Set
+	for _, profile := range r.profiles {
+		if !profile.Match.Matches(peer) {
+			continue
+		}
+
+		for _, quirk := range profile.Quirks {
+			if !result.Has(quirk) {
+				result = append(result, quirk)
+			}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,89 @@
+package quirks
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestQuirksSuite(t *testing.T) {
+	suite.Run(t, new(QuirksSuite))
+}
+
+type QuirksSuite struct {
+	suite.Suite
+}
+
+func (s *QuirksSuite) Test_SetHas() {
+	set := Set{TrailingZeroBytes}
+	assert.True(s.T(), set.Has(TrailingZeroBytes))
+	assert.False(s.T(), set.Has(PendingCloseRejection))
+
+	var empty Set
+	assert.False(s.T(), empty.Has(TrailingZeroBytes))
+}
+
+func (s *QuirksSuite) Test_Match() {
+	certificate := &x509.Certificate{
+		Subject: pkix.Name{
+			Organization:       []string{"Vendor"},
+			OrganizationalUnit: []string{"Unit"},
+			CommonName:         "Model-1234",
+		},
+	}
+	peer := Peer{
+		SKI:         "abcdef",
+		Brand:       "Brand",
+		Model:       "Model",
+		Certificate: certificate,
+	}
+
+	assert.True(s.T(), Match{}.Matches(peer))
+	assert.True(s.T(), Match{SKI: "AB CD-EF"}.Matches(peer))
+	assert.False(s.T(), Match{SKI: "123456"}.Matches(peer))
+	assert.True(s.T(), Match{Brand: "brand", Model: "MODEL"}.Matches(peer))
+	assert.False(s.T(), Match{Brand: "brand", Model: "other"}.Matches(peer))
+	assert.True(s.T(), Match{CertificateOrganization: "vendor"}.Matches(peer))
+	assert.True(s.T(), Match{CertificateOrganizationalUnit: "unit"}.Matches(peer))
+	assert.True(s.T(), Match{CertificateCommonName: "model-1234"}.Matches(peer))
+	assert.False(s.T(), Match{CertificateOrganization: "other"}.Matches(peer))
+	assert.False(s.T(), Match{CertificateOrganizationalUnit: "other"}.Matches(peer))
+	assert.False(s.T(), Match{CertificateCommonName: "other"}.Matches(peer))
+
+	peer.Certificate = nil
+	assert.False(s.T(), Match{CertificateOrganization: "vendor"}.Matches(peer))
+}
+
+func (s *QuirksSuite) Test_Registry() {
+	registry := NewRegistry()
+	assert.Equal(s.T(), 0, len(registry.Lookup(Peer{SKI: "abcdef"})))
+
+	registry.Register(Profile{
+		Name:   "brand",
+		Match:  Match{Brand: "Brand"},
+		Quirks: Set{TrailingZeroBytes},
+	})
+	registry.Register(Profile{
+		Name:   "ski",
+		Match:  Match{SKI: "abcdef"},
+		Quirks: Set{TrailingZeroBytes, PendingCloseRejection},
+	})
+	assert.Equal(s.T(), 2, len(registry.Profiles()))
+
+	set := registry.Lookup(Peer{SKI: "abcdef", Brand: "brand"})
+	assert.Equal(s.T(), Set{TrailingZeroBytes, PendingCloseRejection}, set)
+
+	set = registry.Lookup(Peer{SKI: "123456", Brand: "brand"})
+	assert.Equal(s.T(), Set{TrailingZeroBytes}, set)
+
+	set = registry.Lookup(Peer{SKI: "123456"})
+	assert.Equal(s.T(), 0, len(set))
+}
+
+func (s *QuirksSuite) Test_LegacyRegistry() {
+	registry := NewLegacyRegistry()
+	assert.Equal(s.T(), Legacy(), registry.Lookup(Peer{SKI: "abcdef"}))
+}
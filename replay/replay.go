@@ -0,0 +1,262 @@
+// Package replay feeds recorded SHIP sessions into a ShipConnection,
+// so captures of real devices can be used as regression tests.
+//
+// Recordings are created with ws.FrameRecorder, e.g. via Hub.SetRecordingDirectory.
+// The incoming frames of a recording are passed to a ShipConnection using a fake
+// websocket connection, the resulting SHIP states are collected and can be checked.
+package replay
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/enbility/ship-go/api"
+	"github.com/enbility/ship-go/model"
+	"github.com/enbility/ship-go/quirks"
+	"github.com/enbility/ship-go/ship"
+	"github.com/enbility/ship-go/ws"
+)
+
+// Options of a replay
+type Options struct {
+	// the role of the recording service, defaults to ship.ShipRoleClient
+	Role ship.ShipRole
+
+	// the SHIP IDs and SKI used for the ShipConnection
+	LocalShipID  string
+	RemoteSKI    string
+	RemoteShipID string
+
+	// the answers of the simulated hub
+	Paired               bool
+	AutoAccept           bool
+	AllowWaitingForTrust bool
+
+	// optional, the quirks applied to the ShipConnection
+	Quirks *quirks.Set
+
+	// optional, the time to wait after each incoming frame for asynchronous processing
+	Delay time.Duration
+}
+
+// The result of a replay
+type Result struct {
+	// all SHIP states the ShipConnection reported, in order
+	States []model.ShipMessageExchangeState
+
+	// the last error reported with a state
+	Error error
+
+	// the SHIP messages sent by the ShipConnection
+	Sent [][]byte
+
+	// the SPINE payloads passed on by the ShipConnection
+	Payloads [][]byte
+
+	// true if the ShipConnection closed the connection
+	Closed bool
+}
+
+// ErrUnexpectedStates is returned if the reported states do not contain the expected sequence
+var ErrUnexpectedStates = errors.New("unexpected ship states")
+
+// Check that the expected states were reported in the given order
+//
+// Other states may be reported in between
+func (r *Result) ExpectStates(expected ...model.ShipMessageExchangeState) error {
+	index := 0
+	for _, state := range r.States {
+		if index < len(expected) && state == expected[index] {
+			index++
+		}
+	}
+
+	if index < len(expected) {
+		return fmt.Errorf("%w: state %d not found in %v", ErrUnexpectedStates, expected[index], r.States)
+	}
+
+	return nil
+}
+
+// Replay the frames of a recording file
+func RunFile(path string, opts Options) (*Result, error) {
+	frames, err := ws.ReadRecordingFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return Run(frames, opts)
+}
+
+// Replay the incoming frames into a new ShipConnection and return the collected result
+//
+// The outgoing frames of the recording are not compared, as they may contain
+// values which change for each session
+func Run(frames []ws.RecordedFrame, opts Options) (*Result, error) {
+	role := opts.Role
+	if role == "" {
+		role = ship.ShipRoleClient
+	}
+
+	driver := &driver{
+		options: opts,
+	}
+
+	connection := ship.NewConnectionHandler(driver, driver, role, opts.LocalShipID, opts.RemoteSKI, opts.RemoteShipID)
+	if opts.Quirks != nil {
+		connection.SetQuirks(*opts.Quirks)
+	}
+
+	connection.Run()
+
+	for _, frame := range frames {
+		if frame.Direction != ws.FrameDirectionIncoming {
+			continue
+		}
+
+		if driver.isClosed() {
+			break
+		}
+
+		message, err := frame.Message()
+		if err != nil {
+			connection.CloseConnection(false, 0, "")
+			return nil, err
+		}
+
+		driver.reader.HandleIncomingWebsocketMessage(message)
+
+		if opts.Delay > 0 {
+			time.Sleep(opts.Delay)
+		}
+	}
+
+	// stop all handshake timers
+	connection.CloseConnection(false, 0, "")
+
+	return driver.result(), nil
+}
+
+// the simulated hub and websocket connection used for a replay
+type driver struct {
+	options Options
+
+	reader api.WebsocketDataReaderInterface
+
+	states   []model.ShipMessageExchangeState
+	err      error
+	sent     [][]byte
+	payloads [][]byte
+	closed   bool
+
+	mux sync.Mutex
+}
+
+func (d *driver) result() *Result {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	return &Result{
+		States:   d.states,
+		Error:    d.err,
+		Sent:     d.sent,
+		Payloads: d.payloads,
+		Closed:   d.closed,
+	}
+}
+
+func (d *driver) isClosed() bool {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	return d.closed
+}
+
+var _ api.WebsocketDataWriterInterface = (*driver)(nil)
+
+func (d *driver) InitDataProcessing(reader api.WebsocketDataReaderInterface) {
+	d.reader = reader
+}
+
+func (d *driver) WriteMessageToWebsocketConnection(message []byte) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	if d.closed {
+		return errors.New("connection is closed")
+	}
+
+	d.sent = append(d.sent, message)
+
+	return nil
+}
+
+func (d *driver) CloseDataConnection(closeCode int, reason string) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	d.closed = true
+}
+
+func (d *driver) IsDataConnectionClosed() (bool, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	if d.closed {
+		return true, errors.New("connection is closed")
+	}
+
+	return false, nil
+}
+
+func (d *driver) Latency() api.WebsocketLatency {
+	return api.WebsocketLatency{}
+}
+
+var _ api.ShipConnectionInfoProviderInterface = (*driver)(nil)
+
+func (d *driver) IsRemoteServiceForSKIPaired(string) bool {
+	return d.options.Paired
+}
+
+func (d *driver) IsAutoAcceptEnabled() bool {
+	return d.options.AutoAccept
+}
+
+func (d *driver) HandleConnectionClosed(api.ShipConnectionInterface, bool) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	d.closed = true
+}
+
+func (d *driver) ReportServiceShipID(string, string) {}
+
+func (d *driver) AllowWaitingForTrust(string) bool {
+	return d.options.AllowWaitingForTrust
+}
+
+func (d *driver) HandleShipHandshakeStateUpdate(ski string, state model.ShipState) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	d.states = append(d.states, state.State)
+	if state.Error != nil {
+		d.err = state.Error
+	}
+}
+
+func (d *driver) SetupRemoteDevice(string, api.ShipConnectionDataWriterInterface) api.ShipConnectionDataReaderInterface {
+	return d
+}
+
+var _ api.ShipConnectionDataReaderInterface = (*driver)(nil)
+
+func (d *driver) HandleShipPayloadMessage(message []byte) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	d.payloads = append(d.payloads, message)
+}
@@ -0,0 +1,137 @@
+package replay
+
+import (
+	"testing"
+
+	"github.com/enbility/ship-go/model"
+	"github.com/enbility/ship-go/ship"
+	"github.com/enbility/ship-go/ws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestReplaySuite(t *testing.T) {
+	suite.Run(t, new(ReplaySuite))
+}
+
+type ReplaySuite struct {
+	suite.Suite
+}
+
+// the outgoing messages of a recording
+func (s *ReplaySuite) recordedMessages(path string) [][]byte {
+	frames, err := ws.ReadRecordingFile(path)
+	assert.Nil(s.T(), err)
+
+	var messages [][]byte
+	for _, frame := range frames {
+		if frame.Direction != ws.FrameDirectionOutgoing {
+			continue
+		}
+
+		message, err := frame.Message()
+		assert.Nil(s.T(), err)
+		messages = append(messages, message)
+	}
+
+	return messages
+}
+
+// captured_server.jsonl and captured_client.jsonl were recorded by ws.FrameRecorder on both sides
+// of a TLS connection between ship.Dial and ship.Accept, with one SPINE payload sent by the client
+func (s *ReplaySuite) Test_CapturedServer() {
+	result, err := RunFile("testdata/captured_server.jsonl", Options{
+		Role:        ship.ShipRoleServer,
+		LocalShipID: "ship-go-server",
+		Paired:      true,
+	})
+	assert.Nil(s.T(), err)
+
+	err = result.ExpectStates(model.SmeHelloStateOk, model.SmeProtHStateServerOk, model.SmeStateComplete)
+	assert.Nil(s.T(), err)
+	assert.Nil(s.T(), result.Error)
+	assert.True(s.T(), result.Closed)
+	if assert.Equal(s.T(), 1, len(result.Payloads)) {
+		assert.Contains(s.T(), string(result.Payloads[0]), "nodeManagementDetailedDiscoveryData")
+	}
+
+	// the replayed connection answers like the recorded one
+	assert.Equal(s.T(), s.recordedMessages("testdata/captured_server.jsonl"), result.Sent)
+}
+
+func (s *ReplaySuite) Test_CapturedClient() {
+	result, err := RunFile("testdata/captured_client.jsonl", Options{
+		Role:        ship.ShipRoleClient,
+		LocalShipID: "ship-go-client",
+		Paired:      true,
+	})
+	assert.Nil(s.T(), err)
+
+	err = result.ExpectStates(model.SmeHelloStateOk, model.SmeProtHStateClientOk, model.SmeStateComplete)
+	assert.Nil(s.T(), err)
+	assert.Nil(s.T(), result.Error)
+	assert.Equal(s.T(), 0, len(result.Payloads))
+}
+
+func (s *ReplaySuite) Test_ServerComplete() {
+	result, err := RunFile("testdata/server_complete.jsonl", Options{
+		Role:   ship.ShipRoleServer,
+		Paired: true,
+	})
+	assert.Nil(s.T(), err)
+
+	err = result.ExpectStates(model.SmeHelloStateOk, model.SmeProtHStateServerOk, model.SmeStateComplete)
+	assert.Nil(s.T(), err)
+	assert.Nil(s.T(), result.Error)
+	assert.Equal(s.T(), 1, len(result.Payloads))
+	assert.NotEqual(s.T(), 0, len(result.Sent))
+}
+
+func (s *ReplaySuite) Test_ClientComplete() {
+	result, err := RunFile("testdata/client_complete.jsonl", Options{
+		Role:   ship.ShipRoleClient,
+		Paired: true,
+	})
+	assert.Nil(s.T(), err)
+
+	err = result.ExpectStates(model.SmeHelloStateOk, model.SmeProtHStateClientOk, model.SmeStateComplete)
+	assert.Nil(s.T(), err)
+	assert.Nil(s.T(), result.Error)
+}
+
+func (s *ReplaySuite) Test_ClientRejected() {
+	result, err := RunFile("testdata/client_rejected.jsonl", Options{
+		Role:   ship.ShipRoleClient,
+		Paired: true,
+	})
+	assert.Nil(s.T(), err)
+
+	err = result.ExpectStates(model.SmeHelloStateRemoteAbortDone)
+	assert.Nil(s.T(), err)
+	assert.True(s.T(), result.Closed)
+
+	err = result.ExpectStates(model.SmeStateComplete)
+	assert.ErrorIs(s.T(), err, ErrUnexpectedStates)
+}
+
+func (s *ReplaySuite) Test_ServerRejected() {
+	result, err := RunFile("testdata/server_rejected.jsonl", Options{
+		Role: ship.ShipRoleServer,
+	})
+	assert.Nil(s.T(), err)
+
+	err = result.ExpectStates(model.SmeHelloStateAbortDone)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 0, len(result.Payloads))
+}
+
+func (s *ReplaySuite) Test_Errors() {
+	_, err := RunFile("testdata/missing.jsonl", Options{})
+	assert.NotNil(s.T(), err)
+
+	frames := []ws.RecordedFrame{
+		{Direction: ws.FrameDirectionIncoming, PayloadBase64: "invalid!"},
+	}
+	_, err = Run(frames, Options{})
+	assert.NotNil(s.T(), err)
+}
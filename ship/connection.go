@@ -11,13 +11,14 @@ import (
 	"github.com/enbility/ship-go/api"
 	"github.com/enbility/ship-go/logging"
 	"github.com/enbility/ship-go/model"
+	"github.com/enbility/ship-go/quirks"
 	"github.com/enbility/ship-go/util"
 )
 
 // A ShipConnection handles the data connection and coordinates SHIP and SPINE messages i/o
 type ShipConnection struct {
 	// The ship connection mode of this connection
-	role shipRole
+	role ShipRole
 
 	// The remote SKI
 	remoteSKI string
@@ -57,10 +58,25 @@ type ShipConnection struct {
 
 	lastReceivedWaitingValue time.Duration // required for Prolong-Request-Reply-Timer
 
+	// the timer values used in the handshake
+	timings HandshakeTimings
+
+	// the workarounds applied for deviations of the remote device
+	quirks quirks.Set
+
+	// the chain of interceptors invoked for every SPINE payload
+	interceptors []api.PayloadInterceptorInterface
+
 	shutdownOnce sync.Once
 
 	// buffer for SPINE messages that came in before the handshake was completed
 	spineBuffer [][]byte
+	// the size of all buffered SPINE messages
+	spineBufferBytes int
+	// the limits of the SPINE message buffer
+	spineBufferLimits SpineBufferLimits
+	// the number of dropped and discarded SPINE messages
+	spineBufferStatistic api.SpineBufferStatistic
 
 	mux       sync.Mutex
 	bufferMux sync.Mutex
@@ -68,10 +84,15 @@ type ShipConnection struct {
 
 var _ api.ShipConnectionInterface = (*ShipConnection)(nil)
 
+// Create the SHIP handling of a connection
+//
+// Breaking change: incoming messages of the data handler are only processed once Run
+// is invoked, so the settings like the handshake timings are applied before.
+// Previous versions started processing incoming messages when the handler was created
 func NewConnectionHandler(
 	dataProvider api.ShipConnectionInfoProviderInterface,
 	dataHandler api.WebsocketDataWriterInterface,
-	role shipRole,
+	role ShipRole,
 	localShipID,
 	remoteSki,
 	remoteShipId string) *ShipConnection {
@@ -84,14 +105,14 @@ func NewConnectionHandler(
 		remoteShipID: remoteShipId,
 		smeState:     model.CmiStateInitStart,
 		smeError:     nil,
+		timings:      DefaultHandshakeTimings(),
+		quirks:       quirks.Legacy(),
+
+		spineBufferLimits: DefaultSpineBufferLimits(),
 	}
 
 	ship.handshakeTimerStopChan = make(chan struct{})
 
-	if dataHandler != nil {
-		dataHandler.InitDataProcessing(ship)
-	}
-
 	return ship
 }
 
@@ -103,14 +124,123 @@ func (c *ShipConnection) DataHandler() api.WebsocketDataWriterInterface {
 	return c.dataWriter
 }
 
+// Set the handshake timer values, has to be invoked before Run
+//
+// returns an error if the values do not conform to the SHIP specification
+func (c *ShipConnection) SetHandshakeTimings(timings HandshakeTimings) error {
+	if err := timings.Validate(); err != nil {
+		return err
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	c.timings = timings
+
+	return nil
+}
+
+// Set the limits of the buffer for SPINE messages received before the handshake completed,
+// has to be invoked before Run
+//
+// returns an error if the limits can not be used
+func (c *ShipConnection) SetSpineBufferLimits(limits SpineBufferLimits) error {
+	if err := limits.Validate(); err != nil {
+		return err
+	}
+
+	c.bufferMux.Lock()
+	defer c.bufferMux.Unlock()
+
+	c.spineBufferLimits = limits
+
+	return nil
+}
+
+// Returns the statistic of the buffer for SPINE messages received before the handshake completed
+func (c *ShipConnection) SpineBufferStatistic() api.SpineBufferStatistic {
+	c.bufferMux.Lock()
+	defer c.bufferMux.Unlock()
+
+	statistic := c.spineBufferStatistic
+	statistic.Messages = len(c.spineBuffer)
+	statistic.Bytes = c.spineBufferBytes
+
+	return statistic
+}
+
+// Set the workarounds applied for deviations of the remote device, has to be invoked before Run
+//
+// defaults to quirks.Legacy
+func (c *ShipConnection) SetQuirks(set quirks.Set) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	c.quirks = set
+}
+
+// Set the chain of interceptors invoked for every incoming and outgoing SPINE payload,
+// has to be invoked before Run
+//
+// The interceptors are invoked in the provided order, each one receiving
+// the payload returned by the previous one
+func (c *ShipConnection) SetPayloadInterceptors(interceptors ...api.PayloadInterceptorInterface) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	c.interceptors = interceptors
+}
+
+// pass a SPINE payload through the chain of interceptors
+//
+// returns the resulting payload and false if it was dropped
+func (c *ShipConnection) interceptPayload(direction api.PayloadDirection, typ byte, header model.HeaderType, payload []byte) ([]byte, bool) {
+	c.mux.Lock()
+	interceptors := c.interceptors
+	c.mux.Unlock()
+
+	context := api.PayloadContext{
+		SKI:         c.remoteSKI,
+		Direction:   direction,
+		MessageType: typ,
+		Header:      header,
+	}
+
+	for _, interceptor := range interceptors {
+		var ok bool
+		if payload, ok = interceptor.InterceptPayload(context, payload); !ok {
+			logging.Log().Trace(c.RemoteSKI(), "dropped", direction, "SPINE payload by interceptor")
+			return nil, false
+		}
+	}
+
+	return payload, true
+}
+
+// returns true if the workaround for the quirk is enabled
+func (c *ShipConnection) hasQuirk(quirk quirks.Quirk) bool {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	return c.quirks.Has(quirk)
+}
+
 // start SHIP communication
 func (c *ShipConnection) Run() {
+	// incoming messages are only processed once all settings are applied
+	if c.dataWriter != nil {
+		c.dataWriter.InitDataProcessing(c)
+	}
+
 	c.handleShipMessage(false, nil)
 }
 
 // provides the current ship state and error value if the state is in error
 func (c *ShipConnection) ShipHandshakeState() (model.ShipMessageExchangeState, error) {
-	return c.getState(), c.smeError
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	return c.smeState, c.smeError
 }
 
 // invoked when pairing for a pending request is approved
@@ -159,6 +289,11 @@ func (c *ShipConnection) CloseConnection(safe bool, code int, reason string) {
 			state == model.SmeHelloStateRemoteAbortDone ||
 			state == model.SmeHelloStateRejected
 
+		// messages received during an incomplete handshake are never passed on
+		if state != model.SmeStateComplete {
+			c.discardBufferedSpineMessages()
+		}
+
 		// this may not be used for Connection Data Exchange is entered!
 		if safe && state == model.SmeStateComplete {
 			// SHIP 13.4.7: Connection Termination Announce
@@ -236,6 +371,99 @@ func (c *ShipConnection) processBufferedSpineMessages() {
 	}
 
 	c.spineBuffer = nil
+	c.spineBufferBytes = 0
+}
+
+// add a SPINE message to the buffer, applying the buffer limits
+//
+// returns false if the connection has to be closed because of the limits
+func (c *ShipConnection) bufferSpineMessage(message []byte) bool {
+	c.bufferMux.Lock()
+	defer c.bufferMux.Unlock()
+
+	limits := c.spineBufferLimits
+	exceeded := func(additional int) bool {
+		return (limits.MaxMessages > 0 && len(c.spineBuffer)+1 > limits.MaxMessages) ||
+			(limits.MaxBytes > 0 && c.spineBufferBytes+additional > limits.MaxBytes)
+	}
+
+	if !exceeded(len(message)) {
+		c.spineBuffer = append(c.spineBuffer, message)
+		c.spineBufferBytes += len(message)
+		return true
+	}
+
+	c.logSpineBufferOverflow(limits.OverflowPolicy)
+
+	switch limits.OverflowPolicy {
+	case SpineBufferClose:
+		return false
+
+	case SpineBufferDropNewest:
+		c.countDroppedSpineMessage(message)
+		return true
+	}
+
+	// SpineBufferDropOldest
+
+	// the message alone exceeds the limits, so the buffered messages are kept
+	if limits.MaxBytes > 0 && len(message) > limits.MaxBytes {
+		c.countDroppedSpineMessage(message)
+		return true
+	}
+
+	for len(c.spineBuffer) > 0 && exceeded(len(message)) {
+		c.countDroppedSpineMessage(c.spineBuffer[0])
+		c.spineBufferBytes -= len(c.spineBuffer[0])
+		c.spineBuffer = c.spineBuffer[1:]
+	}
+
+	c.spineBuffer = append(c.spineBuffer, message)
+	c.spineBufferBytes += len(message)
+
+	return true
+}
+
+// count a SPINE message dropped because of the buffer limits
+func (c *ShipConnection) countDroppedSpineMessage(message []byte) {
+	c.spineBufferStatistic.DroppedMessages++
+	c.spineBufferStatistic.DroppedBytes += uint64(len(message))
+}
+
+// report exceeding the buffer limits, the first time with a higher log level
+func (c *ShipConnection) logSpineBufferOverflow(policy SpineBufferOverflowPolicy) {
+	var action string
+	switch policy {
+	case SpineBufferClose:
+		action = "closing connection"
+	case SpineBufferDropNewest:
+		action = "dropping newest message"
+	default:
+		action = "dropping oldest messages"
+	}
+
+	if c.spineBufferStatistic.DroppedMessages == 0 {
+		logging.Log().Info(c.RemoteSKI(), "SPINE buffer limit exceeded before handshake completed,", action)
+		return
+	}
+
+	logging.Log().Debug(c.RemoteSKI(), "SPINE buffer limit exceeded before handshake completed,", action)
+}
+
+// discard all buffered SPINE messages, used when the handshake did not complete
+func (c *ShipConnection) discardBufferedSpineMessages() {
+	c.bufferMux.Lock()
+	defer c.bufferMux.Unlock()
+
+	if len(c.spineBuffer) == 0 {
+		return
+	}
+
+	logging.Log().Debug(c.RemoteSKI(), "discarding", len(c.spineBuffer), "buffered SPINE messages")
+
+	c.spineBufferStatistic.DiscardedMessages += uint64(len(c.spineBuffer))
+	c.spineBuffer = nil
+	c.spineBufferBytes = 0
 }
 
 // route the incoming message to either SHIP or SPINE message handlers
@@ -251,18 +479,22 @@ func (c *ShipConnection) HandleIncomingWebsocketMessage(message []byte) {
 		return
 	}
 
+	payload, ok := c.interceptPayload(api.PayloadDirectionIncoming, message[0], data.Data.Header, []byte(data.Data.Payload))
+	if !ok {
+		return
+	}
+
 	if c.dataReader == nil {
 		// buffer message for processing once the handshake is completed
-		c.bufferMux.Lock()
-		defer c.bufferMux.Unlock()
-
-		c.spineBuffer = append(c.spineBuffer, []byte(data.Data.Payload))
+		if !c.bufferSpineMessage(payload) {
+			c.endHandshakeWithError(ErrSpineBufferOverflow)
+		}
 
 		return
 	}
 
 	// pass the payload to the SPINE read handler
-	c.dataReader.HandleShipPayloadMessage([]byte(data.Data.Payload))
+	c.dataReader.HandleShipPayloadMessage(payload)
 }
 
 // checks wether the provided messages is a SHIP message
@@ -277,7 +509,7 @@ func (c *ShipConnection) ReportConnectionError(err error) {
 
 	// rejections are also received by sending `{"connectionHello":[{"phase":"pending"},{"waiting":60000}]}`
 	// and then closing the websocket connection with `4452: Node rejected by application.`
-	if currentState == model.SmeHelloStateReadyListen {
+	if currentState == model.SmeHelloStateReadyListen && c.hasQuirk(quirks.PendingCloseRejection) {
 		c.setState(model.SmeHelloStateRejected, nil)
 		c.CloseConnection(false, 0, "")
 		return
@@ -347,6 +579,14 @@ func (c *ShipConnection) transformSpineDataIntoShipJson(data []byte) ([]byte, er
 }
 
 func (c *ShipConnection) sendSpineData(data []byte) error {
+	header := model.HeaderType{
+		ProtocolId: model.ShipProtocolId,
+	}
+	data, ok := c.interceptPayload(api.PayloadDirectionOutgoing, model.MsgTypeData, header, data)
+	if !ok {
+		return nil
+	}
+
 	eebusMsg, err := c.transformSpineDataIntoShipJson(data)
 	if err != nil {
 		return err
@@ -434,7 +674,11 @@ func (c *ShipConnection) parseMessage(msg []byte, jsonFormat bool) (byte, []byte
 	msg = msg[1:]
 
 	if jsonFormat {
-		return shipHeaderByte, JsonFromEEBUSJson(msg)
+		if c.hasQuirk(quirks.TrailingZeroBytes) {
+			return shipHeaderByte, JsonFromEEBUSJson(msg)
+		}
+
+		return shipHeaderByte, jsonFromEEBUSJson(msg)
 	}
 
 	return shipHeaderByte, msg
@@ -3,12 +3,15 @@ package ship
 import (
 	"encoding/json"
 	"errors"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/enbility/ship-go/api"
 	"github.com/enbility/ship-go/mocks"
 	"github.com/enbility/ship-go/model"
+	"github.com/enbility/ship-go/quirks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
@@ -82,6 +85,13 @@ func (s *ConnectionSuite) TestRun() {
 	assert.Equal(s.T(), model.CmiStateServerWait, state)
 }
 
+func (s *ConnectionSuite) TestRun_InitDataProcessing() {
+	s.wsDataWriter.AssertNotCalled(s.T(), "InitDataProcessing", mock.Anything)
+
+	s.sut.Run()
+	s.wsDataWriter.AssertCalled(s.T(), "InitDataProcessing", s.sut)
+}
+
 func (s *ConnectionSuite) Test_HandleShipCloseMessage() {
 	s.sut.handleShipMessage(false, []byte{})
 	state, err := s.sut.ShipHandshakeState()
@@ -240,6 +250,39 @@ func (s *ConnectionSuite) TestReportConnectionError() {
 	assert.Equal(s.T(), model.SmeHelloStateAbort, s.sut.smeState)
 }
 
+func (s *ConnectionSuite) TestReportConnectionError_WithoutQuirks() {
+	s.sut.SetQuirks(nil)
+
+	s.sut.smeState = model.SmeHelloStateReadyListen
+	s.sut.ReportConnectionError(errors.New("closed"))
+	assert.Equal(s.T(), model.SmeStateError, s.sut.smeState)
+}
+
+func (s *ConnectionSuite) TestShipModelFromMessage_Quirks() {
+	assert.Equal(s.T(), quirks.Legacy(), s.sut.quirks)
+
+	modelData := model.ShipData{
+		Data: model.DataType{
+			Payload: []byte(`{"datagram":{}}`),
+		},
+	}
+	jsonData, err := json.Marshal(modelData)
+	assert.Nil(s.T(), err)
+
+	msg := []byte{0}
+	msg = append(msg, jsonData...)
+	msg = append(msg, 0x00)
+
+	data, err := s.sut.shipModelFromMessage(msg)
+	assert.Nil(s.T(), err)
+	assert.NotNil(s.T(), data)
+
+	s.sut.SetQuirks(quirks.Set{})
+	data, err = s.sut.shipModelFromMessage(msg)
+	assert.NotNil(s.T(), err)
+	assert.Nil(s.T(), data)
+}
+
 func (s *ConnectionSuite) TestSendShipModel() {
 	err := s.sut.sendShipModel(model.MsgTypeInit, nil)
 	assert.NotNil(s.T(), err)
@@ -291,3 +334,248 @@ func (s *ConnectionSuite) Test_HandshakeTimer() {
 	assert.Equal(s.T(), timeoutTimerTypeWaitForReady, s.sut.getHandshakeTimerType())
 	assert.Equal(s.T(), true, s.sut.getHandshakeTimerRunning())
 }
+
+func (s *ConnectionSuite) Test_SetHandshakeTimings() {
+	assert.Equal(s.T(), DefaultHandshakeTimings(), s.sut.timings)
+
+	timings := DefaultHandshakeTimings()
+	timings.HelloInit = 120 * time.Second
+	err := s.sut.SetHandshakeTimings(timings)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 120*time.Second, s.sut.timings.HelloInit)
+
+	timings = DefaultHandshakeTimings()
+	timings.CmiTimeout = time.Second
+	err = s.sut.SetHandshakeTimings(timings)
+	assert.ErrorIs(s.T(), err, ErrInvalidHandshakeTimings)
+	assert.Equal(s.T(), 120*time.Second, s.sut.timings.HelloInit)
+}
+
+func (s *ConnectionSuite) Test_HandshakeTimingsValidate() {
+	assert.Nil(s.T(), DefaultHandshakeTimings().Validate())
+
+	timings := DefaultHandshakeTimings()
+	timings.HelloInit = 30 * time.Second
+	assert.NotNil(s.T(), timings.Validate())
+
+	timings = DefaultHandshakeTimings()
+	timings.HelloProlongMin = 0
+	assert.NotNil(s.T(), timings.Validate())
+
+	timings = DefaultHandshakeTimings()
+	timings.HelloProlongWaitingGap = timings.HelloProlongThrInc
+	assert.NotNil(s.T(), timings.Validate())
+
+	timings = DefaultHandshakeTimings()
+	timings.HelloProlongThrInc = timings.HelloInit
+	assert.NotNil(s.T(), timings.Validate())
+}
+
+func (s *ConnectionSuite) spineMessage(payload string) []byte {
+	modelData := model.ShipData{
+		Data: model.DataType{
+			Payload: []byte(payload),
+		},
+	}
+	jsonData, err := json.Marshal(modelData)
+	assert.Nil(s.T(), err)
+
+	msg := []byte{model.MsgTypeData}
+	return append(msg, jsonData...)
+}
+
+func (s *ConnectionSuite) Test_SpineBufferLimitsValidate() {
+	assert.Nil(s.T(), DefaultSpineBufferLimits().Validate())
+
+	limits := SpineBufferLimits{MaxMessages: -1}
+	assert.ErrorIs(s.T(), limits.Validate(), ErrInvalidSpineBufferLimits)
+
+	limits = SpineBufferLimits{MaxBytes: -1}
+	assert.ErrorIs(s.T(), limits.Validate(), ErrInvalidSpineBufferLimits)
+
+	limits = SpineBufferLimits{OverflowPolicy: SpineBufferClose + 1}
+	assert.ErrorIs(s.T(), limits.Validate(), ErrInvalidSpineBufferLimits)
+
+	err := s.sut.SetSpineBufferLimits(limits)
+	assert.ErrorIs(s.T(), err, ErrInvalidSpineBufferLimits)
+	assert.Equal(s.T(), DefaultSpineBufferLimits(), s.sut.spineBufferLimits)
+}
+
+func (s *ConnectionSuite) Test_SpineBuffer_DropOldest() {
+	err := s.sut.SetSpineBufferLimits(SpineBufferLimits{MaxMessages: 2, OverflowPolicy: SpineBufferDropOldest})
+	assert.Nil(s.T(), err)
+
+	s.sut.HandleIncomingWebsocketMessage(s.spineMessage(`{"datagram":{"n":1}}`))
+	s.sut.HandleIncomingWebsocketMessage(s.spineMessage(`{"datagram":{"n":2}}`))
+	s.sut.HandleIncomingWebsocketMessage(s.spineMessage(`{"datagram":{"n":3}}`))
+
+	statistic := s.sut.SpineBufferStatistic()
+	assert.Equal(s.T(), 2, statistic.Messages)
+	assert.Equal(s.T(), uint64(1), statistic.DroppedMessages)
+	assert.Equal(s.T(), uint64(len(`{"datagram":{"n":1}}`)), statistic.DroppedBytes)
+	assert.Equal(s.T(), `{"datagram":{"n":2}}`, string(s.sut.spineBuffer[0]))
+	assert.Equal(s.T(), `{"datagram":{"n":3}}`, string(s.sut.spineBuffer[1]))
+
+	s.sut.dataReader = s.shipConnectionReader
+	s.sut.processBufferedSpineMessages()
+
+	statistic = s.sut.SpineBufferStatistic()
+	assert.Equal(s.T(), 0, statistic.Messages)
+	assert.Equal(s.T(), 0, statistic.Bytes)
+}
+
+func (s *ConnectionSuite) Test_SpineBuffer_DropOldest_Bytes() {
+	payload := `{"datagram":{"n":1}}`
+	err := s.sut.SetSpineBufferLimits(SpineBufferLimits{MaxBytes: 2 * len(payload), OverflowPolicy: SpineBufferDropOldest})
+	assert.Nil(s.T(), err)
+
+	s.sut.HandleIncomingWebsocketMessage(s.spineMessage(payload))
+	s.sut.HandleIncomingWebsocketMessage(s.spineMessage(payload))
+	s.sut.HandleIncomingWebsocketMessage(s.spineMessage(payload))
+
+	statistic := s.sut.SpineBufferStatistic()
+	assert.Equal(s.T(), 2, statistic.Messages)
+	assert.Equal(s.T(), 2*len(payload), statistic.Bytes)
+	assert.Equal(s.T(), uint64(1), statistic.DroppedMessages)
+
+	// a message exceeding the limit on its own is dropped, the buffered messages are kept
+	large := `{"datagram":{"n":"` + strings.Repeat("x", 2*len(payload)) + `"}}`
+	s.sut.HandleIncomingWebsocketMessage(s.spineMessage(large))
+
+	statistic = s.sut.SpineBufferStatistic()
+	assert.Equal(s.T(), 2, statistic.Messages)
+	assert.Equal(s.T(), 2*len(payload), statistic.Bytes)
+	assert.Equal(s.T(), uint64(2), statistic.DroppedMessages)
+	assert.Equal(s.T(), uint64(len(payload)+len(large)), statistic.DroppedBytes)
+	assert.Equal(s.T(), payload, string(s.sut.spineBuffer[0]))
+}
+
+func (s *ConnectionSuite) Test_SpineBuffer_DropNewest() {
+	err := s.sut.SetSpineBufferLimits(SpineBufferLimits{MaxMessages: 1, OverflowPolicy: SpineBufferDropNewest})
+	assert.Nil(s.T(), err)
+
+	s.sut.HandleIncomingWebsocketMessage(s.spineMessage(`{"datagram":{"n":1}}`))
+	s.sut.HandleIncomingWebsocketMessage(s.spineMessage(`{"datagram":{"n":2}}`))
+
+	statistic := s.sut.SpineBufferStatistic()
+	assert.Equal(s.T(), 1, statistic.Messages)
+	assert.Equal(s.T(), uint64(1), statistic.DroppedMessages)
+	assert.Equal(s.T(), `{"datagram":{"n":1}}`, string(s.sut.spineBuffer[0]))
+}
+
+func (s *ConnectionSuite) Test_SpineBuffer_Close() {
+	err := s.sut.SetSpineBufferLimits(SpineBufferLimits{MaxMessages: 1, OverflowPolicy: SpineBufferClose})
+	assert.Nil(s.T(), err)
+
+	s.sut.HandleIncomingWebsocketMessage(s.spineMessage(`{"datagram":{"n":1}}`))
+	s.sut.HandleIncomingWebsocketMessage(s.spineMessage(`{"datagram":{"n":2}}`))
+
+	state, err := s.sut.ShipHandshakeState()
+	assert.Equal(s.T(), model.SmeStateError, state)
+	assert.ErrorIs(s.T(), err, ErrSpineBufferOverflow)
+
+	// the handshake did not complete, so the buffer is discarded
+	statistic := s.sut.SpineBufferStatistic()
+	assert.Equal(s.T(), 0, statistic.Messages)
+	assert.Equal(s.T(), uint64(1), statistic.DiscardedMessages)
+}
+
+func (s *ConnectionSuite) Test_SpineBuffer_DiscardOnAbort() {
+	s.sut.HandleIncomingWebsocketMessage(s.spineMessage(`{"datagram":{"n":1}}`))
+	s.sut.HandleIncomingWebsocketMessage(s.spineMessage(`{"datagram":{"n":2}}`))
+	assert.Equal(s.T(), 2, s.sut.SpineBufferStatistic().Messages)
+
+	s.sut.setState(model.SmeHelloStateAbortDone, nil)
+	s.sut.CloseConnection(false, 4452, "Node rejected by application")
+
+	statistic := s.sut.SpineBufferStatistic()
+	assert.Equal(s.T(), 0, statistic.Messages)
+	assert.Equal(s.T(), uint64(2), statistic.DiscardedMessages)
+}
+
+func (s *ConnectionSuite) Test_PayloadInterceptors_Incoming() {
+	s.sut.dataReader = s.shipConnectionReader
+
+	first := mocks.NewPayloadInterceptorInterface(s.T())
+	first.EXPECT().InterceptPayload(mock.Anything, mock.Anything).
+		RunAndReturn(func(context api.PayloadContext, payload []byte) ([]byte, bool) {
+			assert.Equal(s.T(), "RemoveDevice", context.SKI)
+			assert.Equal(s.T(), api.PayloadDirectionIncoming, context.Direction)
+			assert.Equal(s.T(), model.MsgTypeData, context.MessageType)
+			assert.Equal(s.T(), model.ProtocolIdType(model.ShipProtocolId), context.Header.ProtocolId)
+
+			return []byte(`{"datagram":{"n":2}}`), true
+		}).Once()
+	second := mocks.NewPayloadInterceptorInterface(s.T())
+	second.EXPECT().InterceptPayload(mock.Anything, []byte(`{"datagram":{"n":2}}`)).
+		Return([]byte(`{"datagram":{"n":3}}`), true).Once()
+	s.sut.SetPayloadInterceptors(first, second)
+
+	reader := mocks.NewShipConnectionDataReaderInterface(s.T())
+	reader.EXPECT().HandleShipPayloadMessage([]byte(`{"datagram":{"n":3}}`)).Return().Once()
+	s.sut.dataReader = reader
+
+	msg := s.spineMessageWithHeader(`{"datagram":{"n":1}}`)
+	s.sut.HandleIncomingWebsocketMessage(msg)
+}
+
+func (s *ConnectionSuite) Test_PayloadInterceptors_IncomingDropped() {
+	first := mocks.NewPayloadInterceptorInterface(s.T())
+	first.EXPECT().InterceptPayload(mock.Anything, mock.Anything).Return(nil, false).Once()
+	// not invoked once the payload is dropped
+	second := mocks.NewPayloadInterceptorInterface(s.T())
+	s.sut.SetPayloadInterceptors(first, second)
+
+	// not buffered either
+	s.sut.HandleIncomingWebsocketMessage(s.spineMessage(`{"datagram":{"n":1}}`))
+	assert.Equal(s.T(), 0, s.sut.SpineBufferStatistic().Messages)
+
+	reader := mocks.NewShipConnectionDataReaderInterface(s.T())
+	s.sut.dataReader = reader
+	first.EXPECT().InterceptPayload(mock.Anything, mock.Anything).Return(nil, false).Once()
+	s.sut.HandleIncomingWebsocketMessage(s.spineMessage(`{"datagram":{"n":1}}`))
+}
+
+func (s *ConnectionSuite) Test_PayloadInterceptors_Outgoing() {
+	interceptor := mocks.NewPayloadInterceptorInterface(s.T())
+	interceptor.EXPECT().InterceptPayload(mock.Anything, mock.Anything).
+		RunAndReturn(func(context api.PayloadContext, payload []byte) ([]byte, bool) {
+			assert.Equal(s.T(), api.PayloadDirectionOutgoing, context.Direction)
+			assert.Equal(s.T(), model.MsgTypeData, context.MessageType)
+			assert.Equal(s.T(), model.ProtocolIdType(model.ShipProtocolId), context.Header.ProtocolId)
+
+			return []byte(`{"datagram":{"modified":true}}`), true
+		}).Once()
+	s.sut.SetPayloadInterceptors(interceptor)
+
+	s.sut.WriteShipMessageWithPayload([]byte(`{"datagram":{}}`))
+
+	s.mux.Lock()
+	assert.Contains(s.T(), string(s.sentMessage), "modified")
+	s.sentMessage = nil
+	s.mux.Unlock()
+
+	interceptor.EXPECT().InterceptPayload(mock.Anything, mock.Anything).Return(nil, false).Once()
+	err := s.sut.sendSpineData([]byte(`{"datagram":{}}`))
+	assert.Nil(s.T(), err)
+
+	s.mux.Lock()
+	assert.Nil(s.T(), s.sentMessage)
+	s.mux.Unlock()
+}
+
+func (s *ConnectionSuite) spineMessageWithHeader(payload string) []byte {
+	modelData := model.ShipData{
+		Data: model.DataType{
+			Header: model.HeaderType{
+				ProtocolId: model.ShipProtocolId,
+			},
+			Payload: []byte(payload),
+		},
+	}
+	jsonData, err := json.Marshal(modelData)
+	assert.Nil(s.T(), err)
+
+	msg := []byte{model.MsgTypeData}
+	return append(msg, jsonData...)
+}
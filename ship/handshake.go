@@ -55,15 +55,15 @@ func (c *ShipConnection) setState(newState model.ShipMessageExchangeState, err e
 
 	switch newState {
 	case model.SmeHelloStateReadyInit:
-		c.setHandshakeTimer(timeoutTimerTypeWaitForReady, tHelloInit)
+		c.setHandshakeTimer(timeoutTimerTypeWaitForReady, c.timings.HelloInit)
 	case model.SmeHelloStatePendingInit:
-		c.setHandshakeTimer(timeoutTimerTypeWaitForReady, tHelloInit)
+		c.setHandshakeTimer(timeoutTimerTypeWaitForReady, c.timings.HelloInit)
 	case model.SmeHelloStateOk:
 		c.stopHandshakeTimer()
 	case model.SmeHelloStateAbort, model.SmeHelloStateAbortDone, model.SmeHelloStateRemoteAbortDone, model.SmeHelloStateRejected:
 		c.stopHandshakeTimer()
 	case model.SmeProtHStateClientListenChoice:
-		c.setHandshakeTimer(timeoutTimerTypeWaitForReady, cmiTimeout)
+		c.setHandshakeTimer(timeoutTimerTypeWaitForReady, c.timings.CmiTimeout)
 	case model.SmeProtHStateClientOk:
 		c.stopHandshakeTimer()
 	}
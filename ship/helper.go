@@ -10,12 +10,16 @@ import (
 
 // convert incoming EEBUS json format into standard json format
 func JsonFromEEBUSJson(json []byte) []byte {
+	// The PMCP device mistakenly adds an `0x00` byte at the end of many messages.
+	return bytes.Trim(jsonFromEEBUSJson(json), "\x00")
+}
+
+// convert incoming EEBUS json format into standard json format without any workarounds
+func jsonFromEEBUSJson(json []byte) []byte {
 	var result = bytes.ReplaceAll(json, []byte("[{"), []byte("{"))
 	result = bytes.ReplaceAll(result, []byte("},{"), []byte(","))
 	result = bytes.ReplaceAll(result, []byte("}]"), []byte("}"))
 	result = bytes.ReplaceAll(result, []byte("[]"), []byte("{}"))
-	// The PMCP device mistakenly adds an `0x00` byte at the end of many messages.
-	result = bytes.Trim(result, "\x00")
 	return result
 }
 
@@ -22,7 +22,7 @@ func (c *ShipConnection) handshakeAccessMethods_Init() {
 		return
 	}
 
-	c.setHandshakeTimer(timeoutTimerTypeWaitForReady, cmiTimeout)
+	c.setHandshakeTimer(timeoutTimerTypeWaitForReady, c.timings.CmiTimeout)
 	c.setState(model.SmeAccessMethodsRequest, nil)
 }
 
@@ -12,7 +12,7 @@ import (
 
 // SME_HELLO_STATE_READY_INIT
 func (c *ShipConnection) handshakeHello_Init() {
-	if err := c.handshakeHelloSend(model.ConnectionHelloPhaseTypeReady, tHelloInit, false); err != nil {
+	if err := c.handshakeHelloSend(model.ConnectionHelloPhaseTypeReady, c.timings.HelloInit, false); err != nil {
 		c.setAndHandleState(model.SmeHelloStateAbort)
 		return
 	}
@@ -50,10 +50,10 @@ func (c *ShipConnection) handshakeHello_ReadyListen(timeout bool, message []byte
 		if *hello.ProlongationRequest {
 			if c.infoProvider.AllowWaitingForTrust(c.remoteSKI) {
 				// re-init timer
-				c.setHandshakeTimer(timeoutTimerTypeWaitForReady, tHelloInit)
+				c.setHandshakeTimer(timeoutTimerTypeWaitForReady, c.timings.HelloInc)
 			}
 
-			if err := c.handshakeHelloSend(model.ConnectionHelloPhaseTypeReady, tHelloInit, false); err != nil {
+			if err := c.handshakeHelloSend(model.ConnectionHelloPhaseTypeReady, c.timings.HelloInc, false); err != nil {
 				c.endHandshakeWithError(err)
 			}
 
@@ -96,7 +96,7 @@ func (c *ShipConnection) handshakeHello_Abort() {
 
 // SME_HELLO_PENDING_INIT
 func (c *ShipConnection) handshakeHello_PendingInit() {
-	if err := c.handshakeHelloSend(model.ConnectionHelloPhaseTypePending, tHelloInit, false); err != nil {
+	if err := c.handshakeHelloSend(model.ConnectionHelloPhaseTypePending, c.timings.HelloInit, false); err != nil {
 		c.endHandshakeWithError(err)
 		return
 	}
@@ -141,23 +141,14 @@ func (c *ShipConnection) handshakeHello_PendingListen(timeout bool, message []by
 
 		// conversion is safe
 		newDuration := time.Duration(*hello.Waiting) * time.Millisecond // #nosec G115
-		duration := tHelloProlongThrInc
-		if newDuration >= duration {
-			// the duration has to be reduced
-			duration = newDuration - duration
-
-			// check if it is less than T_hello_prolong_min
-			if newDuration >= tHelloProlongMin {
-				c.setHandshakeTimer(timeoutTimerTypeSendProlongationRequest, duration)
-				return
-			}
+		if duration, ok := c.prolongationRequestDelay(newDuration); ok {
+			c.setHandshakeTimer(timeoutTimerTypeSendProlongationRequest, duration)
+			return
 		}
 
-		if newDuration < tHelloProlongMin {
-			// I interpret 13.4.4.1.3 Page 64 Line 1550-1553 as this resulting in a timeout state
-			// TODO: verify this
-			c.setAndHandleState(model.SmeHelloStateAbort)
-		}
+		// I interpret 13.4.4.1.3 Page 64 Line 1550-1553 as this resulting in a timeout state
+		// TODO: verify this
+		c.setAndHandleState(model.SmeHelloStateAbort)
 
 	case model.ConnectionHelloPhaseTypePending:
 		if hello.Waiting != nil && hello.ProlongationRequest == nil {
@@ -166,30 +157,21 @@ func (c *ShipConnection) handshakeHello_PendingListen(timeout bool, message []by
 			// conversion is safe
 			newDuration := time.Duration(*hello.Waiting) * time.Millisecond // #nosec G115
 			c.lastReceivedWaitingValue = newDuration
-			duration := tHelloProlongThrInc
-			if newDuration >= duration {
-				// the duration has to be reduced
-				duration = newDuration - duration
-
-				// check if it is less than T_hello_prolong_min
-				if newDuration >= tHelloProlongMin {
-					c.setHandshakeTimer(timeoutTimerTypeSendProlongationRequest, duration)
-					return
-				}
+			if duration, ok := c.prolongationRequestDelay(newDuration); ok {
+				c.setHandshakeTimer(timeoutTimerTypeSendProlongationRequest, duration)
+				return
 			}
 
-			if newDuration < tHelloProlongMin {
-				// I interpret 13.4.4.1.3 Page 64 Line 1557-1560 as this resulting in a timeout state
-				// TODO: verify this
-				c.setAndHandleState(model.SmeHelloStateAbort)
-			}
+			// I interpret 13.4.4.1.3 Page 64 Line 1557-1560 as this resulting in a timeout state
+			// TODO: verify this
+			c.setAndHandleState(model.SmeHelloStateAbort)
 
 			return
 		}
 
 		if hello.Waiting == nil && hello.ProlongationRequest != nil && *hello.ProlongationRequest {
 			// if we got a prolongation request, accept it
-			if err := c.handshakeHelloSend(model.ConnectionHelloPhaseTypePending, tHelloInit, false); err != nil {
+			if err := c.handshakeHelloSend(model.ConnectionHelloPhaseTypePending, c.timings.HelloInc, false); err != nil {
 				c.endHandshakeWithError(err)
 			}
 
@@ -212,6 +194,25 @@ func (c *ShipConnection) handshakeHello_PendingListen(timeout bool, message []by
 	c.handleState(false, nil)
 }
 
+// returns the time until a prolongation request has to be sent for the waiting value of the remote service
+//
+// SHIP 13.4.4.1.3: the request is sent T_hello_prolong_thr_inc before the remote timer expires,
+// or T_hello_prolong_waiting_gap before if the waiting value is lower than that.
+// Returns false if the waiting value is less than T_hello_prolong_min
+func (c *ShipConnection) prolongationRequestDelay(waiting time.Duration) (time.Duration, bool) {
+	switch {
+	case waiting < c.timings.HelloProlongMin:
+		return 0, false
+	case waiting >= c.timings.HelloProlongThrInc:
+		return waiting - c.timings.HelloProlongThrInc, true
+	case waiting > c.timings.HelloProlongWaitingGap:
+		return waiting - c.timings.HelloProlongWaitingGap, true
+	default:
+		// the remote timer expires soon, so the request is sent right away
+		return 0, true
+	}
+}
+
 func (c *ShipConnection) handshakeHello_PendingProlongationRequest() {
 	if err := c.handshakeHelloSend(model.ConnectionHelloPhaseTypePending, 0, true); err != nil {
 		c.endHandshakeWithError(err)
@@ -219,7 +220,7 @@ func (c *ShipConnection) handshakeHello_PendingProlongationRequest() {
 	}
 
 	// TODO: we need to set the timer to the last received waiting value
-	c.setHandshakeTimer(timeoutTimerTypeProlongRequestReply, tHelloInit)
+	c.setHandshakeTimer(timeoutTimerTypeProlongRequestReply, c.timings.HelloInit)
 }
 
 func (c *ShipConnection) handshakeHello_PendingTimeout() {
@@ -234,7 +235,7 @@ func (c *ShipConnection) handshakeHello_PendingTimeout() {
 	}
 
 	if c.lastReceivedWaitingValue == 0 {
-		newValue := float64(tHelloInit.Milliseconds()) * 1.1
+		newValue := float64(c.timings.HelloInit.Milliseconds()) * 1.1
 		c.lastReceivedWaitingValue = time.Duration(newValue)
 	}
 	c.setHandshakeTimer(timeoutTimerTypeProlongRequestReply, c.lastReceivedWaitingValue)
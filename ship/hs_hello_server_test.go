@@ -221,6 +221,31 @@ func (s *HelloSuite) Test_ReadyListen_Prolongation() {
 	assert.Equal(s.T(), model.SmeHelloStateReadyListen, s.sut.getState())
 }
 
+func (s *HelloSuite) Test_ReadyListen_Prolongation_HelloInc() {
+	s.sut.timings.HelloInc = 90 * time.Second
+
+	s.sut.setState(model.SmeHelloStateReadyInit, nil) // inits the timer
+	s.sut.setState(model.SmeHelloStateReadyListen, nil)
+
+	s.mockShipInfo.EXPECT().AllowWaitingForTrust(mock.Anything).Return(true)
+
+	helloMsg := model.ConnectionHello{
+		ConnectionHello: model.ConnectionHelloType{
+			Phase:               model.ConnectionHelloPhaseTypePending,
+			ProlongationRequest: util.Ptr(true),
+		},
+	}
+
+	msg, err := s.sut.shipMessage(model.MsgTypeControl, helloMsg)
+	assert.Nil(s.T(), err)
+
+	s.sut.handleState(false, msg)
+
+	// the timer is prolonged by T_hello_inc
+	assert.Equal(s.T(), model.SmeHelloStateReadyListen, s.sut.getState())
+	assert.Contains(s.T(), string(s.lastMessage()), `{"waiting":90000}`)
+}
+
 func (s *HelloSuite) Test_ReadyListen_Abort() {
 	s.sut.setState(model.SmeHelloStateReadyInit, nil) // inits the timer
 	s.sut.setState(model.SmeHelloStateReadyListen, nil)
@@ -476,6 +501,75 @@ func (s *HelloSuite) Test_PendingListen_PendingProlongation() {
 	assert.NotNil(s.T(), s.lastMessage())
 }
 
+func (s *HelloSuite) Test_PendingListen_PendingProlongation_HelloInc() {
+	s.sut.timings.HelloInc = 90 * time.Second
+
+	s.sut.setState(model.SmeHelloStatePendingInit, nil) // inits the timer
+	s.sut.setState(model.SmeHelloStatePendingListen, nil)
+
+	helloMsg := model.ConnectionHello{
+		ConnectionHello: model.ConnectionHelloType{
+			Phase:               model.ConnectionHelloPhaseTypePending,
+			ProlongationRequest: util.Ptr(true),
+		},
+	}
+
+	msg, err := s.sut.shipMessage(model.MsgTypeControl, helloMsg)
+	assert.Nil(s.T(), err)
+
+	s.sut.handleShipMessage(false, msg)
+
+	assert.Equal(s.T(), model.SmeHelloStatePendingListen, s.sut.getState())
+	assert.Contains(s.T(), string(s.lastMessage()), `{"waiting":90000}`)
+}
+
+func (s *HelloSuite) Test_PendingListen_PendingWaiting_Gap() {
+	s.mockShipInfo.EXPECT().AllowWaitingForTrust(mock.Anything).Return(true).Maybe()
+
+	s.sut.setState(model.SmeHelloStatePendingInit, nil) // inits the timer
+	s.sut.setState(model.SmeHelloStatePendingListen, nil)
+
+	// the waiting value is below T_hello_prolong_thr_inc
+	helloMsg := model.ConnectionHello{
+		ConnectionHello: model.ConnectionHelloType{
+			Phase:   model.ConnectionHelloPhaseTypePending,
+			Waiting: util.Ptr(uint(20000)),
+		},
+	}
+
+	msg, err := s.sut.shipMessage(model.MsgTypeControl, helloMsg)
+	assert.Nil(s.T(), err)
+
+	s.sut.handleShipMessage(false, msg)
+
+	assert.Equal(s.T(), true, s.sut.getHandshakeTimerRunning())
+	assert.Equal(s.T(), timeoutTimerTypeSendProlongationRequest, s.sut.getHandshakeTimerType())
+	assert.Equal(s.T(), model.SmeHelloStatePendingListen, s.sut.getState())
+}
+
+func (s *HelloSuite) Test_ProlongationRequestDelay() {
+	s.sut.timings.HelloProlongThrInc = 30 * time.Second
+	s.sut.timings.HelloProlongWaitingGap = 10 * time.Second
+	s.sut.timings.HelloProlongMin = 2 * time.Second
+
+	tests := []struct {
+		waiting, delay time.Duration
+		ok             bool
+	}{
+		{60 * time.Second, 30 * time.Second, true},
+		{30 * time.Second, 0, true},
+		{25 * time.Second, 15 * time.Second, true},
+		{5 * time.Second, 0, true},
+		{1 * time.Second, 0, false},
+	}
+
+	for _, test := range tests {
+		delay, ok := s.sut.prolongationRequestDelay(test.waiting)
+		assert.Equal(s.T(), test.ok, ok, test.waiting)
+		assert.Equal(s.T(), test.delay, delay, test.waiting)
+	}
+}
+
 func (s *HelloSuite) Test_HelloSend_Failure() {
 	s.sut.setState(model.SmeHelloStatePendingInit, nil) // inits the timer
 	s.sut.setState(model.SmeHelloStatePendingListen, nil)
@@ -23,7 +23,7 @@ func (c *ShipConnection) handshakeInit_cmiStateInitStart() {
 		c.setState(model.CmiStateServerWait, nil)
 	}
 
-	c.setHandshakeTimer(timeoutTimerTypeWaitForReady, cmiTimeout)
+	c.setHandshakeTimer(timeoutTimerTypeWaitForReady, c.timings.CmiTimeout)
 }
 
 // CMI_STATE_SERVER_WAIT
@@ -14,7 +14,7 @@ func (c *ShipConnection) handshakeProtocol_Init() {
 	switch c.role {
 	case ShipRoleServer:
 		c.setState(model.SmeProtHStateServerInit, nil)
-		c.setHandshakeTimer(timeoutTimerTypeWaitForReady, cmiTimeout)
+		c.setHandshakeTimer(timeoutTimerTypeWaitForReady, c.timings.CmiTimeout)
 		c.setState(model.SmeProtHStateServerListenProposal, nil)
 	case ShipRoleClient:
 		c.setState(model.SmeProtHStateClientInit, nil)
@@ -59,7 +59,7 @@ func (c *ShipConnection) handshakeProtocol_smeProtHStateServerListenProposal(mes
 		c.endHandshakeWithError(err)
 	}
 
-	c.setHandshakeTimer(timeoutTimerTypeWaitForReady, cmiTimeout)
+	c.setHandshakeTimer(timeoutTimerTypeWaitForReady, c.timings.CmiTimeout)
 
 	c.setState(model.SmeProtHStateServerListenConfirm, nil)
 }
@@ -0,0 +1,560 @@
+package ship
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/enbility/ship-go/api"
+	"github.com/enbility/ship-go/cert"
+	"github.com/enbility/ship-go/logging"
+	"github.com/enbility/ship-go/model"
+	"github.com/enbility/ship-go/util"
+	"github.com/enbility/ship-go/ws"
+	"github.com/gorilla/websocket"
+)
+
+// the default websocket path used by Dial
+const defaultWebsocketPath = "/ship/"
+
+// the number of received SPINE payloads which are queued until Receive is called
+const receiveQueueSize = 100
+
+// ErrUntrustedRemoteService is returned if the trust callback did not trust the remote service
+var ErrUntrustedRemoteService = errors.New("remote service is not trusted")
+
+// ErrHandshakeAborted is returned if the SHIP handshake was aborted by either side
+var ErrHandshakeAborted = errors.New("ship handshake aborted")
+
+// ErrConnectionClosed is returned if the connection was closed
+var ErrConnectionClosed = errors.New("ship connection closed")
+
+// ErrRemoteSKIMismatch is returned if the remote certificate does not provide the expected SKI
+var ErrRemoteSKIMismatch = errors.New("remote SKI does not match")
+
+// ErrReceiveQueueFull is returned if the connection was closed as Receive did not keep up with the incoming payloads
+var ErrReceiveQueueFull = errors.New("receive queue is full")
+
+// Options for a standalone SHIP connection created with Dial or Accept
+type Options struct {
+	// The local SHIP ID
+	LocalShipID string
+
+	// Optional, the SHIP ID the remote service has to report
+	RemoteShipID string
+
+	// Optional, the SKI the remote certificate has to provide
+	RemoteSKI string
+
+	// Optional for Dial, the websocket path, defaults to "/ship/"
+	Path string
+
+	// Decides if the remote service with the given SKI is trusted
+	// If not set, no remote service is trusted
+	Trust func(ski string) bool
+
+	// Optional, invoked for every SHIP handshake state change
+	StateUpdate func(state model.ShipState)
+
+	// Optional, the timer values used for the SHIP handshake
+	HandshakeTimings *HandshakeTimings
+
+	// Optional, the limits of the buffer for SPINE messages received before the handshake completed
+	SpineBufferLimits *SpineBufferLimits
+
+	// Optional, the chain of interceptors invoked for every SPINE payload
+	Interceptors []api.PayloadInterceptorInterface
+
+	// Optional, records every SHIP message of the connection
+	Recorder *ws.FrameRecorder
+
+	// Optional, the timer values used for the websocket connection
+	WebsocketTimings *ws.Timings
+}
+
+// A single SHIP connection which is not managed by a Hub
+//
+// Created with Dial or Accept, the SHIP handshake is completed when returned
+type Conn struct {
+	options Options
+
+	remoteSKI    string
+	remoteShipID string
+
+	connection *ShipConnection
+
+	// incoming SPINE payloads, queued until Receive is called
+	payloads chan []byte
+
+	// the number of incoming SPINE payloads which could not be queued
+	droppedPayloads atomic.Uint64
+
+	// the reason the connection was closed, if it was not closed by either side
+	closeErr error
+
+	// receives the result of the SHIP handshake
+	handshakeResult chan error
+
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	mux sync.Mutex
+}
+
+// Returns a TLS configuration for a SHIP client
+func ClientTLSConfig(certificate tls.Certificate) *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		// SHIP 12.1: all certificates are locally signed
+		InsecureSkipVerify: true, // #nosec G402
+		// SHIP 9.1: the ciphers are reported insecure but are defined to be used by SHIP
+		CipherSuites: cert.CipherSuites, // #nosec G402
+		MinVersion:   tls.VersionTLS12,  // SHIP 9: Mandatory TLS version
+	}
+}
+
+// Returns a TLS configuration for a SHIP server, e.g. to create a listener for Accept
+func ServerTLSConfig(certificate tls.Certificate) *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		ClientAuth:   tls.RequireAnyClientCert, // SHIP 9: Client authentication is required
+		CipherSuites: cert.CipherSuites,        // #nosec G402 // SHIP 9.1: the ciphers are reported insecure but are defined to be used by SHIP
+		MinVersion:   tls.VersionTLS12,         // SHIP 9: Mandatory TLS version
+	}
+}
+
+// Connect to a SHIP service at addr (host:port) and complete the SHIP handshake
+//
+// The remote service has to be trusted by the trust callback, otherwise
+// ErrUntrustedRemoteService is returned
+func Dial(ctx context.Context, addr string, certificate tls.Certificate, opts Options) (*Conn, error) {
+	path := opts.Path
+	if path == "" {
+		path = defaultWebsocketPath
+	}
+
+	dialer := &websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: 5 * time.Second,
+		TLSClientConfig:  ClientTLSConfig(certificate),
+		Subprotocols:     []string{api.ShipWebsocketSubProtocol}, // SHIP 10.2: Sub protocol "ship" is required
+	}
+
+	conn, resp, err := dialer.DialContext(ctx, fmt.Sprintf("wss://%s%s", addr, path), nil)
+	if err != nil {
+		return nil, err
+	}
+	_ = resp.Body.Close()
+
+	ski, err := checkWebsocketConnection(conn)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	if err := checkRemoteSKI(ski, opts); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	if opts.Trust == nil || !opts.Trust(ski) {
+		_ = conn.Close()
+		return nil, ErrUntrustedRemoteService
+	}
+
+	return newConn(ctx, conn, ShipRoleClient, ski, opts)
+}
+
+// Accept an incoming SHIP connection and complete the SHIP handshake
+//
+// conn has to be created by a listener using ServerTLSConfig.
+// If the remote service is not trusted by the trust callback, the handshake is aborted
+func Accept(ctx context.Context, conn *tls.Conn, opts Options) (*Conn, error) {
+	upgradeCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	if err := conn.HandshakeContext(upgradeCtx); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	wsConn, err := upgradeConnection(upgradeCtx, conn)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	ski, err := checkWebsocketConnection(wsConn)
+	if err != nil {
+		_ = wsConn.Close()
+		return nil, err
+	}
+
+	if err := checkRemoteSKI(ski, opts); err != nil {
+		_ = wsConn.Close()
+		return nil, err
+	}
+
+	return newConn(ctx, wsConn, ShipRoleServer, ski, opts)
+}
+
+// check the sub protocol and certificate of a websocket connection
+//
+// returns the SKI of the remote certificate, an error wrapping cert.ErrSkiMismatch
+// if it is not the hash of the public key
+func checkWebsocketConnection(conn *websocket.Conn) (string, error) {
+	if conn.Subprotocol() != api.ShipWebsocketSubProtocol {
+		return "", errors.New("remote service does not support the ship sub protocol")
+	}
+
+	tlsConn, ok := conn.UnderlyingConn().(*tls.Conn)
+	if !ok {
+		return "", errors.New("connection is not using TLS")
+	}
+
+	remoteCerts := tlsConn.ConnectionState().PeerCertificates
+	if len(remoteCerts) == 0 {
+		return "", errors.New("remote service does not provide a certificate")
+	}
+
+	// the SKI is only used once it is verified to be the hash of the public key
+	ski, err := cert.VerifiedSkiFromCertificate(remoteCerts[0])
+	if err != nil {
+		return "", err
+	}
+
+	return util.NormalizeSKI(ski), nil
+}
+
+// check the SKI of the remote certificate against the optional expected SKI
+func checkRemoteSKI(ski string, opts Options) error {
+	if opts.RemoteSKI != "" && util.NormalizeSKI(opts.RemoteSKI) != ski {
+		return fmt.Errorf("%w: expected %s, got %s", ErrRemoteSKIMismatch, opts.RemoteSKI, ski)
+	}
+
+	return nil
+}
+
+// upgrade a single TLS connection to a websocket connection
+func upgradeConnection(ctx context.Context, conn *tls.Conn) (*websocket.Conn, error) {
+	type upgradeResult struct {
+		conn *websocket.Conn
+		err  error
+	}
+
+	result := make(chan upgradeResult, 1)
+
+	upgrader := websocket.Upgrader{
+		ReadBufferSize:  ws.MaxMessageSize,
+		WriteBufferSize: ws.MaxMessageSize,
+		CheckOrigin:     func(r *http.Request) bool { return true },
+		Subprotocols:    []string{api.ShipWebsocketSubProtocol}, // SHIP 10.2: Sub protocol "ship" is required
+	}
+
+	listener := newSingleConnListener(conn)
+	server := &http.Server{
+		ReadHeaderTimeout: 10 * time.Second,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			wsConn, err := upgrader.Upgrade(w, r, nil)
+			result <- upgradeResult{conn: wsConn, err: err}
+		}),
+	}
+
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer listener.Close()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-result:
+		return res.conn, res.err
+	}
+}
+
+// create the SHIP handling for a websocket connection and wait for the handshake to complete
+func newConn(ctx context.Context, conn *websocket.Conn, role ShipRole, ski string, opts Options) (*Conn, error) {
+	c := &Conn{
+		options:         opts,
+		remoteSKI:       ski,
+		remoteShipID:    opts.RemoteShipID,
+		payloads:        make(chan []byte, receiveQueueSize),
+		handshakeResult: make(chan error, 1),
+		closed:          make(chan struct{}),
+	}
+
+	dataHandler := ws.NewWebsocketConnection(conn, ski)
+	if opts.Recorder != nil {
+		dataHandler.SetRecorder(opts.Recorder)
+	}
+	if opts.WebsocketTimings != nil {
+		if err := dataHandler.SetTimings(*opts.WebsocketTimings); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+
+	c.mux.Lock()
+	c.connection = NewConnectionHandler(c, dataHandler, role, opts.LocalShipID, ski, opts.RemoteShipID)
+	c.mux.Unlock()
+
+	if opts.HandshakeTimings != nil {
+		if err := c.connection.SetHandshakeTimings(*opts.HandshakeTimings); err != nil {
+			c.connection.CloseConnection(false, 0, "")
+			return nil, err
+		}
+	}
+
+	if opts.SpineBufferLimits != nil {
+		if err := c.connection.SetSpineBufferLimits(*opts.SpineBufferLimits); err != nil {
+			c.connection.CloseConnection(false, 0, "")
+			return nil, err
+		}
+	}
+
+	c.connection.SetPayloadInterceptors(opts.Interceptors...)
+	c.connection.Run()
+
+	select {
+	case <-ctx.Done():
+		c.connection.CloseConnection(false, 0, "")
+		return nil, ctx.Err()
+	case err := <-c.handshakeResult:
+		// on failures the connection is closed by the SHIP handshake itself
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// Returns the SKI of the remote service
+func (c *Conn) RemoteSKI() string {
+	return c.remoteSKI
+}
+
+// Returns the SHIP ID of the remote service
+func (c *Conn) RemoteShipID() string {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	return c.remoteShipID
+}
+
+// Returns the current SHIP state and error value if the state is in error
+func (c *Conn) State() (model.ShipMessageExchangeState, error) {
+	return c.connection.ShipHandshakeState()
+}
+
+// Send a SPINE payload to the remote service
+func (c *Conn) Send(payload []byte) error {
+	select {
+	case <-c.closed:
+		return ErrConnectionClosed
+	default:
+	}
+
+	return c.connection.sendSpineData(payload)
+}
+
+// Wait for the next SPINE payload of the remote service
+//
+// queued payloads are returned first, afterwards ErrConnectionClosed
+// is returned once the connection is closed. If the connection was closed as
+// the receive queue was full, the error also wraps ErrReceiveQueueFull
+func (c *Conn) Receive(ctx context.Context) ([]byte, error) {
+	select {
+	case payload := <-c.payloads:
+		return payload, nil
+	default:
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-c.closed:
+		return nil, c.closeError()
+	case payload := <-c.payloads:
+		return payload, nil
+	}
+}
+
+// Returns the number of incoming SPINE payloads which were dropped,
+// as the receive queue was full or the connection was already closed
+func (c *Conn) DroppedPayloads() uint64 {
+	return c.droppedPayloads.Load()
+}
+
+// the error returned by Receive once the connection is closed
+func (c *Conn) closeError() error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if c.closeErr != nil {
+		return fmt.Errorf("%w: %w", ErrConnectionClosed, c.closeErr)
+	}
+
+	return ErrConnectionClosed
+}
+
+// Returns a channel which is closed once the connection is closed
+func (c *Conn) Done() <-chan struct{} {
+	return c.closed
+}
+
+// Close the connection
+func (c *Conn) Close() {
+	c.connection.CloseConnection(true, 0, "close")
+}
+
+// report the result of the handshake once
+func (c *Conn) reportHandshakeResult(err error) {
+	select {
+	case c.handshakeResult <- err:
+	default:
+	}
+}
+
+var _ api.ShipConnectionInfoProviderInterface = (*Conn)(nil)
+
+// check if the SKI is trusted
+func (c *Conn) IsRemoteServiceForSKIPaired(ski string) bool {
+	return c.options.Trust != nil && c.options.Trust(ski)
+}
+
+// auto accept is never enabled, trust is decided by the trust callback
+func (c *Conn) IsAutoAcceptEnabled() bool {
+	return false
+}
+
+// report closing of the connection
+func (c *Conn) HandleConnectionClosed(connection api.ShipConnectionInterface, handshakeCompleted bool) {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+	})
+
+	c.reportHandshakeResult(ErrConnectionClosed)
+}
+
+// report the ship ID provided during the handshake
+func (c *Conn) ReportServiceShipID(ski string, shipID string) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	c.remoteShipID = shipID
+}
+
+// there is no user interaction, so waiting for trust is not possible
+func (c *Conn) AllowWaitingForTrust(ski string) bool {
+	return false
+}
+
+// report the updated SHIP handshake state
+func (c *Conn) HandleShipHandshakeStateUpdate(ski string, state model.ShipState) {
+	if c.options.StateUpdate != nil {
+		c.options.StateUpdate(state)
+	}
+
+	switch state.State {
+	case model.SmeStateComplete:
+		c.reportHandshakeResult(nil)
+	case model.SmeStateError:
+		err := state.Error
+		if err == nil {
+			err = errors.New("ship handshake error")
+		}
+		c.reportHandshakeResult(err)
+	case model.SmeHelloStateAbortDone, model.SmeHelloStateRemoteAbortDone, model.SmeHelloStateRejected:
+		c.reportHandshakeResult(ErrHandshakeAborted)
+	}
+}
+
+// the handshake is approved, incoming payloads are provided via Receive
+func (c *Conn) SetupRemoteDevice(ski string, writeI api.ShipConnectionDataWriterInterface) api.ShipConnectionDataReaderInterface {
+	return c
+}
+
+var _ api.ShipConnectionDataReaderInterface = (*Conn)(nil)
+
+// queue an incoming SPINE payload for Receive
+//
+// this is invoked by the websocket read loop and must not block it,
+// so the connection is closed with ErrReceiveQueueFull if Receive does not keep up
+func (c *Conn) HandleShipPayloadMessage(message []byte) {
+	select {
+	case <-c.closed:
+		logging.Log().Debug(c.remoteSKI, "dropping payload of closed connection")
+		c.droppedPayloads.Add(1)
+		return
+	default:
+	}
+
+	select {
+	case c.payloads <- message:
+		return
+	default:
+	}
+
+	logging.Log().Errorf("%s: receive queue is full, closing the connection", c.remoteSKI)
+	c.droppedPayloads.Add(1)
+
+	c.mux.Lock()
+	if c.closeErr == nil {
+		c.closeErr = ErrReceiveQueueFull
+	}
+	connection := c.connection
+	c.mux.Unlock()
+
+	// closed asynchronously, as this is invoked while the connection processes the payload
+	go connection.CloseConnection(false, 0, ErrReceiveQueueFull.Error())
+}
+
+// a net.Listener which provides a single connection
+type singleConnListener struct {
+	conn   net.Conn
+	accept chan net.Conn
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newSingleConnListener(conn net.Conn) *singleConnListener {
+	l := &singleConnListener{
+		conn:   conn,
+		accept: make(chan net.Conn, 1),
+		closed: make(chan struct{}),
+	}
+	l.accept <- conn
+
+	return l
+}
+
+var _ net.Listener = (*singleConnListener)(nil)
+
+func (l *singleConnListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.accept:
+		return conn, nil
+	case <-l.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *singleConnListener) Close() error {
+	l.once.Do(func() {
+		close(l.closed)
+	})
+
+	return nil
+}
+
+func (l *singleConnListener) Addr() net.Addr {
+	return l.conn.LocalAddr()
+}
@@ -0,0 +1,392 @@
+package ship
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/enbility/ship-go/cert"
+	"github.com/enbility/ship-go/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestStandaloneSuite(t *testing.T) {
+	suite.Run(t, new(StandaloneSuite))
+}
+
+type StandaloneSuite struct {
+	suite.Suite
+
+	serverCert, clientCert tls.Certificate
+	serverSKI, clientSKI   string
+
+	listener net.Listener
+}
+
+func (s *StandaloneSuite) SetupSuite() {
+	var err error
+	s.serverCert, err = cert.CreateCertificate("unit", "org", "DE", "server")
+	assert.Nil(s.T(), err)
+	s.serverSKI = s.skiOf(s.serverCert)
+
+	s.clientCert, err = cert.CreateCertificate("unit", "org", "DE", "client")
+	assert.Nil(s.T(), err)
+	s.clientSKI = s.skiOf(s.clientCert)
+}
+
+func (s *StandaloneSuite) skiOf(certificate tls.Certificate) string {
+	leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+	assert.Nil(s.T(), err)
+
+	ski, err := cert.SkiFromCertificate(leaf)
+	assert.Nil(s.T(), err)
+
+	return ski
+}
+
+// create a certificate with a new key, which claims the SKI of another certificate
+func (s *StandaloneSuite) forgedCertificate(ski string) tls.Certificate {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(s.T(), err)
+
+	skiBytes, err := hex.DecodeString(ski)
+	assert.Nil(s.T(), err)
+
+	template := &x509.Certificate{
+		SignatureAlgorithm: x509.ECDSAWithSHA256,
+		SerialNumber:       big.NewInt(1),
+		Subject:            pkix.Name{CommonName: "forged"},
+		NotBefore:          time.Now().Add(-time.Hour),
+		NotAfter:           time.Now().Add(time.Hour),
+		SubjectKeyId:       skiBytes,
+		KeyUsage:           x509.KeyUsageDigitalSignature,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	assert.Nil(s.T(), err)
+
+	return tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  privateKey,
+	}
+}
+
+func (s *StandaloneSuite) BeforeTest(suiteName, testName string) {
+	var err error
+	s.listener, err = tls.Listen("tcp", "127.0.0.1:0", ServerTLSConfig(s.serverCert))
+	assert.Nil(s.T(), err)
+}
+
+func (s *StandaloneSuite) AfterTest(suiteName, testName string) {
+	_ = s.listener.Close()
+}
+
+type acceptResult struct {
+	conn *Conn
+	err  error
+}
+
+func (s *StandaloneSuite) accept(opts Options) chan acceptResult {
+	result := make(chan acceptResult, 1)
+
+	go func() {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			result <- acceptResult{err: err}
+			return
+		}
+
+		shipConn, err := Accept(context.Background(), conn.(*tls.Conn), opts)
+		result <- acceptResult{conn: shipConn, err: err}
+	}()
+
+	return result
+}
+
+func (s *StandaloneSuite) trust(ski string) func(string) bool {
+	return func(remoteSki string) bool {
+		return remoteSki == ski
+	}
+}
+
+func (s *StandaloneSuite) Test_DialAccept() {
+	serverResult := s.accept(Options{
+		LocalShipID: "server",
+		Trust:       s.trust(s.clientSKI),
+	})
+
+	var states []model.ShipMessageExchangeState
+	var mux sync.Mutex
+	client, err := Dial(context.Background(), s.listener.Addr().String(), s.clientCert, Options{
+		LocalShipID: "client",
+		RemoteSKI:   s.serverSKI,
+		Trust:       s.trust(s.serverSKI),
+		StateUpdate: func(state model.ShipState) {
+			mux.Lock()
+			defer mux.Unlock()
+
+			states = append(states, state.State)
+		},
+	})
+	assert.Nil(s.T(), err)
+	assert.NotNil(s.T(), client)
+
+	server := <-serverResult
+	assert.Nil(s.T(), server.err)
+	assert.NotNil(s.T(), server.conn)
+
+	assert.Equal(s.T(), s.serverSKI, client.RemoteSKI())
+	assert.Equal(s.T(), s.clientSKI, server.conn.RemoteSKI())
+	assert.Equal(s.T(), "server", client.RemoteShipID())
+	assert.Equal(s.T(), "client", server.conn.RemoteShipID())
+	mux.Lock()
+	assert.Contains(s.T(), states, model.SmeStateComplete)
+	mux.Unlock()
+
+	state, err := client.State()
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), model.SmeStateComplete, state)
+
+	payload := []byte(`{"datagram":{"header":{},"payload":{"cmd":[]}}}`)
+	err = client.Send(payload)
+	assert.Nil(s.T(), err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	received, err := server.conn.Receive(ctx)
+	assert.Nil(s.T(), err)
+	assert.Contains(s.T(), string(received), "datagram")
+
+	client.Close()
+
+	select {
+	case <-server.conn.Done():
+	case <-time.After(5 * time.Second):
+		s.T().Fatal("server connection was not closed")
+	}
+
+	_, err = server.conn.Receive(ctx)
+	assert.ErrorIs(s.T(), err, ErrConnectionClosed)
+
+	err = server.conn.Send(payload)
+	assert.ErrorIs(s.T(), err, ErrConnectionClosed)
+}
+
+func (s *StandaloneSuite) Test_Dial_Untrusted() {
+	serverResult := s.accept(Options{
+		LocalShipID: "server",
+		Trust:       s.trust(s.clientSKI),
+	})
+
+	client, err := Dial(context.Background(), s.listener.Addr().String(), s.clientCert, Options{
+		LocalShipID: "client",
+	})
+	assert.ErrorIs(s.T(), err, ErrUntrustedRemoteService)
+	assert.Nil(s.T(), client)
+
+	server := <-serverResult
+	assert.NotNil(s.T(), server.err)
+}
+
+func (s *StandaloneSuite) Test_ForgedSKI() {
+	// an incoming connection claiming the SKI of the trusted client
+	serverResult := s.accept(Options{
+		LocalShipID: "server",
+		Trust:       s.trust(s.clientSKI),
+	})
+
+	client, err := Dial(context.Background(), s.listener.Addr().String(), s.forgedCertificate(s.clientSKI), Options{
+		LocalShipID: "client",
+		Trust:       s.trust(s.serverSKI),
+	})
+	assert.NotNil(s.T(), err)
+	assert.Nil(s.T(), client)
+
+	server := <-serverResult
+	assert.ErrorIs(s.T(), server.err, cert.ErrSkiMismatch)
+	assert.Nil(s.T(), server.conn)
+
+	// an outgoing connection to a server claiming the SKI of the trusted server
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", ServerTLSConfig(s.forgedCertificate(s.serverSKI)))
+	assert.Nil(s.T(), err)
+	defer listener.Close()
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		_, _ = Accept(context.Background(), conn.(*tls.Conn), Options{
+			LocalShipID: "server",
+			Trust:       s.trust(s.clientSKI),
+		})
+	}()
+
+	client, err = Dial(context.Background(), listener.Addr().String(), s.clientCert, Options{
+		LocalShipID: "client",
+		Trust:       s.trust(s.serverSKI),
+	})
+	assert.ErrorIs(s.T(), err, cert.ErrSkiMismatch)
+	assert.Nil(s.T(), client)
+}
+
+func (s *StandaloneSuite) Test_Dial_WrongSKI() {
+	serverResult := s.accept(Options{
+		LocalShipID: "server",
+		Trust:       s.trust(s.clientSKI),
+	})
+
+	client, err := Dial(context.Background(), s.listener.Addr().String(), s.clientCert, Options{
+		LocalShipID: "client",
+		RemoteSKI:   s.clientSKI,
+		Trust:       s.trust(s.serverSKI),
+	})
+	assert.NotNil(s.T(), err)
+	assert.Nil(s.T(), client)
+
+	server := <-serverResult
+	assert.NotNil(s.T(), server.err)
+}
+
+func (s *StandaloneSuite) Test_Accept_WrongSKI() {
+	serverResult := s.accept(Options{
+		LocalShipID: "server",
+		RemoteSKI:   s.serverSKI,
+		Trust:       s.trust(s.clientSKI),
+	})
+
+	client, err := Dial(context.Background(), s.listener.Addr().String(), s.clientCert, Options{
+		LocalShipID: "client",
+		Trust:       s.trust(s.serverSKI),
+	})
+	assert.NotNil(s.T(), err)
+	assert.Nil(s.T(), client)
+
+	server := <-serverResult
+	assert.ErrorIs(s.T(), server.err, ErrRemoteSKIMismatch)
+	assert.Nil(s.T(), server.conn)
+}
+
+func (s *StandaloneSuite) Test_ReceiveQueueFull() {
+	serverResult := s.accept(Options{
+		LocalShipID: "server",
+		Trust:       s.trust(s.clientSKI),
+	})
+
+	client, err := Dial(context.Background(), s.listener.Addr().String(), s.clientCert, Options{
+		LocalShipID: "client",
+		Trust:       s.trust(s.serverSKI),
+	})
+	assert.Nil(s.T(), err)
+
+	server := <-serverResult
+	assert.Nil(s.T(), server.err)
+
+	// the client does not receive, so the queue fills up and the client closes the connection
+	for i := 0; i < receiveQueueSize+1; i++ {
+		if err := server.conn.Send([]byte(fmt.Sprintf(`{"datagram":{"header":{"msgCounter":%d},"payload":{"cmd":[]}}}`, i))); err != nil {
+			break
+		}
+	}
+
+	select {
+	case <-client.Done():
+	case <-time.After(5 * time.Second):
+		s.T().Fatal("connection was not closed on a full receive queue")
+	}
+	assert.Equal(s.T(), uint64(1), client.DroppedPayloads())
+
+	// the queued payloads are still provided
+	for i := 0; i < receiveQueueSize; i++ {
+		payload, err := client.Receive(context.Background())
+		assert.Nil(s.T(), err)
+		assert.Contains(s.T(), string(payload), fmt.Sprintf(`"msgCounter":%d}`, i))
+	}
+
+	_, err = client.Receive(context.Background())
+	assert.ErrorIs(s.T(), err, ErrConnectionClosed)
+	assert.ErrorIs(s.T(), err, ErrReceiveQueueFull)
+
+	server.conn.Close()
+}
+
+func (s *StandaloneSuite) Test_Accept_Canceled() {
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan acceptResult, 1)
+
+	go func() {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			result <- acceptResult{err: err}
+			return
+		}
+
+		shipConn, err := Accept(ctx, conn.(*tls.Conn), Options{LocalShipID: "server"})
+		result <- acceptResult{conn: shipConn, err: err}
+	}()
+
+	// the client does not complete the TLS handshake
+	conn, err := net.Dial("tcp", s.listener.Addr().String())
+	assert.Nil(s.T(), err)
+	defer conn.Close()
+
+	cancel()
+
+	select {
+	case server := <-result:
+		assert.ErrorIs(s.T(), server.err, context.Canceled)
+		assert.Nil(s.T(), server.conn)
+	case <-time.After(5 * time.Second):
+		s.T().Fatal("Accept was not canceled")
+	}
+}
+
+func (s *StandaloneSuite) Test_Accept_Untrusted() {
+	serverResult := s.accept(Options{
+		LocalShipID: "server",
+	})
+
+	client, err := Dial(context.Background(), s.listener.Addr().String(), s.clientCert, Options{
+		LocalShipID: "client",
+		Trust:       s.trust(s.serverSKI),
+	})
+	assert.ErrorIs(s.T(), err, ErrHandshakeAborted)
+	assert.Nil(s.T(), client)
+
+	server := <-serverResult
+	assert.ErrorIs(s.T(), server.err, ErrHandshakeAborted)
+	assert.Nil(s.T(), server.conn)
+}
+
+func (s *StandaloneSuite) Test_InvalidTimings() {
+	serverResult := s.accept(Options{
+		LocalShipID: "server",
+		Trust:       s.trust(s.clientSKI),
+	})
+
+	timings := DefaultHandshakeTimings()
+	timings.CmiTimeout = time.Millisecond
+	client, err := Dial(context.Background(), s.listener.Addr().String(), s.clientCert, Options{
+		LocalShipID:      "client",
+		Trust:            s.trust(s.serverSKI),
+		HandshakeTimings: &timings,
+	})
+	assert.ErrorIs(s.T(), err, ErrInvalidHandshakeTimings)
+	assert.Nil(s.T(), client)
+
+	server := <-serverResult
+	assert.NotNil(s.T(), server.err)
+}
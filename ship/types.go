@@ -1,14 +1,17 @@
 package ship
 
 import (
+	"errors"
+	"fmt"
 	"time"
 )
 
-type shipRole string
+// the role of this service in a SHIP connection
+type ShipRole string
 
 const (
-	ShipRoleServer shipRole = "server"
-	ShipRoleClient shipRole = "client"
+	ShipRoleServer ShipRole = "server"
+	ShipRoleClient ShipRole = "client"
 )
 
 const (
@@ -21,6 +24,144 @@ const (
 	tHelloProlongMin        = 1 * time.Second
 )
 
+// the lowest values the SHIP specification allows for the handshake timers
+const (
+	cmiTimeoutMin       = 10 * time.Second // SHIP 4.2
+	tHelloInitMin       = 60 * time.Second // SHIP 13.4.4.1.3
+	tHelloIncMin        = 60 * time.Second // SHIP 13.4.4.1.3
+	tHelloProlongMinMin = 1 * time.Second  // SHIP 13.4.4.1.3
+)
+
+// Timer values used during the SHIP handshake
+//
+// The default values are provided by DefaultHandshakeTimings
+type HandshakeTimings struct {
+	// SHIP 4.2: timeout for receiving the next message during CMI, protocol and access methods handshake
+	CmiTimeout time.Duration
+
+	// SHIP 13.4.4.1.3: initial value of the Wait-For-Ready-Timer
+	HelloInit time.Duration
+
+	// SHIP 13.4.4.1.3: increment of the Wait-For-Ready-Timer on a prolongation request
+	HelloInc time.Duration
+
+	// SHIP 13.4.4.1.3: threshold used to send a prolongation request before the remote timer expires
+	HelloProlongThrInc time.Duration
+
+	// SHIP 13.4.4.1.3: gap between sending a prolongation request and the remote timer expiring
+	HelloProlongWaitingGap time.Duration
+
+	// SHIP 13.4.4.1.3: minimum waiting value which still allows a prolongation request
+	HelloProlongMin time.Duration
+}
+
+// Returns the handshake timer values used if nothing else is configured
+func DefaultHandshakeTimings() HandshakeTimings {
+	return HandshakeTimings{
+		CmiTimeout:             cmiTimeout,
+		HelloInit:              tHelloInit,
+		HelloInc:               tHelloInc,
+		HelloProlongThrInc:     tHelloProlongThrInc,
+		HelloProlongWaitingGap: tHelloProlongWaitingGap,
+		HelloProlongMin:        tHelloProlongMin,
+	}
+}
+
+// ErrInvalidHandshakeTimings is returned if a handshake timer value violates the SHIP specification
+var ErrInvalidHandshakeTimings = errors.New("invalid handshake timings")
+
+// Check the timer values against the minimums defined in the SHIP specification
+func (t HandshakeTimings) Validate() error {
+	minimums := []struct {
+		name         string
+		value, lower time.Duration
+	}{
+		{"CmiTimeout", t.CmiTimeout, cmiTimeoutMin},
+		{"HelloInit", t.HelloInit, tHelloInitMin},
+		{"HelloInc", t.HelloInc, tHelloIncMin},
+		{"HelloProlongMin", t.HelloProlongMin, tHelloProlongMinMin},
+	}
+	for _, item := range minimums {
+		if item.value < item.lower {
+			return fmt.Errorf("%w: %s is %s, minimum is %s", ErrInvalidHandshakeTimings, item.name, item.value, item.lower)
+		}
+	}
+
+	if t.HelloProlongWaitingGap <= 0 || t.HelloProlongWaitingGap >= t.HelloProlongThrInc {
+		return fmt.Errorf("%w: HelloProlongWaitingGap has to be positive and less than HelloProlongThrInc", ErrInvalidHandshakeTimings)
+	}
+
+	// the prolongation request has to be sent before the remote timer expires
+	if t.HelloProlongThrInc >= t.HelloInit {
+		return fmt.Errorf("%w: HelloProlongThrInc has to be less than HelloInit", ErrInvalidHandshakeTimings)
+	}
+
+	return nil
+}
+
+// What happens if a SPINE message received before the handshake completed exceeds the buffer limits
+type SpineBufferOverflowPolicy uint
+
+const (
+	// drop the oldest buffered messages until the new message fits,
+	// a message exceeding the size limit on its own is dropped instead
+	SpineBufferDropOldest SpineBufferOverflowPolicy = iota
+	// drop the new message
+	SpineBufferDropNewest
+	// close the connection with ErrSpineBufferOverflow
+	SpineBufferClose
+)
+
+// the default limits of the SPINE message buffer
+const (
+	spineBufferMaxMessages = 100
+	spineBufferMaxBytes    = 1024 * 1024
+)
+
+// Limits of the buffer for SPINE messages received before the SHIP handshake completed
+//
+// Buffered messages are passed on once the handshake completed. If the handshake
+// does not complete, e.g. because it is aborted, the buffered messages are discarded.
+// The default values are provided by DefaultSpineBufferLimits
+type SpineBufferLimits struct {
+	// the maximum number of buffered messages, 0 means no limit
+	MaxMessages int
+
+	// the maximum size of all buffered messages in bytes, 0 means no limit
+	MaxBytes int
+
+	// what happens if a limit would be exceeded
+	OverflowPolicy SpineBufferOverflowPolicy
+}
+
+// Returns the SPINE message buffer limits used if nothing else is configured
+func DefaultSpineBufferLimits() SpineBufferLimits {
+	return SpineBufferLimits{
+		MaxMessages:    spineBufferMaxMessages,
+		MaxBytes:       spineBufferMaxBytes,
+		OverflowPolicy: SpineBufferDropOldest,
+	}
+}
+
+// ErrInvalidSpineBufferLimits is returned if the SPINE message buffer limits can not be used
+var ErrInvalidSpineBufferLimits = errors.New("invalid spine buffer limits")
+
+// ErrSpineBufferOverflow is the error of a connection closed because the SPINE message buffer limits were exceeded
+var ErrSpineBufferOverflow = errors.New("spine buffer overflow")
+
+// Check if the SPINE message buffer limits can be used
+func (l SpineBufferLimits) Validate() error {
+	if l.MaxMessages < 0 || l.MaxBytes < 0 {
+		return fmt.Errorf("%w: limits may not be negative", ErrInvalidSpineBufferLimits)
+	}
+
+	if l.OverflowPolicy > SpineBufferClose {
+		return fmt.Errorf("%w: unknown overflow policy %d", ErrInvalidSpineBufferLimits, l.OverflowPolicy)
+	}
+
+	return nil
+}
+
 type timeoutTimerType uint
 
 const (
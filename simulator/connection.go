@@ -0,0 +1,279 @@
+package simulator
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	"github.com/enbility/ship-go/api"
+	"github.com/enbility/ship-go/logging"
+	"github.com/enbility/ship-go/model"
+	"github.com/enbility/ship-go/ship"
+	"github.com/enbility/ship-go/ws"
+	"github.com/gorilla/websocket"
+)
+
+// the maximum time to wait for a handshake state required by a step
+const stateTimeout = 30 * time.Second
+
+// the interval used to check the handshake state
+const stateCheckInterval = 50 * time.Millisecond
+
+// a truncated protocol handshake message, sent by the malformed-protocol-handshake action
+var malformedProtocolHandshake = []byte(`{"messageProtocolHandshake":[{"handshakeType":"select"},{"version":`)
+
+// A simulated connection following the scenario
+type connection struct {
+	simulator *Simulator
+	remoteSKI string
+
+	dataHandler    *ws.WebsocketConnection
+	shipConnection *ship.ShipConnection
+
+	// the SPINE data writer of the completed connection
+	spineWriter api.ShipConnectionDataWriterInterface
+
+	// the malformed protocol handshake message was sent
+	malformedSent bool
+
+	echo bool
+
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	mux sync.Mutex
+}
+
+func newConnection(simulator *Simulator, conn *websocket.Conn, remoteSKI string) *connection {
+	c := &connection{
+		simulator:   simulator,
+		remoteSKI:   remoteSKI,
+		dataHandler: ws.NewWebsocketConnection(conn, remoteSKI),
+		closed:      make(chan struct{}),
+	}
+
+	scenario := simulator.config.Scenario
+
+	shipID := simulator.config.ShipID
+	if step, ok := scenario.step(ActionShipIDMismatch); ok {
+		shipID = step.ShipID
+		if shipID == "" {
+			shipID = simulator.config.ShipID + "-mismatch"
+		}
+	}
+
+	c.shipConnection = ship.NewConnectionHandler(c, &dataWriter{connection: c}, ship.ShipRoleServer, shipID, remoteSKI, "")
+	if simulator.config.HandshakeTimings != nil {
+		// the values are validated in New
+		_ = c.shipConnection.SetHandshakeTimings(*simulator.config.HandshakeTimings)
+	}
+
+	return c
+}
+
+// start the SHIP handshake and run the scenario steps
+func (c *connection) run() {
+	c.shipConnection.Run()
+
+	for _, step := range c.simulator.config.Scenario.Steps {
+		if c.isClosed() {
+			return
+		}
+
+		logging.Log().Debug(c.remoteSKI, "simulator: running step", step.Action)
+
+		switch step.Action {
+		case ActionAutoAccept, ActionAccept:
+			if c.waitForStates(model.SmeHelloStatePendingListen, model.SmeHelloStateOk, model.SmeStateComplete) {
+				c.shipConnection.ApprovePendingHandshake()
+			}
+
+		case ActionPending:
+			if c.waitForStates(model.SmeHelloStatePendingListen) {
+				c.wait(time.Duration(step.Duration))
+			}
+
+		case ActionAbort:
+			if c.waitForStates(model.SmeHelloStatePendingListen, model.SmeHelloStateReadyListen) {
+				c.shipConnection.AbortPendingHandshake()
+			}
+
+		case ActionClose:
+			code := step.Code
+			if code == 0 {
+				code = rejectCloseCode
+			}
+			reason := step.Reason
+			if reason == "" {
+				reason = rejectCloseReason
+			}
+			c.shipConnection.CloseConnection(false, code, reason)
+
+		case ActionEcho:
+			c.mux.Lock()
+			c.echo = true
+			c.mux.Unlock()
+
+		case ActionWait:
+			c.wait(time.Duration(step.Duration))
+		}
+	}
+}
+
+// wait until the handshake reached one of the states
+//
+// returns false if the connection was closed or the state was not reached in time
+func (c *connection) waitForStates(states ...model.ShipMessageExchangeState) bool {
+	timeout := time.NewTimer(stateTimeout)
+	defer timeout.Stop()
+
+	ticker := time.NewTicker(stateCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		state, _ := c.shipConnection.ShipHandshakeState()
+		for _, item := range states {
+			if state == item {
+				return true
+			}
+		}
+
+		select {
+		case <-c.closed:
+			return false
+		case <-timeout.C:
+			logging.Log().Debug(c.remoteSKI, "simulator: timeout waiting for states", states, "current state", state)
+			return false
+		case <-ticker.C:
+		}
+	}
+}
+
+// wait for the duration or until the connection is closed
+func (c *connection) wait(duration time.Duration) {
+	select {
+	case <-c.closed:
+	case <-time.After(duration):
+	}
+}
+
+func (c *connection) isClosed() bool {
+	select {
+	case <-c.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *connection) close() {
+	c.shipConnection.CloseConnection(false, 0, "")
+}
+
+var _ api.ShipConnectionInfoProviderInterface = (*connection)(nil)
+
+func (c *connection) IsRemoteServiceForSKIPaired(string) bool {
+	return c.simulator.config.Scenario.trusted()
+}
+
+func (c *connection) IsAutoAcceptEnabled() bool {
+	return false
+}
+
+func (c *connection) HandleConnectionClosed(api.ShipConnectionInterface, bool) {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+	})
+
+	c.simulator.removeConnection(c)
+}
+
+func (c *connection) ReportServiceShipID(string, string) {}
+
+// the steps decide when a pending connection is approved or aborted
+func (c *connection) AllowWaitingForTrust(string) bool {
+	return true
+}
+
+func (c *connection) HandleShipHandshakeStateUpdate(ski string, state model.ShipState) {
+	logging.Log().Debug(ski, "simulator: SHIP state", state.State, state.Error)
+
+	if c.simulator.config.StateUpdate != nil {
+		c.simulator.config.StateUpdate(ski, state)
+	}
+}
+
+func (c *connection) SetupRemoteDevice(ski string, writer api.ShipConnectionDataWriterInterface) api.ShipConnectionDataReaderInterface {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	c.spineWriter = writer
+
+	return c
+}
+
+var _ api.ShipConnectionDataReaderInterface = (*connection)(nil)
+
+// send the payload back if the echo action was run
+func (c *connection) HandleShipPayloadMessage(message []byte) {
+	c.mux.Lock()
+	echo := c.echo
+	writer := c.spineWriter
+	c.mux.Unlock()
+
+	if !echo || writer == nil {
+		return
+	}
+
+	writer.WriteShipMessageWithPayload(message)
+}
+
+// replace the protocol handshake message with a malformed one, if required by the scenario
+func (c *connection) rewriteMessage(message []byte) []byte {
+	if _, ok := c.simulator.config.Scenario.step(ActionMalformedProtocolHandshake); !ok {
+		return message
+	}
+
+	if len(message) < 2 || message[0] != model.MsgTypeControl ||
+		!bytes.Contains(message, []byte(`"messageProtocolHandshake"`)) {
+		return message
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if c.malformedSent {
+		return message
+	}
+	c.malformedSent = true
+
+	return append([]byte{model.MsgTypeControl}, malformedProtocolHandshake...)
+}
+
+// Passes the messages of the ShipConnection to the websocket connection,
+// so outgoing messages can be changed by the scenario
+type dataWriter struct {
+	connection *connection
+}
+
+var _ api.WebsocketDataWriterInterface = (*dataWriter)(nil)
+
+func (w *dataWriter) InitDataProcessing(reader api.WebsocketDataReaderInterface) {
+	w.connection.dataHandler.InitDataProcessing(reader)
+}
+
+func (w *dataWriter) WriteMessageToWebsocketConnection(message []byte) error {
+	return w.connection.dataHandler.WriteMessageToWebsocketConnection(w.connection.rewriteMessage(message))
+}
+
+func (w *dataWriter) CloseDataConnection(closeCode int, reason string) {
+	w.connection.dataHandler.CloseDataConnection(closeCode, reason)
+}
+
+func (w *dataWriter) IsDataConnectionClosed() (bool, error) {
+	return w.connection.dataHandler.IsDataConnectionClosed()
+}
+
+func (w *dataWriter) Latency() api.WebsocketLatency {
+	return w.connection.dataHandler.Latency()
+}
@@ -0,0 +1,195 @@
+package simulator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ErrInvalidScenario is returned if a scenario contains unknown actions or invalid values
+var ErrInvalidScenario = errors.New("invalid scenario")
+
+// An action of a scenario step
+type Action string
+
+const (
+	// Trust the remote service, so the hello phase completes without waiting.
+	// If the connection is pending, the pending handshake is approved
+	ActionAutoAccept Action = "auto-accept"
+
+	// Stay in the hello pending state for the step duration.
+	// Prolongation requests are sent by the ShipConnection when the remote waiting time runs out
+	ActionPending Action = "pending"
+
+	// Approve a pending handshake
+	ActionAccept Action = "accept"
+
+	// Abort the handshake by sending the hello phase aborted
+	ActionAbort Action = "abort"
+
+	// Close the websocket connection, by default with `4452: Node rejected by application.`
+	ActionClose Action = "close"
+
+	// Send an invalid JSON message instead of the protocol handshake message
+	ActionMalformedProtocolHandshake Action = "malformed-protocol-handshake"
+
+	// Report a SHIP ID in the access methods which differs from the announced one
+	ActionShipIDMismatch Action = "ship-id-mismatch"
+
+	// Send every received SPINE payload back to the remote service
+	ActionEcho Action = "echo"
+
+	// Wait for the step duration before running the next step
+	ActionWait Action = "wait"
+)
+
+// the close code and reason used by devices to reject a connection
+const (
+	rejectCloseCode   = 4452
+	rejectCloseReason = "Node rejected by application."
+)
+
+// A duration which is read from a JSON string like "30s" or a number of seconds
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	switch value := value.(type) {
+	case float64:
+		*d = Duration(value * float64(time.Second))
+	case string:
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		*d = Duration(duration)
+	default:
+		return fmt.Errorf("invalid duration %s", string(data))
+	}
+
+	return nil
+}
+
+// A step of a scenario
+type Step struct {
+	Action Action `json:"action"`
+
+	// the duration of the pending and wait actions
+	Duration Duration `json:"duration,omitempty"`
+
+	// optional, the close code and reason of the close action
+	Code   int    `json:"code,omitempty"`
+	Reason string `json:"reason,omitempty"`
+
+	// optional, the SHIP ID reported by the ship-id-mismatch action,
+	// defaults to the configured SHIP ID with the suffix "-mismatch"
+	ShipID string `json:"shipId,omitempty"`
+}
+
+// Describes the behaviour of the simulated SHIP service for each connection
+//
+// The steps are run in order for every incoming connection.
+// The malformed-protocol-handshake and ship-id-mismatch actions change the handshake
+// and are applied when the connection is created, independent of their position.
+// The remote service is trusted from the start if an auto-accept step comes before
+// any pending step, otherwise the hello phase stays pending until a step decides.
+//
+// Example:
+//
+//	{
+//	  "name": "pending then accept",
+//	  "steps": [
+//	    {"action": "pending", "duration": "30s"},
+//	    {"action": "accept"},
+//	    {"action": "echo"}
+//	  ]
+//	}
+type Scenario struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Steps       []Step `json:"steps"`
+}
+
+// Parse a scenario from JSON data and validate it
+func ParseScenario(data []byte) (*Scenario, error) {
+	var scenario Scenario
+	if err := json.Unmarshal(data, &scenario); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidScenario, err)
+	}
+
+	if err := scenario.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &scenario, nil
+}
+
+// Load a scenario from a JSON file and validate it
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseScenario(data)
+}
+
+// Check that all steps use known actions and valid values
+func (s *Scenario) Validate() error {
+	for index, step := range s.Steps {
+		switch step.Action {
+		case ActionPending, ActionWait:
+			if step.Duration <= 0 {
+				return fmt.Errorf("%w: step %d: %s requires a positive duration", ErrInvalidScenario, index, step.Action)
+			}
+
+		case ActionClose:
+			if step.Code != 0 && (step.Code < 1000 || step.Code > 4999) {
+				return fmt.Errorf("%w: step %d: invalid close code %d", ErrInvalidScenario, index, step.Code)
+			}
+
+		case ActionAutoAccept, ActionAccept, ActionAbort, ActionMalformedProtocolHandshake,
+			ActionShipIDMismatch, ActionEcho:
+
+		default:
+			return fmt.Errorf("%w: step %d: unknown action %q", ErrInvalidScenario, index, step.Action)
+		}
+	}
+
+	return nil
+}
+
+// Returns true if the remote service is trusted from the start
+func (s *Scenario) trusted() bool {
+	for _, step := range s.Steps {
+		switch step.Action {
+		case ActionAutoAccept:
+			return true
+		case ActionPending:
+			return false
+		}
+	}
+
+	return false
+}
+
+// Returns the step with the action, if it is part of the scenario
+func (s *Scenario) step(action Action) (Step, bool) {
+	for _, step := range s.Steps {
+		if step.Action == action {
+			return step, true
+		}
+	}
+
+	return Step{}, false
+}
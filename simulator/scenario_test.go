@@ -0,0 +1,75 @@
+package simulator
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseScenario(t *testing.T) {
+	data := []byte(`{
+		"name": "pending then accept",
+		"steps": [
+			{"action": "pending", "duration": "30s"},
+			{"action": "wait", "duration": 1.5},
+			{"action": "accept"},
+			{"action": "close", "code": 4001, "reason": "close"}
+		]
+	}`)
+
+	scenario, err := ParseScenario(data)
+	assert.Nil(t, err)
+	assert.Equal(t, "pending then accept", scenario.Name)
+	assert.Equal(t, 4, len(scenario.Steps))
+	assert.Equal(t, Duration(30*time.Second), scenario.Steps[0].Duration)
+	assert.Equal(t, Duration(1500*time.Millisecond), scenario.Steps[1].Duration)
+	assert.Equal(t, 4001, scenario.Steps[3].Code)
+	assert.False(t, scenario.trusted())
+
+	encoded, err := json.Marshal(scenario.Steps[0])
+	assert.Nil(t, err)
+	assert.Equal(t, `{"action":"pending","duration":"30s"}`, string(encoded))
+
+	invalid := []string{
+		`invalid`,
+		`{"steps": [{"action": "unknown"}]}`,
+		`{"steps": [{"action": "pending"}]}`,
+		`{"steps": [{"action": "wait", "duration": "invalid"}]}`,
+		`{"steps": [{"action": "wait", "duration": true}]}`,
+		`{"steps": [{"action": "close", "code": 1}]}`,
+	}
+	for _, item := range invalid {
+		_, err = ParseScenario([]byte(item))
+		assert.NotNil(t, err, item)
+	}
+}
+
+func TestScenarioTrusted(t *testing.T) {
+	scenario := Scenario{Steps: []Step{{Action: ActionEcho}, {Action: ActionAutoAccept}}}
+	assert.True(t, scenario.trusted())
+
+	scenario = Scenario{Steps: []Step{{Action: ActionPending, Duration: Duration(time.Second)}, {Action: ActionAutoAccept}}}
+	assert.False(t, scenario.trusted())
+
+	_, ok := scenario.step(ActionAutoAccept)
+	assert.True(t, ok)
+	_, ok = scenario.step(ActionEcho)
+	assert.False(t, ok)
+}
+
+func TestLoadScenario(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scenario.json")
+	err := os.WriteFile(path, []byte(`{"name": "reject", "steps": [{"action": "close"}]}`), 0o600)
+	assert.Nil(t, err)
+
+	scenario, err := LoadScenario(path)
+	assert.Nil(t, err)
+	assert.Equal(t, ActionClose, scenario.Steps[0].Action)
+
+	_, err = LoadScenario(filepath.Join(t.TempDir(), "missing.json"))
+	assert.NotNil(t, err)
+}
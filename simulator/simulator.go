@@ -0,0 +1,263 @@
+// Package simulator provides a scriptable SHIP service for integration tests.
+//
+// The simulator accepts incoming SHIP connections and follows a scenario for each of them,
+// e.g. to stay pending, reject a connection or send invalid handshake messages.
+// It can be announced via mDNS or used on a static port.
+package simulator
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/enbility/ship-go/api"
+	"github.com/enbility/ship-go/cert"
+	"github.com/enbility/ship-go/logging"
+	"github.com/enbility/ship-go/mdns"
+	"github.com/enbility/ship-go/model"
+	"github.com/enbility/ship-go/ship"
+	"github.com/enbility/ship-go/ws"
+	"github.com/gorilla/websocket"
+)
+
+// The configuration of a simulator
+type Config struct {
+	// The certificate of the simulated service
+	Certificate tls.Certificate
+
+	// The port of the websocket server, 0 selects a free port
+	Port int
+
+	// The SHIP ID of the simulated service
+	ShipID string
+
+	// Optional, the device details announced via mDNS
+	Brand      string
+	Model      string
+	Type       string
+	Serial     string
+	Categories []api.DeviceCategoryType
+
+	// Announce the service via mDNS
+	Mdns bool
+
+	// Optional, the network interfaces used for mDNS, default all
+	Interfaces []string
+
+	// Optional, the mDNS provider
+	MdnsProvider mdns.MdnsProviderSelection
+
+	// The scenario run for every connection
+	Scenario *Scenario
+
+	// Optional, the timer values used for the SHIP handshake
+	HandshakeTimings *ship.HandshakeTimings
+
+	// Optional, invoked for every SHIP handshake state change of a connection
+	StateUpdate func(ski string, state model.ShipState)
+}
+
+// A simulated SHIP service
+type Simulator struct {
+	config Config
+
+	ski string
+
+	listener   net.Listener
+	httpServer *http.Server
+	mdns       *mdns.MdnsManager
+
+	connections map[*connection]struct{}
+
+	mux sync.Mutex
+}
+
+// Create a new simulator, Start has to be invoked to accept connections
+func New(config Config) (*Simulator, error) {
+	if config.ShipID == "" {
+		return nil, errors.New("the SHIP ID is required")
+	}
+
+	if config.Scenario == nil {
+		return nil, fmt.Errorf("%w: no scenario provided", ErrInvalidScenario)
+	}
+
+	if err := config.Scenario.Validate(); err != nil {
+		return nil, err
+	}
+
+	if config.HandshakeTimings != nil {
+		if err := config.HandshakeTimings.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(config.Certificate.Certificate) == 0 {
+		return nil, errors.New("no certificate provided")
+	}
+
+	leaf, err := x509.ParseCertificate(config.Certificate.Certificate[0])
+	if err != nil {
+		return nil, err
+	}
+
+	ski, err := cert.SkiFromCertificate(leaf)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Simulator{
+		config:      config,
+		ski:         ski,
+		connections: make(map[*connection]struct{}),
+	}, nil
+}
+
+// Returns the SKI of the simulated service
+func (s *Simulator) SKI() string {
+	return s.ski
+}
+
+// Start the websocket server and the mDNS announcement if enabled
+func (s *Simulator) Start() error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", s.config.Port))
+	if err != nil {
+		return err
+	}
+
+	s.mux.Lock()
+	s.listener = listener
+	s.httpServer = &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+		TLSConfig:         ship.ServerTLSConfig(s.config.Certificate),
+	}
+	httpServer := s.httpServer
+	s.mux.Unlock()
+
+	go func() {
+		if err := httpServer.ServeTLS(listener, "", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logging.Log().Error("simulator: websocket server error:", err)
+		}
+	}()
+
+	if !s.config.Mdns {
+		return nil
+	}
+
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	manager := mdns.NewMDNS(s.ski, s.config.Brand, s.config.Model, s.config.Type, s.config.Serial,
+		s.config.Categories, s.config.ShipID, s.config.ShipID, port, s.config.Interfaces, s.config.MdnsProvider)
+	manager.SetAutoAccept(s.config.Scenario.trusted())
+
+	if err := manager.Start(s); err != nil {
+		s.Close()
+		return err
+	}
+
+	s.mux.Lock()
+	s.mdns = manager
+	s.mux.Unlock()
+
+	return nil
+}
+
+// Returns the address of the websocket server, nil if it is not started
+func (s *Simulator) Addr() net.Addr {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if s.listener == nil {
+		return nil
+	}
+
+	return s.listener.Addr()
+}
+
+// Stop the mDNS announcement, the websocket server and close all connections
+func (s *Simulator) Close() {
+	s.mux.Lock()
+	manager := s.mdns
+	httpServer := s.httpServer
+	connections := make([]*connection, 0, len(s.connections))
+	for item := range s.connections {
+		connections = append(connections, item)
+	}
+	s.mdns = nil
+	s.httpServer = nil
+	s.mux.Unlock()
+
+	if manager != nil {
+		manager.Shutdown()
+	}
+
+	if httpServer != nil {
+		_ = httpServer.Close()
+	}
+
+	for _, item := range connections {
+		item.close()
+	}
+}
+
+var _ api.MdnsReportInterface = (*Simulator)(nil)
+
+// the simulator does not connect to other services, so mDNS entries are ignored
+func (s *Simulator) ReportMdnsEntries(entries map[string]*api.MdnsEntry, newEntries bool) {}
+
+// HTTP Server callback for handling incoming connection requests
+func (s *Simulator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{
+		ReadBufferSize:  ws.MaxMessageSize,
+		WriteBufferSize: ws.MaxMessageSize,
+		CheckOrigin:     func(r *http.Request) bool { return true },
+		Subprotocols:    []string{api.ShipWebsocketSubProtocol}, // SHIP 10.2: Sub protocol "ship" is required
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logging.Log().Debug("simulator: error during connection upgrading:", err)
+		return
+	}
+
+	if conn.Subprotocol() != api.ShipWebsocketSubProtocol {
+		logging.Log().Debug("simulator: client does not support the ship sub protocol")
+		_ = conn.Close()
+		return
+	}
+
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		logging.Log().Debug("simulator: client does not provide a certificate")
+		_ = conn.Close()
+		return
+	}
+
+	ski, err := cert.SkiFromCertificate(r.TLS.PeerCertificates[0])
+	if err != nil {
+		logging.Log().Debug("simulator:", err)
+		_ = conn.Close()
+		return
+	}
+
+	item := newConnection(s, conn, api.NewServiceDetails(ski).SKI())
+
+	s.mux.Lock()
+	s.connections[item] = struct{}{}
+	s.mux.Unlock()
+
+	go item.run()
+}
+
+// remove a closed connection
+func (s *Simulator) removeConnection(item *connection) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	delete(s.connections, item)
+}
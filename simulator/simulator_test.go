@@ -0,0 +1,160 @@
+package simulator
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/enbility/ship-go/cert"
+	"github.com/enbility/ship-go/ship"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+const testPayload = `{"datagram":{"header":{"specificationVersion":"1.3.0"},"payload":{"cmd":[{"nodeManagementDetailedDiscoveryData":{}}]}}}`
+
+func TestSimulatorSuite(t *testing.T) {
+	suite.Run(t, new(SimulatorSuite))
+}
+
+type SimulatorSuite struct {
+	suite.Suite
+
+	serverCert, clientCert tls.Certificate
+
+	sut *Simulator
+}
+
+func (s *SimulatorSuite) SetupSuite() {
+	var err error
+	s.serverCert, err = cert.CreateCertificate("unit", "org", "DE", "simulator")
+	assert.Nil(s.T(), err)
+
+	s.clientCert, err = cert.CreateCertificate("unit", "org", "DE", "client")
+	assert.Nil(s.T(), err)
+}
+
+func (s *SimulatorSuite) AfterTest(suiteName, testName string) {
+	if s.sut != nil {
+		s.sut.Close()
+		s.sut = nil
+	}
+}
+
+func (s *SimulatorSuite) start(steps ...Step) {
+	var err error
+	s.sut, err = New(Config{
+		Certificate: s.serverCert,
+		ShipID:      "Simulator",
+		Scenario:    &Scenario{Name: "test", Steps: steps},
+	})
+	assert.Nil(s.T(), err)
+
+	err = s.sut.Start()
+	assert.Nil(s.T(), err)
+}
+
+func (s *SimulatorSuite) dial(opts ship.Options) (*ship.Conn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts.LocalShipID = "Client"
+	opts.RemoteSKI = s.sut.SKI()
+	opts.Trust = func(string) bool { return true }
+
+	port := s.sut.Addr().(*net.TCPAddr).Port
+
+	return ship.Dial(ctx, fmt.Sprintf("127.0.0.1:%d", port), s.clientCert, opts)
+}
+
+func (s *SimulatorSuite) Test_New() {
+	_, err := New(Config{Certificate: s.serverCert, Scenario: &Scenario{}})
+	assert.NotNil(s.T(), err)
+
+	_, err = New(Config{Certificate: s.serverCert, ShipID: "id"})
+	assert.ErrorIs(s.T(), err, ErrInvalidScenario)
+
+	_, err = New(Config{Certificate: s.serverCert, ShipID: "id", Scenario: &Scenario{Steps: []Step{{Action: "invalid"}}}})
+	assert.ErrorIs(s.T(), err, ErrInvalidScenario)
+
+	_, err = New(Config{ShipID: "id", Scenario: &Scenario{}})
+	assert.NotNil(s.T(), err)
+
+	timings := ship.DefaultHandshakeTimings()
+	timings.HelloInit = 0
+	_, err = New(Config{Certificate: s.serverCert, ShipID: "id", Scenario: &Scenario{}, HandshakeTimings: &timings})
+	assert.NotNil(s.T(), err)
+
+	sut, err := New(Config{Certificate: s.serverCert, ShipID: "id", Scenario: &Scenario{}})
+	assert.Nil(s.T(), err)
+	assert.Nil(s.T(), sut.Addr())
+	assert.Equal(s.T(), 40, len(sut.SKI()))
+}
+
+func (s *SimulatorSuite) Test_AutoAcceptEcho() {
+	s.start(Step{Action: ActionAutoAccept}, Step{Action: ActionEcho})
+
+	conn, err := s.dial(ship.Options{RemoteShipID: "Simulator"})
+	if !assert.Nil(s.T(), err) {
+		return
+	}
+	defer conn.Close()
+
+	// give the echo step time to run
+	time.Sleep(100 * time.Millisecond)
+
+	err = conn.Send([]byte(testPayload))
+	assert.Nil(s.T(), err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	payload, err := conn.Receive(ctx)
+	assert.Nil(s.T(), err)
+	assert.JSONEq(s.T(), testPayload, string(payload))
+}
+
+func (s *SimulatorSuite) Test_PendingAccept() {
+	s.start(Step{Action: ActionPending, Duration: Duration(time.Second)}, Step{Action: ActionAccept})
+
+	start := time.Now()
+	conn, err := s.dial(ship.Options{})
+	if !assert.Nil(s.T(), err) {
+		return
+	}
+	defer conn.Close()
+
+	assert.GreaterOrEqual(s.T(), time.Since(start), time.Second)
+	assert.Equal(s.T(), "Simulator", conn.RemoteShipID())
+}
+
+func (s *SimulatorSuite) Test_Abort() {
+	s.start(Step{Action: ActionAbort})
+
+	_, err := s.dial(ship.Options{})
+	assert.ErrorIs(s.T(), err, ship.ErrHandshakeAborted)
+}
+
+func (s *SimulatorSuite) Test_Close() {
+	s.start(Step{Action: ActionPending, Duration: Duration(200 * time.Millisecond)}, Step{Action: ActionClose})
+
+	_, err := s.dial(ship.Options{})
+	assert.NotNil(s.T(), err)
+}
+
+func (s *SimulatorSuite) Test_MalformedProtocolHandshake() {
+	s.start(Step{Action: ActionAutoAccept}, Step{Action: ActionMalformedProtocolHandshake})
+
+	_, err := s.dial(ship.Options{})
+	assert.NotNil(s.T(), err)
+}
+
+func (s *SimulatorSuite) Test_ShipIDMismatch() {
+	s.start(Step{Action: ActionAutoAccept}, Step{Action: ActionShipIDMismatch})
+
+	_, err := s.dial(ship.Options{RemoteShipID: "Simulator"})
+	assert.NotNil(s.T(), err)
+}
@@ -0,0 +1,95 @@
+package ws
+
+import (
+	"sync"
+	"time"
+
+	"github.com/enbility/ship-go/api"
+)
+
+// number of round trip time measurements used for the rolling statistic
+const latencySamples = 10
+
+// rolling round trip time statistic based on ping/pong messages
+type latencyStatistic struct {
+	// ring buffer of the most recent measurements
+	samples []time.Duration
+	next    int
+
+	// the time the pending ping was sent, zero if no ping is pending
+	pingSentAt time.Time
+
+	// number of consecutive pings without a pong
+	missedPongs uint
+
+	mux sync.Mutex
+}
+
+// register a sent ping
+//
+// returns the number of consecutive pings without a pong
+func (l *latencyStatistic) pingSent(now time.Time) uint {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	if !l.pingSentAt.IsZero() {
+		l.missedPongs++
+	}
+	l.pingSentAt = now
+
+	return l.missedPongs
+}
+
+// register a received pong
+//
+// returns the round trip time and false if no ping was pending
+func (l *latencyStatistic) pongReceived(now time.Time) (time.Duration, bool) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	if l.pingSentAt.IsZero() {
+		return 0, false
+	}
+
+	rtt := now.Sub(l.pingSentAt)
+	l.pingSentAt = time.Time{}
+	l.missedPongs = 0
+
+	if len(l.samples) < latencySamples {
+		l.samples = append(l.samples, rtt)
+	} else {
+		l.samples[l.next] = rtt
+	}
+	l.next = (l.next + 1) % latencySamples
+
+	return rtt, true
+}
+
+// return the current statistic
+func (l *latencyStatistic) statistic() api.WebsocketLatency {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	result := api.WebsocketLatency{
+		Samples:     len(l.samples),
+		MissedPongs: l.missedPongs,
+	}
+
+	if len(l.samples) == 0 {
+		return result
+	}
+
+	result.Last = l.samples[(l.next+latencySamples-1)%latencySamples]
+	result.Min = l.samples[0]
+	result.Max = l.samples[0]
+
+	var sum time.Duration
+	for _, sample := range l.samples {
+		sum += sample
+		result.Min = min(result.Min, sample)
+		result.Max = max(result.Max, sample)
+	}
+	result.Average = sum / time.Duration(len(l.samples))
+
+	return result
+}
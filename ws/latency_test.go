@@ -0,0 +1,80 @@
+package ws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestLatencySuite(t *testing.T) {
+	suite.Run(t, new(LatencySuite))
+}
+
+type LatencySuite struct {
+	suite.Suite
+
+	sut *latencyStatistic
+}
+
+func (s *LatencySuite) BeforeTest(suiteName, testName string) {
+	s.sut = &latencyStatistic{}
+}
+
+func (s *LatencySuite) Test_NoSamples() {
+	_, ok := s.sut.pongReceived(time.Now())
+	assert.False(s.T(), ok)
+
+	stat := s.sut.statistic()
+	assert.Equal(s.T(), 0, stat.Samples)
+	assert.Equal(s.T(), time.Duration(0), stat.Average)
+}
+
+func (s *LatencySuite) Test_Statistic() {
+	now := time.Now()
+
+	for i := 1; i <= 3; i++ {
+		missed := s.sut.pingSent(now)
+		assert.Equal(s.T(), uint(0), missed)
+
+		rtt, ok := s.sut.pongReceived(now.Add(time.Duration(i) * 10 * time.Millisecond))
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), time.Duration(i)*10*time.Millisecond, rtt)
+	}
+
+	stat := s.sut.statistic()
+	assert.Equal(s.T(), 3, stat.Samples)
+	assert.Equal(s.T(), 30*time.Millisecond, stat.Last)
+	assert.Equal(s.T(), 10*time.Millisecond, stat.Min)
+	assert.Equal(s.T(), 30*time.Millisecond, stat.Max)
+	assert.Equal(s.T(), 20*time.Millisecond, stat.Average)
+	assert.Equal(s.T(), uint(0), stat.MissedPongs)
+}
+
+func (s *LatencySuite) Test_RollingWindow() {
+	now := time.Now()
+
+	for i := 1; i <= latencySamples+5; i++ {
+		s.sut.pingSent(now)
+		_, _ = s.sut.pongReceived(now.Add(time.Duration(i) * time.Millisecond))
+	}
+
+	stat := s.sut.statistic()
+	assert.Equal(s.T(), latencySamples, stat.Samples)
+	assert.Equal(s.T(), time.Duration(latencySamples+5)*time.Millisecond, stat.Last)
+	assert.Equal(s.T(), 6*time.Millisecond, stat.Min)
+}
+
+func (s *LatencySuite) Test_MissedPongs() {
+	now := time.Now()
+
+	assert.Equal(s.T(), uint(0), s.sut.pingSent(now))
+	assert.Equal(s.T(), uint(1), s.sut.pingSent(now))
+	assert.Equal(s.T(), uint(2), s.sut.pingSent(now))
+	assert.Equal(s.T(), uint(2), s.sut.statistic().MissedPongs)
+
+	_, ok := s.sut.pongReceived(now)
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), uint(0), s.sut.statistic().MissedPongs)
+}
@@ -0,0 +1,172 @@
+package ws
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// The direction of a recorded websocket frame
+type FrameDirection string
+
+const (
+	FrameDirectionIncoming FrameDirection = "in"  // received from the remote service
+	FrameDirectionOutgoing FrameDirection = "out" // sent to the remote service
+)
+
+// A websocket frame of a recording, stored as a single JSON line
+type RecordedFrame struct {
+	Direction FrameDirection `json:"direction"`
+	Timestamp time.Time      `json:"timestamp"`
+
+	// the SHIP message type byte, e.g. model.MsgTypeData
+	MessageType byte `json:"type"`
+
+	// the frame without the SHIP message type byte
+	Payload string `json:"payload,omitempty"`
+
+	// the frame without the SHIP message type byte, used instead of Payload if it is no valid UTF-8
+	PayloadBase64 string `json:"payloadBase64,omitempty"`
+}
+
+// Create a recorded frame for a SHIP message
+func NewRecordedFrame(direction FrameDirection, timestamp time.Time, message []byte) RecordedFrame {
+	frame := RecordedFrame{
+		Direction: direction,
+		Timestamp: timestamp,
+	}
+
+	if len(message) == 0 {
+		return frame
+	}
+
+	frame.MessageType = message[0]
+	if payload := message[1:]; utf8.Valid(payload) {
+		frame.Payload = string(payload)
+	} else {
+		frame.PayloadBase64 = base64.StdEncoding.EncodeToString(payload)
+	}
+
+	return frame
+}
+
+// Returns the SHIP message of the frame, including the message type byte
+func (f RecordedFrame) Message() ([]byte, error) {
+	payload := []byte(f.Payload)
+
+	if f.PayloadBase64 != "" {
+		var err error
+		if payload, err = base64.StdEncoding.DecodeString(f.PayloadBase64); err != nil {
+			return nil, err
+		}
+	}
+
+	return append([]byte{f.MessageType}, payload...), nil
+}
+
+// Writes every frame of a websocket connection as a JSON line
+type FrameRecorder struct {
+	encoder *json.Encoder
+	closer  io.Closer
+
+	mux sync.Mutex
+}
+
+// Create a recorder writing to w
+func NewFrameRecorder(w io.Writer) *FrameRecorder {
+	recorder := &FrameRecorder{
+		encoder: json.NewEncoder(w),
+	}
+
+	if closer, ok := w.(io.Closer); ok {
+		recorder.closer = closer
+	}
+
+	return recorder
+}
+
+// Create a recorder writing to a new file, an existing file is truncated
+func NewFileFrameRecorder(path string) (*FrameRecorder, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewFrameRecorder(file), nil
+}
+
+// Record a SHIP message
+func (r *FrameRecorder) Record(direction FrameDirection, message []byte) error {
+	frame := NewRecordedFrame(direction, time.Now(), message)
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if r.encoder == nil {
+		return errors.New("recorder is closed")
+	}
+
+	return r.encoder.Encode(frame)
+}
+
+// Stop recording and close the underlying writer if it is an io.Closer
+func (r *FrameRecorder) Close() error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	r.encoder = nil
+
+	if r.closer == nil {
+		return nil
+	}
+
+	closer := r.closer
+	r.closer = nil
+
+	return closer.Close()
+}
+
+// Read all frames of a recording
+func ReadRecording(r io.Reader) ([]RecordedFrame, error) {
+	var frames []RecordedFrame
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var frame RecordedFrame
+		if err := json.Unmarshal(line, &frame); err != nil {
+			return nil, err
+		}
+
+		frames = append(frames, frame)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return frames, nil
+}
+
+// Read all frames of a recording file
+func ReadRecordingFile(path string) ([]RecordedFrame, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ReadRecording(file)
+}
@@ -0,0 +1,91 @@
+package ws
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordedFrame(t *testing.T) {
+	now := time.Now()
+
+	frame := NewRecordedFrame(FrameDirectionIncoming, now, []byte{1, '{', '}'})
+	assert.Equal(t, byte(1), frame.MessageType)
+	assert.Equal(t, "{}", frame.Payload)
+	assert.Equal(t, "", frame.PayloadBase64)
+
+	message, err := frame.Message()
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{1, '{', '}'}, message)
+
+	frame = NewRecordedFrame(FrameDirectionOutgoing, now, []byte{2, 0xff, 0xfe})
+	assert.Equal(t, "", frame.Payload)
+	assert.NotEqual(t, "", frame.PayloadBase64)
+
+	message, err = frame.Message()
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{2, 0xff, 0xfe}, message)
+
+	frame = NewRecordedFrame(FrameDirectionOutgoing, now, nil)
+	message, err = frame.Message()
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0}, message)
+
+	frame.PayloadBase64 = "invalid!"
+	_, err = frame.Message()
+	assert.NotNil(t, err)
+}
+
+func TestFrameRecorder(t *testing.T) {
+	var buffer bytes.Buffer
+	recorder := NewFrameRecorder(&buffer)
+
+	err := recorder.Record(FrameDirectionOutgoing, []byte{0, 0})
+	assert.Nil(t, err)
+	err = recorder.Record(FrameDirectionIncoming, []byte{1, 0xff})
+	assert.Nil(t, err)
+
+	err = recorder.Close()
+	assert.Nil(t, err)
+	err = recorder.Record(FrameDirectionIncoming, []byte{0, 0})
+	assert.NotNil(t, err)
+
+	frames, err := ReadRecording(&buffer)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(frames))
+
+	message, err := frames[1].Message()
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{1, 0xff}, message)
+
+	_, err = ReadRecording(strings.NewReader("invalid\n"))
+	assert.NotNil(t, err)
+}
+
+func TestFileFrameRecorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recording.jsonl")
+
+	recorder, err := NewFileFrameRecorder(path)
+	assert.Nil(t, err)
+
+	err = recorder.Record(FrameDirectionOutgoing, []byte{0, 0})
+	assert.Nil(t, err)
+	err = recorder.Close()
+	assert.Nil(t, err)
+	err = recorder.Close()
+	assert.Nil(t, err)
+
+	frames, err := ReadRecordingFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(frames))
+
+	_, err = ReadRecordingFile(filepath.Join(t.TempDir(), "missing.jsonl"))
+	assert.NotNil(t, err)
+
+	_, err = NewFileFrameRecorder(filepath.Join(t.TempDir(), "missing", "recording.jsonl"))
+	assert.NotNil(t, err)
+}
@@ -1,6 +1,10 @@
 package ws
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 const (
 	writeWait = 10 * time.Second
@@ -13,3 +17,80 @@ const (
 	// SHIP 9.2: Set maximum fragment length to 1024 bytes
 	MaxMessageSize = 1024
 )
+
+// Timer values used by a websocket connection
+//
+// The default values are provided by DefaultTimings
+type Timings struct {
+	// Time allowed to write a message to the peer
+	WriteWait time.Duration
+
+	// Time allowed to read the next pong message from the peer, has to be greater than PingPeriod
+	PongWait time.Duration
+
+	// Send pings to peer with this period
+	PingPeriod time.Duration
+}
+
+// Returns the websocket timer values used if nothing else is configured
+func DefaultTimings() Timings {
+	return Timings{
+		WriteWait:  writeWait,
+		PongWait:   pongWait,
+		PingPeriod: pingPeriod,
+	}
+}
+
+// ErrInvalidTimings is returned if the websocket timer values can not be used
+var ErrInvalidTimings = errors.New("invalid websocket timings")
+
+// Check if the timer values can be used for a websocket connection
+func (t Timings) Validate() error {
+	if t.WriteWait <= 0 || t.PingPeriod <= 0 {
+		return ErrInvalidTimings
+	}
+
+	// the read deadline is extended by pongs, so it has to last longer than the ping interval
+	if t.PongWait <= t.PingPeriod {
+		return ErrInvalidTimings
+	}
+
+	return nil
+}
+
+// Limits for the round trip time measured via ping/pong messages
+//
+// A value of 0 disables the corresponding check
+type LatencyThresholds struct {
+	// The maximum accepted round trip time of a ping
+	MaxRoundTrip time.Duration
+
+	// The maximum accepted number of consecutive pings without a pong
+	MaxMissedPongs uint
+
+	// Close the connection if a limit is exceeded, otherwise only a log message is written
+	Disconnect bool
+}
+
+// ErrInvalidLatencyThresholds is returned if a latency limit can not be reached with the websocket timings
+var ErrInvalidLatencyThresholds = errors.New("invalid latency thresholds")
+
+// Check if the limits can be reached with the timer values
+//
+// A ping is counted as missed when the next ping is sent without a pong being received,
+// but the read deadline closes the connection PongWait after the last pong.
+// So MaxMissedPongs can only be reached if MaxMissedPongs+1 pings are sent within PongWait
+func (l LatencyThresholds) Validate(timings Timings) error {
+	if l.MaxMissedPongs == 0 {
+		return nil
+	}
+
+	// conversions are safe, the first check keeps the multiplication from overflowing
+	if l.MaxMissedPongs >= uint(timings.PongWait/timings.PingPeriod) || // #nosec G115
+		time.Duration(l.MaxMissedPongs+1)*timings.PingPeriod >= timings.PongWait { // #nosec G115
+		return fmt.Errorf("%w: %d missed pongs can not be reached with a ping period of %s and a pong wait of %s",
+			ErrInvalidLatencyThresholds, l.MaxMissedPongs, timings.PingPeriod, timings.PongWait)
+	}
+
+	return nil
+}
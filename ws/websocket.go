@@ -37,6 +37,16 @@ type WebsocketConnection struct {
 
 	remoteSki string
 
+	// the timer values used for this connection
+	timings Timings
+
+	// the round trip time statistic and its limits
+	latency           latencyStatistic
+	latencyThresholds LatencyThresholds
+
+	// optional, records every SHIP message
+	recorder *FrameRecorder
+
 	muxConnClosed sync.Mutex
 	muxShipWrite  sync.Mutex
 	muxConWrite   sync.Mutex
@@ -49,6 +59,49 @@ func NewWebsocketConnection(conn *websocket.Conn, remoteSki string) *WebsocketCo
 		conn:                  conn,
 		remoteSki:             remoteSki,
 		connectionClosedError: nil,
+		timings:               DefaultTimings(),
+	}
+}
+
+// Set the timer values, has to be invoked before InitDataProcessing
+func (w *WebsocketConnection) SetTimings(timings Timings) error {
+	if err := timings.Validate(); err != nil {
+		return err
+	}
+
+	w.timings = timings
+
+	return nil
+}
+
+// Set the limits for the measured round trip times, has to be invoked after SetTimings and before InitDataProcessing
+//
+// returns an error wrapping ErrInvalidLatencyThresholds if a limit can not be reached with the timings
+func (w *WebsocketConnection) SetLatencyThresholds(thresholds LatencyThresholds) error {
+	if err := thresholds.Validate(w.timings); err != nil {
+		return err
+	}
+
+	w.latencyThresholds = thresholds
+
+	return nil
+}
+
+// Record every SHIP message of this connection, has to be invoked before InitDataProcessing
+//
+// The recorder is closed when the connection is closed
+func (w *WebsocketConnection) SetRecorder(recorder *FrameRecorder) {
+	w.recorder = recorder
+}
+
+// record a SHIP message if a recorder is set
+func (w *WebsocketConnection) record(direction FrameDirection, message []byte) {
+	if w.recorder == nil {
+		return
+	}
+
+	if err := w.recorder.Record(direction, message); err != nil {
+		logging.Log().Debug(w.remoteSki, "error recording message:", err)
 	}
 }
 
@@ -89,7 +142,7 @@ func (w *WebsocketConnection) run() {
 
 // writePump pumps messages from the SPINE and SHIP writeChannels to the websocket connection
 func (w *WebsocketConnection) writeShipPump() {
-	ticker := time.NewTicker(pingPeriod)
+	ticker := time.NewTicker(w.timings.PingPeriod)
 	defer func() {
 		ticker.Stop()
 		w.closeShipWriteChannel()
@@ -113,13 +166,15 @@ func (w *WebsocketConnection) writeShipPump() {
 			}
 
 			w.muxConWrite.Lock()
-			_ = w.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			_ = w.conn.SetWriteDeadline(time.Now().Add(w.timings.WriteWait))
 			w.muxConWrite.Unlock()
 
 			if !w.writeMessage(websocket.BinaryMessage, message) {
 				return
 			}
 
+			w.record(FrameDirectionOutgoing, message)
+
 			text := w.textFromMessage(message)
 			logging.Log().Trace("Send:", w.remoteSki, text)
 
@@ -134,12 +189,54 @@ func (w *WebsocketConnection) handlePing() {
 		return
 	}
 
+	missedPongs := w.latency.pingSent(time.Now())
+	if limit := w.latencyThresholds.MaxMissedPongs; limit > 0 && missedPongs >= limit {
+		err := fmt.Errorf("%d consecutive pings without pong", missedPongs)
+		if err = w.latencyThresholdExceeded(err); err != nil {
+			w.close()
+			w.setConnClosedError(err)
+			w.dataProcessing.ReportConnectionError(err)
+			return
+		}
+	}
+
 	w.muxConWrite.Lock()
-	_ = w.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	_ = w.conn.SetWriteDeadline(time.Now().Add(w.timings.WriteWait))
 	w.muxConWrite.Unlock()
 	_ = w.writeMessage(websocket.PingMessage, nil)
 }
 
+// process a received pong, returning an error closes the connection
+func (w *WebsocketConnection) handlePong(string) error {
+	_ = w.conn.SetReadDeadline(time.Now().Add(w.timings.PongWait))
+
+	rtt, ok := w.latency.pongReceived(time.Now())
+	if !ok {
+		return nil
+	}
+
+	logging.Log().Trace(w.remoteSki, "ping round trip time:", rtt)
+
+	if limit := w.latencyThresholds.MaxRoundTrip; limit > 0 && rtt > limit {
+		return w.latencyThresholdExceeded(fmt.Errorf("ping round trip time %s exceeds %s", rtt, limit))
+	}
+
+	return nil
+}
+
+// report an exceeded latency limit
+//
+// returns the error if the connection should be closed
+func (w *WebsocketConnection) latencyThresholdExceeded(err error) error {
+	if !w.latencyThresholds.Disconnect {
+		logging.Log().Info(w.remoteSki, "websocket latency warning:", err)
+		return nil
+	}
+
+	logging.Log().Info(w.remoteSki, "closing connection due to websocket latency:", err)
+	return err
+}
+
 func (w *WebsocketConnection) closeWithError(err error, reason string) {
 	logging.Log().Debug(w.remoteSki, reason, err)
 	w.setConnClosedError(err)
@@ -148,8 +245,8 @@ func (w *WebsocketConnection) closeWithError(err error, reason string) {
 
 // readShipPump checks for messages from the websocket connection
 func (w *WebsocketConnection) readShipPump() {
-	_ = w.conn.SetReadDeadline(time.Now().Add(pongWait))
-	w.conn.SetPongHandler(func(string) error { _ = w.conn.SetReadDeadline(time.Now().Add(pongWait)); return nil })
+	_ = w.conn.SetReadDeadline(time.Now().Add(w.timings.PongWait))
+	w.conn.SetPongHandler(w.handlePong)
 
 	for {
 		select {
@@ -175,6 +272,8 @@ func (w *WebsocketConnection) readShipPump() {
 				return
 			}
 
+			w.record(FrameDirectionIncoming, message)
+
 			text := w.textFromMessage(message)
 			logging.Log().Trace("Recv:", w.remoteSki, text)
 
@@ -233,15 +332,23 @@ func (w *WebsocketConnection) close() {
 
 		w.setConnClosedError(nil)
 
-		close(w.closeChannel)
+		// the channel only exists once data processing was started
+		if w.closeChannel != nil {
+			close(w.closeChannel)
+		}
 
 		if w.conn != nil {
 			_ = w.conn.Close()
 		}
+
+		if w.recorder != nil {
+			_ = w.recorder.Close()
+		}
 	})
 }
 
 var _ api.WebsocketDataWriterInterface = (*WebsocketConnection)(nil)
+var _ api.WebsocketLatencyInterface = (*WebsocketConnection)(nil)
 
 func (w *WebsocketConnection) InitDataProcessing(dataProcessing api.WebsocketDataReaderInterface) {
 	w.dataProcessing = dataProcessing
@@ -291,6 +398,11 @@ func (w *WebsocketConnection) writeMessageWithoutErrorHandling(messageType int,
 	return w.conn.WriteMessage(messageType, data)
 }
 
+// return the round trip time statistic of the connection
+func (w *WebsocketConnection) Latency() api.WebsocketLatency {
+	return w.latency.statistic()
+}
+
 // shutdown the connection and all internals
 func (w *WebsocketConnection) CloseDataConnection(closeCode int, reason string) {
 	// send a close message to the remote side if we have a reason
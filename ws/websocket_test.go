@@ -1,6 +1,7 @@
 package ws
 
 import (
+	"bytes"
 	"errors"
 	"log"
 	"net/http"
@@ -159,6 +160,161 @@ func (s *WebsocketSuite) TestCloseWithError() {
 	assert.NotNil(s.T(), err)
 }
 
+func (s *WebsocketSuite) TestLatency() {
+	latency := s.sut.Latency()
+	assert.Equal(s.T(), 0, latency.Samples)
+
+	s.sut.handlePing()
+
+	// the test server answers the ping while reading
+	time.Sleep(time.Millisecond * 200)
+
+	latency = s.sut.Latency()
+	assert.Equal(s.T(), 1, latency.Samples)
+	assert.Equal(s.T(), uint(0), latency.MissedPongs)
+
+	isClosed, err := s.sut.IsDataConnectionClosed()
+	assert.Equal(s.T(), false, isClosed)
+	assert.Nil(s.T(), err)
+}
+
+func (s *WebsocketSuite) TestLatencyThresholds() {
+	err := s.sut.SetLatencyThresholds(LatencyThresholds{
+		MaxRoundTrip: time.Nanosecond,
+	})
+	assert.Nil(s.T(), err)
+
+	s.sut.latency.pingSent(time.Now().Add(-time.Second))
+	err = s.sut.handlePong("")
+	assert.Nil(s.T(), err)
+
+	err = s.sut.SetLatencyThresholds(LatencyThresholds{
+		MaxRoundTrip: time.Nanosecond,
+		Disconnect:   true,
+	})
+	assert.Nil(s.T(), err)
+
+	s.sut.latency.pingSent(time.Now().Add(-time.Second))
+	err = s.sut.handlePong("")
+	assert.NotNil(s.T(), err)
+
+	// a pong without a pending ping is ignored
+	err = s.sut.handlePong("")
+	assert.Nil(s.T(), err)
+}
+
+func (s *WebsocketSuite) TestLatencyMissedPongs() {
+	// the remote service does not read, so it does not answer pings
+	server, response, conn := newWSServer(s.T(), &silentServer{})
+	defer server.Close()
+	defer response.Body.Close()
+
+	sut := NewWebsocketConnection(conn, "remoteSki")
+	err := sut.SetTimings(Timings{
+		WriteWait:  time.Second,
+		PongWait:   time.Second,
+		PingPeriod: 50 * time.Millisecond,
+	})
+	assert.Nil(s.T(), err)
+	err = sut.SetLatencyThresholds(LatencyThresholds{
+		MaxMissedPongs: 3,
+		Disconnect:     true,
+	})
+	assert.Nil(s.T(), err)
+
+	sut.InitDataProcessing(s.wsDataReader)
+
+	// the connection is closed due to the missed pongs before the read deadline expires
+	assert.Eventually(s.T(), func() bool {
+		isClosed, _ := sut.IsDataConnectionClosed()
+		return isClosed
+	}, 900*time.Millisecond, 10*time.Millisecond)
+
+	_, err = sut.IsDataConnectionClosed()
+	if assert.NotNil(s.T(), err) {
+		assert.Contains(s.T(), err.Error(), "3 consecutive pings without pong")
+	}
+}
+
+func (s *WebsocketSuite) TestCloseBeforeDataProcessing() {
+	server, response, conn := newWSServer(s.T(), &silentServer{})
+	defer server.Close()
+	defer response.Body.Close()
+
+	sut := NewWebsocketConnection(conn, "remoteSki")
+	sut.CloseDataConnection(0, "")
+
+	isClosed, _ := sut.IsDataConnectionClosed()
+	assert.True(s.T(), isClosed)
+}
+
+func (s *WebsocketSuite) TestLatencyThresholdsValidate() {
+	// the read deadline closes the connection before a second ping is sent
+	err := s.sut.SetLatencyThresholds(LatencyThresholds{MaxMissedPongs: 1})
+	assert.ErrorIs(s.T(), err, ErrInvalidLatencyThresholds)
+
+	timings := DefaultTimings()
+	timings.PingPeriod = 15 * time.Second
+	assert.Nil(s.T(), LatencyThresholds{MaxMissedPongs: 2}.Validate(timings))
+	assert.Nil(s.T(), LatencyThresholds{MaxMissedPongs: 3}.Validate(Timings{PongWait: 61 * time.Second, PingPeriod: 15 * time.Second}))
+	assert.ErrorIs(s.T(), LatencyThresholds{MaxMissedPongs: 3}.Validate(timings), ErrInvalidLatencyThresholds)
+	assert.ErrorIs(s.T(), LatencyThresholds{MaxMissedPongs: ^uint(0)}.Validate(timings), ErrInvalidLatencyThresholds)
+
+	assert.Nil(s.T(), LatencyThresholds{MaxRoundTrip: time.Second}.Validate(DefaultTimings()))
+}
+
+func (s *WebsocketSuite) TestSetTimings() {
+	assert.Nil(s.T(), DefaultTimings().Validate())
+
+	sut := NewWebsocketConnection(nil, "remoteSki")
+	assert.Equal(s.T(), DefaultTimings(), sut.timings)
+
+	timings := Timings{
+		WriteWait:  time.Second,
+		PongWait:   2 * time.Second,
+		PingPeriod: time.Second,
+	}
+	err := sut.SetTimings(timings)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), timings, sut.timings)
+
+	timings.PongWait = timings.PingPeriod
+	err = sut.SetTimings(timings)
+	assert.ErrorIs(s.T(), err, ErrInvalidTimings)
+
+	timings = DefaultTimings()
+	timings.WriteWait = 0
+	err = sut.SetTimings(timings)
+	assert.ErrorIs(s.T(), err, ErrInvalidTimings)
+}
+
+func (s *WebsocketSuite) TestRecorder() {
+	var buffer bytes.Buffer
+	s.sut.SetRecorder(NewFrameRecorder(&buffer))
+
+	msg := []byte{1}
+	msg = append(msg, []byte("message")...)
+	err := s.sut.WriteMessageToWebsocketConnection(msg)
+	assert.Nil(s.T(), err)
+
+	// make sure we have enough time to read and write
+	time.Sleep(time.Millisecond * 500)
+
+	s.sut.CloseDataConnection(450, "User Close")
+
+	frames, err := ReadRecording(&buffer)
+	assert.Nil(s.T(), err)
+	if assert.Equal(s.T(), 2, len(frames)) {
+		assert.Equal(s.T(), FrameDirectionOutgoing, frames[0].Direction)
+		assert.Equal(s.T(), FrameDirectionIncoming, frames[1].Direction)
+		assert.Equal(s.T(), byte(1), frames[1].MessageType)
+		assert.Equal(s.T(), "message", frames[1].Payload)
+	}
+
+	err = s.sut.recorder.Record(FrameDirectionOutgoing, msg)
+	assert.NotNil(s.T(), err)
+}
+
 var upgrader = websocket.Upgrader{}
 
 func newWSServer(t *testing.T, h http.Handler) (*httptest.Server, *http.Response, *websocket.Conn) {
@@ -203,3 +359,18 @@ func (s *testServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 }
+
+// a websocket server which does not read, so pings are not answered
+type silentServer struct {
+}
+
+func (s *silentServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Println("upgrade:", err)
+		return
+	}
+	defer ws.Close()
+
+	<-r.Context().Done()
+}